@@ -6,7 +6,7 @@ import (
 
 // LoginReq defines the request structure for user login.
 type LoginReq struct {
-	g.Meta   `path:"/auth/login" method:"post" summary:"User login" tags:"Authentication"`
+	g.Meta   `path:"/auth/login" method:"post" public:"true" summary:"User login" tags:"Authentication"`
 	Username string `json:"username" v:"required#Username is required"`
 	Password string `json:"password" v:"required#Password is required"`
 }
@@ -27,7 +27,7 @@ type LoginRes struct {
 
 // RefreshTokenReq defines the request structure for refreshing access token.
 type RefreshTokenReq struct {
-	g.Meta       `path:"/auth/refresh" method:"post" summary:"Refresh access token" tags:"Authentication"`
+	g.Meta       `path:"/auth/refresh" method:"post" public:"true" summary:"Refresh access token" tags:"Authentication"`
 	RefreshToken string `json:"refreshToken"`
 }
 
@@ -13,4 +13,17 @@ import (
 // IMenuV1 defines the menu controller interface.
 type IMenuV1 interface {
 	All(ctx context.Context, req *v1.MenuAllReq) (res v1.MenuAllRes, err error)
+	List(ctx context.Context, req *v1.MenuListReq) (res v1.MenuListRes, err error)
+	NameExists(ctx context.Context, req *v1.MenuNameExistsReq) (res v1.MenuExistsRes, err error)
+	PathExists(ctx context.Context, req *v1.MenuPathExistsReq) (res v1.MenuExistsRes, err error)
+	Create(ctx context.Context, req *v1.MenuCreateReq) (res *v1.MenuCreateRes, err error)
+	Update(ctx context.Context, req *v1.MenuUpdateReq) (res *v1.MenuUpdateRes, err error)
+	Delete(ctx context.Context, req *v1.MenuDeleteReq) (res *v1.MenuDeleteRes, err error)
+	Move(ctx context.Context, req *v1.MenuMoveReq) (res v1.MenuMoveRes, err error)
+	Export(ctx context.Context, req *v1.MenuExportReq) (res *v1.MenuExportRes, err error)
+	Import(ctx context.Context, req *v1.MenuImportReq) (res *v1.MenuImportRes, err error)
+	RevisionList(ctx context.Context, req *v1.MenuRevisionListReq) (res *v1.MenuRevisionListRes, err error)
+	History(ctx context.Context, req *v1.MenuHistoryReq) (res *v1.MenuRevisionListRes, err error)
+	Restore(ctx context.Context, req *v1.MenuRestoreReq) (res v1.MenuRestoreRes, err error)
+	RestoreTree(ctx context.Context, req *v1.MenuRestoreTreeReq) (res v1.MenuRestoreRes, err error)
 }
@@ -1,25 +1,50 @@
 package v1
 
-import "github.com/gogf/gf/v2/frame/g"
+import (
+	"encoding/json"
 
-// MenuMeta describes menu metadata expected by the frontend.
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// MenuMeta describes menu metadata expected by the frontend, mirroring the
+// RouteMeta of vben. Keys it does not model are kept in Extra and written
+// back when the meta is encoded. FullPathKey is a pointer because vben
+// defaults it to true.
 type MenuMeta struct {
-	Icon                     string `json:"icon,omitempty"`
-	Title                    string `json:"title,omitempty"`
-	Order                    int    `json:"order,omitempty"`
-	AffixTab                 bool   `json:"affixTab,omitempty"`
-	Badge                    string `json:"badge,omitempty"`
-	BadgeType                string `json:"badgeType,omitempty"`
-	BadgeVariants            string `json:"badgeVariants,omitempty"`
-	IframeSrc                string `json:"iframeSrc,omitempty"`
-	Link                     string `json:"link,omitempty"`
-	MenuVisibleWithForbidden bool   `json:"menuVisibleWithForbidden,omitempty"`
+	Icon                     string                     `json:"icon,omitempty"`
+	ActiveIcon               string                     `json:"activeIcon,omitempty"`
+	Title                    string                     `json:"title,omitempty"`
+	Order                    int                        `json:"order,omitempty"`
+	AffixTab                 bool                       `json:"affixTab,omitempty"`
+	Badge                    string                     `json:"badge,omitempty"`
+	BadgeType                string                     `json:"badgeType,omitempty"`
+	BadgeVariants            string                     `json:"badgeVariants,omitempty"`
+	IframeSrc                string                     `json:"iframeSrc,omitempty"`
+	Link                     string                     `json:"link,omitempty"`
+	OpenInNewWindow          bool                       `json:"openInNewWindow,omitempty"`
+	MenuVisibleWithForbidden bool                       `json:"menuVisibleWithForbidden,omitempty"`
+	KeepAlive                bool                       `json:"keepAlive,omitempty"`
+	HideInMenu               bool                       `json:"hideInMenu,omitempty"`
+	HideInTab                bool                       `json:"hideInTab,omitempty"`
+	HideInBreadcrumb         bool                       `json:"hideInBreadcrumb,omitempty"`
+	HideChildrenInMenu       bool                       `json:"hideChildrenInMenu,omitempty"`
+	ActivePath               string                     `json:"activePath,omitempty"`
+	Query                    map[string]any             `json:"query,omitempty"`
+	Authority                []string                   `json:"authority,omitempty"`
+	IgnoreAccess             bool                       `json:"ignoreAccess,omitempty"`
+	NoBasicLayout            bool                       `json:"noBasicLayout,omitempty"`
+	MaxNumOfOpenTab          int                        `json:"maxNumOfOpenTab,omitempty"`
+	FullPathKey              *bool                      `json:"fullPathKey,omitempty"`
+	Extra                    map[string]json.RawMessage `json:"-"`
 }
 
 // MenuItem represents a backend menu item for dynamic routing.
+// Id and Pid are sys_menu ids, so they can be passed back to the system menu
+// endpoints.
 type MenuItem struct {
-	Id        int         `json:"id,omitempty"`
-	Pid       int         `json:"pid,omitempty"`
+	Id        string      `json:"id,omitempty"`
+	Pid       string      `json:"pid,omitempty"`
 	Name      string      `json:"name,omitempty"`
 	Path      string      `json:"path,omitempty"`
 	Component string      `json:"component,omitempty"`
@@ -38,3 +63,247 @@ type MenuAllReq struct {
 
 // MenuAllRes defines the response structure for fetching menu list.
 type MenuAllRes []*MenuItem
+
+// SystemMenuItem is a sys_menu row as managed on the system menu page,
+// including buttons and disabled entries. Meta.Order mirrors the order column
+// and Meta.HideInMenu the visible column.
+type SystemMenuItem struct {
+	Id        string            `json:"id"`
+	Pid       string            `json:"pid"`
+	Name      string            `json:"name"`
+	Path      string            `json:"path"`
+	Component string            `json:"component"`
+	Type      string            `json:"type"`
+	Status    int               `json:"status"`
+	AuthCode  string            `json:"authCode"`
+	Meta      *MenuMeta         `json:"meta,omitempty"`
+	Children  []*SystemMenuItem `json:"children,omitempty"`
+}
+
+// MenuInput carries the writable fields of a menu.
+type MenuInput struct {
+	Pid       string    `json:"pid"`
+	Name      string    `json:"name" v:"required|max-length:255#Name is required"`
+	Path      string    `json:"path" v:"max-length:255"`
+	Component string    `json:"component" v:"max-length:255"`
+	Type      string    `json:"type" v:"required|in:catalog,menu,button,embedded,link#Type is required|Type must be one of catalog, menu, button, embedded or link"`
+	Status    int       `json:"status" d:"1" v:"in:0,1"`
+	AuthCode  string    `json:"authCode" v:"max-length:255"`
+	Meta      *MenuMeta `json:"meta"`
+}
+
+// MenuListReq defines the request structure for listing the menu tree of the tenant.
+type MenuListReq struct {
+	g.Meta `path:"/system/menu/list" method:"get" perm:"System:Menu:List" summary:"List the menu tree with buttons" tags:"Menu"`
+}
+
+// MenuListRes defines the response structure for listing the menu tree.
+type MenuListRes []*SystemMenuItem
+
+// MenuNameExistsReq defines the request structure for checking whether a menu name is taken.
+// Id excludes the menu being edited.
+type MenuNameExistsReq struct {
+	g.Meta `path:"/system/menu/name-exists" method:"get" perm:"System:Menu:List" summary:"Check whether a menu name is taken" tags:"Menu"`
+	Name   string `json:"name" v:"required#Name is required"`
+	Id     string `json:"id"`
+}
+
+// MenuPathExistsReq defines the request structure for checking whether a menu path is taken.
+// Id excludes the menu being edited.
+type MenuPathExistsReq struct {
+	g.Meta `path:"/system/menu/path-exists" method:"get" perm:"System:Menu:List" summary:"Check whether a menu path is taken" tags:"Menu"`
+	Path   string `json:"path" v:"required#Path is required"`
+	Id     string `json:"id"`
+}
+
+// MenuExistsRes reports whether the name or path is taken.
+type MenuExistsRes bool
+
+// MenuCreateReq defines the request structure for creating a menu.
+type MenuCreateReq struct {
+	g.Meta `path:"/system/menu" method:"post" perm:"System:Menu:Create" summary:"Create a menu" tags:"Menu"`
+	MenuInput
+}
+
+// MenuCreateRes defines the response structure for creating a menu.
+type MenuCreateRes struct {
+	*SystemMenuItem
+}
+
+// MenuUpdateReq defines the request structure for updating a menu.
+type MenuUpdateReq struct {
+	g.Meta `path:"/system/menu/{id}" method:"put" perm:"System:Menu:Edit" summary:"Update a menu" tags:"Menu"`
+	Id     string `json:"id" in:"path" v:"required#Menu id is required"`
+	MenuInput
+}
+
+// MenuUpdateRes defines the response structure for updating a menu.
+type MenuUpdateRes struct {
+	*SystemMenuItem
+}
+
+// MenuDeleteReq defines the request structure for deleting a menu and its descendants.
+type MenuDeleteReq struct {
+	g.Meta `path:"/system/menu/{id}" method:"delete" perm:"System:Menu:Delete" summary:"Delete a menu and its descendants" tags:"Menu"`
+	Id     string `json:"id" in:"path" v:"required#Menu id is required"`
+}
+
+// MenuDeleteRes defines the response structure for deleting a menu.
+type MenuDeleteRes struct{}
+
+// MenuMoveItem places a menu under Pid at Position among its new siblings,
+// counted from 0. Positions past the end append the menu.
+type MenuMoveItem struct {
+	Id       string `json:"id" v:"required#Menu id is required"`
+	Pid      string `json:"pid"`
+	Position int    `json:"position" v:"min:0"`
+}
+
+// MenuMoveReq defines the request structure for moving and reordering menus.
+// Moves are applied in order and saved together.
+type MenuMoveReq struct {
+	g.Meta `path:"/system/menu/move" method:"post" perm:"System:Menu:Edit" summary:"Move and reorder menus" tags:"Menu"`
+	Moves  []MenuMoveItem `json:"moves" v:"required#Moves are required"`
+}
+
+// MenuMoveRes defines the response structure for moving menus, the updated menu tree.
+type MenuMoveRes []*SystemMenuItem
+
+// MenuBundle is a portable menu tree, identified by names and paths rather
+// than ids so it can be moved between tenants and environments.
+type MenuBundle struct {
+	Version int               `json:"version"`
+	Menus   []*MenuBundleItem `json:"menus"`
+}
+
+// MenuBundleItem is a menu of a bundle with its children and buttons.
+type MenuBundleItem struct {
+	Name      string            `json:"name"`
+	Path      string            `json:"path,omitempty"`
+	Component string            `json:"component,omitempty"`
+	Type      string            `json:"type"`
+	Status    int               `json:"status"`
+	AuthCode  string            `json:"authCode,omitempty"`
+	Meta      *MenuMeta         `json:"meta,omitempty"`
+	Children  []*MenuBundleItem `json:"children,omitempty"`
+}
+
+// MenuExportReq defines the request structure for exporting the menu tree of the tenant.
+type MenuExportReq struct {
+	g.Meta `path:"/system/menu/export" method:"get" perm:"System:Menu:List" summary:"Export the menu tree as a bundle" tags:"Menu"`
+	Format string `json:"format" d:"json" v:"in:json,yaml#Format must be json or yaml"`
+}
+
+// MenuExportRes defines the response structure for exporting menus, the encoded bundle.
+type MenuExportRes struct {
+	Format  string `json:"format"`
+	Content string `json:"content"`
+}
+
+// MenuImportReq defines the request structure for importing a bundle. Menus
+// are matched by name, then by path; matches are updated and the rest are
+// created. Menus missing from the bundle are left alone. Format is detected
+// from Content when empty.
+type MenuImportReq struct {
+	g.Meta  `path:"/system/menu/import" method:"post" perm:"System:Menu:Import" summary:"Import a menu bundle" tags:"Menu"`
+	Format  string `json:"format" v:"in:json,yaml#Format must be json or yaml"`
+	Content string `json:"content" v:"required#Content is required"`
+	DryRun  bool   `json:"dryRun"`
+}
+
+// MenuFieldChange is a column an import changes.
+type MenuFieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// MenuImportChange is a menu an import creates or updates.
+type MenuImportChange struct {
+	Action string             `json:"action"`
+	Name   string             `json:"name"`
+	Path   string             `json:"path,omitempty"`
+	Fields []*MenuFieldChange `json:"fields,omitempty"`
+}
+
+// MenuImportConflict is a bundle menu that cannot be imported as is.
+type MenuImportConflict struct {
+	Name   string `json:"name"`
+	Path   string `json:"path,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// MenuImportRes defines the response structure for importing menus. Nothing
+// is written on a dry run or when there are conflicts; Applied reports
+// whether the changes were saved. Kept lists the tenant customizations a
+// template sync leaves in place, with From the tenant's value and To the
+// template's.
+type MenuImportRes struct {
+	DryRun    bool                  `json:"dryRun"`
+	Applied   bool                  `json:"applied"`
+	Created   int                   `json:"created"`
+	Updated   int                   `json:"updated"`
+	Unchanged int                   `json:"unchanged"`
+	Changes   []*MenuImportChange   `json:"changes"`
+	Conflicts []*MenuImportConflict `json:"conflicts"`
+	Kept      []*MenuImportChange   `json:"kept,omitempty"`
+}
+
+// MenuRevisionItem is a recorded change of one menu. Before is null for a
+// created menu and After for a deleted one.
+type MenuRevisionItem struct {
+	Id         int64           `json:"id"`
+	MenuId     string          `json:"menuId"`
+	Action     string          `json:"action"`
+	OperatorId string          `json:"operatorId"`
+	Before     *SystemMenuItem `json:"before"`
+	After      *SystemMenuItem `json:"after"`
+	CreatedAt  *gtime.Time     `json:"createdAt"`
+}
+
+// MenuRevisionListReq defines the request structure for listing the menu revisions of the tenant, newest first.
+type MenuRevisionListReq struct {
+	g.Meta   `path:"/system/menu/revisions" method:"get" perm:"System:Menu:List" summary:"List the menu revisions of the tenant" tags:"Menu"`
+	Page     int `json:"page" d:"1" v:"min:1"`
+	PageSize int `json:"pageSize" d:"20" v:"between:1,100"`
+}
+
+// MenuHistoryReq defines the request structure for listing the revisions of one menu, newest first.
+// Deleted menus keep their history.
+type MenuHistoryReq struct {
+	g.Meta   `path:"/system/menu/{id}/revisions" method:"get" perm:"System:Menu:List" summary:"List the revisions of a menu" tags:"Menu"`
+	Id       string `json:"id" in:"path" v:"required#Menu id is required"`
+	Page     int    `json:"page" d:"1" v:"min:1"`
+	PageSize int    `json:"pageSize" d:"20" v:"between:1,100"`
+}
+
+// MenuRevisionListRes defines the response structure for listing menu revisions.
+type MenuRevisionListRes struct {
+	Items []*MenuRevisionItem `json:"items"`
+	Total int                 `json:"total"`
+}
+
+// MenuRestorePoint is a point in the revision history of the tenant: right
+// after revision RevisionId, or the time At when RevisionId is 0.
+type MenuRestorePoint struct {
+	RevisionId int64       `json:"revisionId" v:"min:0"`
+	At         *gtime.Time `json:"at"`
+}
+
+// MenuRestoreReq defines the request structure for restoring one menu as it
+// was at a point in time. A menu that did not exist then is deleted.
+type MenuRestoreReq struct {
+	g.Meta `path:"/system/menu/{id}/restore" method:"post" perm:"System:Menu:Restore" summary:"Restore a menu to an earlier revision" tags:"Menu"`
+	Id     string `json:"id" in:"path" v:"required#Menu id is required"`
+	MenuRestorePoint
+}
+
+// MenuRestoreTreeReq defines the request structure for restoring every menu
+// of the tenant as it was at a point in time.
+type MenuRestoreTreeReq struct {
+	g.Meta `path:"/system/menu/restore" method:"post" perm:"System:Menu:Restore" summary:"Restore the menu tree to an earlier revision" tags:"Menu"`
+	MenuRestorePoint
+}
+
+// MenuRestoreRes defines the response structure for restoring menus, the updated menu tree.
+type MenuRestoreRes []*SystemMenuItem
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// menuMeta has the fields of MenuMeta without its methods.
+type menuMeta MenuMeta
+
+// menuMetaKeys are the JSON keys modelled by MenuMeta.
+var menuMetaKeys = func() []string {
+	t := reflect.TypeOf(MenuMeta{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}()
+
+// MarshalJSON encodes the modelled fields followed by the keys kept in Extra.
+func (m MenuMeta) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(menuMeta(m))
+	if err != nil || len(m.Extra) == 0 {
+		return data, err
+	}
+	fields := make(map[string]json.RawMessage, len(m.Extra))
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range m.Extra {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON decodes data, keeping the keys MenuMeta does not model in Extra.
+func (m *MenuMeta) UnmarshalJSON(data []byte) error {
+	var meta menuMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	meta.Extra = nil
+	for key, value := range fields {
+		if isMenuMetaKey(key) {
+			continue
+		}
+		if meta.Extra == nil {
+			meta.Extra = make(map[string]json.RawMessage)
+		}
+		meta.Extra[key] = value
+	}
+	*m = MenuMeta(meta)
+	return nil
+}
+
+// UnmarshalValue lets request binding keep unknown keys as UnmarshalJSON does.
+func (m *MenuMeta) UnmarshalValue(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	return m.UnmarshalJSON(data)
+}
+
+// isMenuMetaKey reports whether encoding/json decodes key into a MenuMeta
+// field, which it matches case-insensitively.
+func isMenuMetaKey(key string) bool {
+	for _, known := range menuMetaKeys {
+		if strings.EqualFold(key, known) {
+			return true
+		}
+	}
+	return false
+}
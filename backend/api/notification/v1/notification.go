@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// NotificationItem is a message addressed to the current user.
+type NotificationItem struct {
+	Id        int64       `json:"id"`
+	Kind      string      `json:"kind"`
+	Title     string      `json:"title"`
+	Content   string      `json:"content"`
+	ReadAt    *gtime.Time `json:"readAt"`
+	CreatedAt *gtime.Time `json:"createdAt"`
+}
+
+// NotificationListReq defines the request structure for listing the caller's notifications.
+type NotificationListReq struct {
+	g.Meta   `path:"/notification/list" method:"get" summary:"List my notifications" tags:"Notification"`
+	Page     int  `json:"page" d:"1" v:"min:1"`
+	PageSize int  `json:"pageSize" d:"20" v:"between:1,100"`
+	Unread   bool `json:"unread"`
+}
+
+// NotificationListRes defines the response structure for listing notifications.
+type NotificationListRes struct {
+	Items  []*NotificationItem `json:"items"`
+	Total  int                 `json:"total"`
+	Unread int                 `json:"unread"`
+}
+
+// NotificationReadReq defines the request structure for marking a notification as read.
+type NotificationReadReq struct {
+	g.Meta `path:"/notification/{id}/read" method:"put" summary:"Mark a notification as read" tags:"Notification"`
+	Id     int64 `json:"id" in:"path" v:"required#Notification id is required"`
+}
+
+// NotificationReadRes defines the response structure for marking a notification as read.
+type NotificationReadRes struct{}
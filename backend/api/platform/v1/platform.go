@@ -0,0 +1,147 @@
+package v1
+
+import (
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+
+	menuv1 "backend/api/menu/v1"
+)
+
+// Platform routes carry domain:"platform": CasbinAuthz checks them in the
+// platform domain against the caller's operator roles, never its tenant roles.
+
+// TenantItem is a sys_tenant row.
+type TenantItem struct {
+	Id        string      `json:"id"`
+	Name      string      `json:"name"`
+	Status    int         `json:"status"`
+	Users     int         `json:"users"`
+	CreatedAt *gtime.Time `json:"createdAt"`
+	UpdatedAt *gtime.Time `json:"updatedAt"`
+}
+
+// TenantListReq defines the request structure for listing tenants.
+type TenantListReq struct {
+	g.Meta   `path:"/platform/tenant/list" method:"get" domain:"platform" summary:"List tenants" tags:"Platform"`
+	Page     int    `json:"page" d:"1" v:"min:1"`
+	PageSize int    `json:"pageSize" d:"20" v:"between:1,100"`
+	Name     string `json:"name"`
+}
+
+// TenantListRes defines the response structure for listing tenants.
+type TenantListRes struct {
+	Items []*TenantItem `json:"items"`
+	Total int           `json:"total"`
+}
+
+// TenantCreateReq defines the request structure for creating a tenant.
+type TenantCreateReq struct {
+	g.Meta `path:"/platform/tenant" method:"post" domain:"platform" summary:"Create a tenant" tags:"Platform"`
+	Name   string `json:"name" v:"required|max-length:255#Name is required"`
+	Status int    `json:"status" d:"1" v:"in:0,1"`
+}
+
+// TenantCreateRes defines the response structure for creating a tenant.
+type TenantCreateRes struct {
+	*TenantItem
+}
+
+// TenantUpdateReq defines the request structure for renaming, enabling or
+// disabling a tenant.
+type TenantUpdateReq struct {
+	g.Meta `path:"/platform/tenant/{id}" method:"put" domain:"platform" summary:"Update a tenant" tags:"Platform"`
+	Id     string `json:"id" in:"path" v:"required#Tenant id is required"`
+	Name   string `json:"name" v:"required|max-length:255#Name is required"`
+	Status int    `json:"status" v:"in:0,1"`
+}
+
+// TenantUpdateRes defines the response structure for updating a tenant.
+type TenantUpdateRes struct {
+	*TenantItem
+}
+
+// TenantMenuSyncReq defines the request structure for pushing the menu
+// template to a tenant. Menus the tenant has customized since its last sync
+// keep the tenant's values.
+type TenantMenuSyncReq struct {
+	g.Meta `path:"/platform/tenant/{id}/menu/sync" method:"post" domain:"platform" summary:"Push the menu template to a tenant" tags:"Platform"`
+	Id     string `json:"id" in:"path" v:"required#Tenant id is required"`
+	DryRun bool   `json:"dryRun"`
+}
+
+// TenantMenuSyncRes defines the response structure for pushing the menu template.
+type TenantMenuSyncRes struct {
+	*menuv1.MenuImportRes
+}
+
+// PlatformUserItem is a user of any tenant.
+type PlatformUserItem struct {
+	Id        string      `json:"id"`
+	TenantId  string      `json:"tenantId"`
+	Username  string      `json:"username"`
+	RealName  string      `json:"realName"`
+	Status    int         `json:"status"`
+	Roles     []string    `json:"roles"`
+	CreatedAt *gtime.Time `json:"createdAt"`
+}
+
+// PlatformUserListReq defines the request structure for listing users across tenants.
+type PlatformUserListReq struct {
+	g.Meta   `path:"/platform/user/list" method:"get" domain:"platform" summary:"List users across tenants" tags:"Platform"`
+	Page     int    `json:"page" d:"1" v:"min:1"`
+	PageSize int    `json:"pageSize" d:"20" v:"between:1,100"`
+	TenantId string `json:"tenantId"`
+	Username string `json:"username"`
+}
+
+// PlatformUserListRes defines the response structure for listing users across tenants.
+type PlatformUserListRes struct {
+	Items []*PlatformUserItem `json:"items"`
+	Total int                 `json:"total"`
+}
+
+// PlatformUserTenantReq defines the request structure for moving a user to
+// another tenant. The user leaves its department, which belongs to the old
+// tenant.
+type PlatformUserTenantReq struct {
+	g.Meta   `path:"/platform/user/{id}/tenant" method:"put" domain:"platform" summary:"Move a user to another tenant" tags:"Platform"`
+	Id       string `json:"id" in:"path" v:"required#User id is required"`
+	TenantId string `json:"tenantId" v:"required#Tenant id is required"`
+}
+
+// PlatformUserTenantRes defines the response structure for moving a user.
+type PlatformUserTenantRes struct {
+	*PlatformUserItem
+}
+
+// SettingItem is a global setting. Value is any JSON document.
+type SettingItem struct {
+	Key       string      `json:"key"`
+	Value     interface{} `json:"value"`
+	Remark    string      `json:"remark"`
+	UpdatedBy string      `json:"updatedBy"`
+	UpdatedAt *gtime.Time `json:"updatedAt"`
+}
+
+// SettingListReq defines the request structure for listing global settings.
+type SettingListReq struct {
+	g.Meta `path:"/platform/setting/list" method:"get" domain:"platform" summary:"List global settings" tags:"Platform"`
+}
+
+// SettingListRes defines the response structure for listing global settings.
+type SettingListRes struct {
+	Items []*SettingItem `json:"items"`
+}
+
+// SettingSaveReq defines the request structure for creating or replacing a global setting.
+type SettingSaveReq struct {
+	g.Meta `path:"/platform/setting" method:"put" domain:"platform" summary:"Create or replace a global setting" tags:"Platform"`
+	Key    string      `json:"key" v:"required|max-length:128#Key is required"`
+	Value  interface{} `json:"value" v:"required#Value is required"`
+	Remark string      `json:"remark" v:"max-length:255"`
+}
+
+// SettingSaveRes defines the response structure for saving a global setting.
+type SettingSaveRes struct {
+	*SettingItem
+}
@@ -0,0 +1,203 @@
+package v1
+
+import (
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// PolicyItem represents a casbin_rule row.
+// For "p" rules Subject/Domain/Object/Action/Effect are set; for "g" rules
+// Subject is granted Role inside Domain.
+type PolicyItem struct {
+	Id      int64  `json:"id"`
+	Ptype   string `json:"ptype"`
+	Subject string `json:"subject"`
+	Domain  string `json:"domain"`
+	Object  string `json:"object,omitempty"`
+	Action  string `json:"action,omitempty"`
+	Effect  string `json:"effect,omitempty"`
+	Role    string `json:"role,omitempty"`
+}
+
+// PolicyInput carries the writable fields of a policy.
+type PolicyInput struct {
+	Ptype   string `json:"ptype" v:"required|in:p,g#Policy type is required|Policy type must be p or g"`
+	Subject string `json:"subject" v:"required#Subject is required"`
+	Domain  string `json:"domain"`
+	Object  string `json:"object"`
+	Action  string `json:"action"`
+	Effect  string `json:"effect" v:"in:allow,deny#Effect must be allow or deny"`
+	Role    string `json:"role"`
+}
+
+// PolicyListReq defines the request structure for listing policies.
+type PolicyListReq struct {
+	g.Meta   `path:"/system/policy/list" method:"get" perm:"System:Policy:List" summary:"List casbin policies" tags:"Policy"`
+	Page     int    `json:"page" d:"1" v:"min:1"`
+	PageSize int    `json:"pageSize" d:"20" v:"between:1,100"`
+	Ptype    string `json:"ptype" v:"in:p,g"`
+	Domain   string `json:"domain"`
+	Subject  string `json:"subject"`
+	Object   string `json:"object"`
+	Action   string `json:"action"`
+}
+
+// PolicyListRes defines the response structure for listing policies.
+type PolicyListRes struct {
+	Items []*PolicyItem `json:"items"`
+	Total int           `json:"total"`
+}
+
+// PolicyCreateReq defines the request structure for adding a policy.
+type PolicyCreateReq struct {
+	g.Meta `path:"/system/policy" method:"post" perm:"System:Policy:Create" summary:"Add a casbin policy" tags:"Policy"`
+	PolicyInput
+}
+
+// PolicyCreateRes defines the response structure for adding a policy.
+type PolicyCreateRes struct {
+	*PolicyItem
+}
+
+// PolicyUpdateReq defines the request structure for updating a policy.
+type PolicyUpdateReq struct {
+	g.Meta `path:"/system/policy/{id}" method:"put" perm:"System:Policy:Edit" summary:"Update a casbin policy" tags:"Policy"`
+	Id     int64 `json:"id" in:"path" v:"required#Policy id is required"`
+	PolicyInput
+}
+
+// PolicyUpdateRes defines the response structure for updating a policy.
+type PolicyUpdateRes struct {
+	*PolicyItem
+}
+
+// PolicyDeleteReq defines the request structure for deleting a policy.
+type PolicyDeleteReq struct {
+	g.Meta `path:"/system/policy/{id}" method:"delete" perm:"System:Policy:Delete" summary:"Delete a casbin policy" tags:"Policy"`
+	Id     int64 `json:"id" in:"path" v:"required#Policy id is required"`
+}
+
+// PolicyDeleteRes defines the response structure for deleting a policy.
+type PolicyDeleteRes struct{}
+
+// PolicyReloadReq defines the request structure for reloading policies on all instances.
+type PolicyReloadReq struct {
+	g.Meta `path:"/system/policy/reload" method:"post" perm:"System:Policy:Reload" summary:"Reload casbin policies on all instances" tags:"Policy"`
+}
+
+// PolicyReloadRes defines the response structure for reloading policies.
+type PolicyReloadRes struct{}
+
+// PolicyExplainReq defines the request structure for explaining an authorization decision.
+type PolicyExplainReq struct {
+	g.Meta   `path:"/system/policy/explain" method:"get" perm:"System:Policy:Explain" summary:"Explain the authorization decision for a request" tags:"Policy"`
+	UserId   string `json:"userId" v:"required#User id is required"`
+	TenantId string `json:"tenantId"`
+	Path     string `json:"path" v:"required#Path is required"`
+	Method   string `json:"method" v:"required#Method is required"`
+	// Request attributes policy conditions are evaluated against. Time
+	// defaults to now.
+	ClientIp  string      `json:"clientIp"`
+	UserAgent string      `json:"userAgent"`
+	Time      *gtime.Time `json:"time"`
+}
+
+// PolicyExplainCondition reports whether the condition of a subject accepts
+// the request attributes.
+type PolicyExplainCondition struct {
+	Subject string `json:"subject"`
+	Matched bool   `json:"matched"`
+}
+
+// PolicyExplainRule is a "p" rule considered for a request, with whether its
+// object and action patterns match the request.
+type PolicyExplainRule struct {
+	Subject     string `json:"subject"`
+	Domain      string `json:"domain"`
+	Object      string `json:"object"`
+	Action      string `json:"action"`
+	Effect      string `json:"effect"`
+	ObjectMatch bool   `json:"objectMatch"`
+	ActionMatch bool   `json:"actionMatch"`
+}
+
+// PolicyExplainSubject is the outcome of the request for one subject.
+type PolicyExplainSubject struct {
+	Subject string             `json:"subject"`
+	Allowed bool               `json:"allowed"`
+	Rule    *PolicyExplainRule `json:"rule,omitempty"`
+}
+
+// PolicyExplainRes defines the response structure for explaining an authorization decision.
+type PolicyExplainRes struct {
+	UserId          string                  `json:"userId"`
+	Domain          string                  `json:"domain"`
+	Path            string                  `json:"path"`
+	Method          string                  `json:"method"`
+	Allowed         bool                    `json:"allowed"`
+	Roles           []string                `json:"roles"`
+	ImplicitRoles   []string                `json:"implicitRoles"`
+	Subjects        []*PolicyExplainSubject `json:"subjects"`
+	NearestPolicies []*PolicyExplainRule    `json:"nearestPolicies"`
+	AccessCodes     []string                `json:"accessCodes"`
+	// Conditions lists the conditions of the subjects involved, including
+	// roles inherited through "g" rules.
+	Conditions []*PolicyExplainCondition `json:"conditions"`
+}
+
+// PolicyConditionItem restricts the allow rules of Subject inside Domain to
+// requests matching every non-empty field.
+type PolicyConditionItem struct {
+	Id      int64  `json:"id"`
+	Domain  string `json:"domain"`
+	Subject string `json:"subject"`
+	// Cidrs lists client networks such as "10.0.0.0/8"; bare addresses match
+	// themselves only.
+	Cidrs []string `json:"cidrs"`
+	// TimeWindows lists windows such as "Mon-Fri 09:00-18:00" or
+	// "Sat,Sun 10:00-14:00"; the days may be omitted or "*" for every day,
+	// and windows ending before they start run past midnight.
+	TimeWindows []string `json:"timeWindows"`
+	// Timezone is the IANA zone TimeWindows are evaluated in, UTC by default.
+	Timezone string `json:"timezone"`
+	// UserAgent is a regular expression the User-Agent header must match.
+	UserAgent string `json:"userAgent"`
+	Remark    string `json:"remark"`
+}
+
+// PolicyConditionListReq defines the request structure for listing policy conditions.
+type PolicyConditionListReq struct {
+	g.Meta `path:"/system/policy/condition/list" method:"get" perm:"System:Policy:List" summary:"List policy conditions" tags:"Policy"`
+	Domain string `json:"domain"`
+}
+
+// PolicyConditionListRes defines the response structure for listing policy conditions.
+type PolicyConditionListRes struct {
+	Items []*PolicyConditionItem `json:"items"`
+}
+
+// PolicyConditionSaveReq defines the request structure for setting the condition of a subject.
+type PolicyConditionSaveReq struct {
+	g.Meta      `path:"/system/policy/condition" method:"put" perm:"System:Policy:Edit" summary:"Set the condition of a subject" tags:"Policy"`
+	Domain      string   `json:"domain"`
+	Subject     string   `json:"subject" v:"required#Subject is required"`
+	Cidrs       []string `json:"cidrs"`
+	TimeWindows []string `json:"timeWindows"`
+	Timezone    string   `json:"timezone"`
+	UserAgent   string   `json:"userAgent" v:"max-length:255"`
+	Remark      string   `json:"remark" v:"max-length:255"`
+}
+
+// PolicyConditionSaveRes defines the response structure for setting a condition.
+type PolicyConditionSaveRes struct {
+	*PolicyConditionItem
+}
+
+// PolicyConditionDeleteReq defines the request structure for removing a policy condition.
+type PolicyConditionDeleteReq struct {
+	g.Meta `path:"/system/policy/condition/{id}" method:"delete" perm:"System:Policy:Delete" summary:"Remove a policy condition" tags:"Policy"`
+	Id     int64 `json:"id" in:"path" v:"required#Condition id is required"`
+}
+
+// PolicyConditionDeleteRes defines the response structure for removing a policy condition.
+type PolicyConditionDeleteRes struct{}
@@ -0,0 +1,165 @@
+package v1
+
+import (
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// RoleGrantItem is a time-bound role assignment. The role applies to the
+// user inside TenantId from StartsAt until ExpiresAt; an empty bound is open.
+type RoleGrantItem struct {
+	Id        int64       `json:"id"`
+	TenantId  string      `json:"tenantId"`
+	UserId    string      `json:"userId"`
+	Role      string      `json:"role"`
+	StartsAt  *gtime.Time `json:"startsAt"`
+	ExpiresAt *gtime.Time `json:"expiresAt"`
+	Reason    string      `json:"reason"`
+	GrantedBy string      `json:"grantedBy"`
+	RequestId int64       `json:"requestId,omitempty"`
+	Active    bool        `json:"active"`
+	CreatedAt *gtime.Time `json:"createdAt"`
+}
+
+// RoleGrantListReq defines the request structure for listing role grants.
+type RoleGrantListReq struct {
+	g.Meta   `path:"/system/role/grant/list" method:"get" perm:"System:RoleGrant:List" summary:"List time-bound role grants" tags:"Role"`
+	Page     int    `json:"page" d:"1" v:"min:1"`
+	PageSize int    `json:"pageSize" d:"20" v:"between:1,100"`
+	UserId   string `json:"userId"`
+	Role     string `json:"role"`
+}
+
+// RoleGrantListRes defines the response structure for listing role grants.
+type RoleGrantListRes struct {
+	Items []*RoleGrantItem `json:"items"`
+	Total int              `json:"total"`
+}
+
+// RoleGrantCreateReq defines the request structure for granting a role for a period of time.
+type RoleGrantCreateReq struct {
+	g.Meta    `path:"/system/role/grant" method:"post" perm:"System:RoleGrant:Create" summary:"Grant a role for a period of time" tags:"Role"`
+	UserId    string      `json:"userId" v:"required#User id is required"`
+	Role      string      `json:"role" v:"required#Role is required"`
+	StartsAt  *gtime.Time `json:"startsAt"`
+	ExpiresAt *gtime.Time `json:"expiresAt"`
+	Reason    string      `json:"reason" v:"max-length:255"`
+}
+
+// RoleGrantCreateRes defines the response structure for granting a role.
+type RoleGrantCreateRes struct {
+	*RoleGrantItem
+}
+
+// RoleGrantDeleteReq defines the request structure for revoking a role grant.
+type RoleGrantDeleteReq struct {
+	g.Meta `path:"/system/role/grant/{id}" method:"delete" perm:"System:RoleGrant:Delete" summary:"Revoke a role grant" tags:"Role"`
+	Id     int64 `json:"id" in:"path" v:"required#Grant id is required"`
+}
+
+// RoleGrantDeleteRes defines the response structure for revoking a role grant.
+type RoleGrantDeleteRes struct{}
+
+// RoleRequestItem is a user's request for a role, pending until a tenant
+// admin approves or rejects it.
+type RoleRequestItem struct {
+	Id            int64       `json:"id"`
+	TenantId      string      `json:"tenantId"`
+	UserId        string      `json:"userId"`
+	Role          string      `json:"role"`
+	StartsAt      *gtime.Time `json:"startsAt"`
+	ExpiresAt     *gtime.Time `json:"expiresAt"`
+	Reason        string      `json:"reason"`
+	Status        string      `json:"status"`
+	ReviewerId    string      `json:"reviewerId,omitempty"`
+	ReviewComment string      `json:"reviewComment,omitempty"`
+	ReviewedAt    *gtime.Time `json:"reviewedAt"`
+	CreatedAt     *gtime.Time `json:"createdAt"`
+}
+
+// RoleRequestCreateReq defines the request structure for requesting a role.
+type RoleRequestCreateReq struct {
+	g.Meta    `path:"/system/role/request" method:"post" summary:"Request a role" tags:"Role"`
+	Role      string      `json:"role" v:"required#Role is required"`
+	StartsAt  *gtime.Time `json:"startsAt"`
+	ExpiresAt *gtime.Time `json:"expiresAt"`
+	Reason    string      `json:"reason" v:"required|max-length:255#Reason is required"`
+}
+
+// RoleRequestCreateRes defines the response structure for requesting a role.
+type RoleRequestCreateRes struct {
+	*RoleRequestItem
+}
+
+// RoleRequestMineReq defines the request structure for listing the caller's role requests.
+type RoleRequestMineReq struct {
+	g.Meta   `path:"/system/role/request/mine" method:"get" summary:"List my role requests" tags:"Role"`
+	Page     int `json:"page" d:"1" v:"min:1"`
+	PageSize int `json:"pageSize" d:"20" v:"between:1,100"`
+}
+
+// RoleRequestListReq defines the request structure for listing the tenant's role requests.
+type RoleRequestListReq struct {
+	g.Meta   `path:"/system/role/request/list" method:"get" perm:"System:RoleRequest:List" summary:"List role requests" tags:"Role"`
+	Page     int    `json:"page" d:"1" v:"min:1"`
+	PageSize int    `json:"pageSize" d:"20" v:"between:1,100"`
+	Status   string `json:"status" v:"in:pending,approved,rejected"`
+	UserId   string `json:"userId"`
+}
+
+// RoleRequestListRes defines the response structure for listing role requests.
+type RoleRequestListRes struct {
+	Items []*RoleRequestItem `json:"items"`
+	Total int                `json:"total"`
+}
+
+// RoleRequestApproveReq defines the request structure for approving a role request.
+type RoleRequestApproveReq struct {
+	g.Meta  `path:"/system/role/request/{id}/approve" method:"put" perm:"System:RoleRequest:Review" summary:"Approve a role request" tags:"Role"`
+	Id      int64  `json:"id" in:"path" v:"required#Request id is required"`
+	Comment string `json:"comment" v:"max-length:255"`
+}
+
+// RoleRequestApproveRes defines the response structure for approving a role request.
+type RoleRequestApproveRes struct {
+	*RoleRequestItem
+	Grant *RoleGrantItem `json:"grant"`
+}
+
+// RoleRequestRejectReq defines the request structure for rejecting a role request.
+type RoleRequestRejectReq struct {
+	g.Meta  `path:"/system/role/request/{id}/reject" method:"put" perm:"System:RoleRequest:Review" summary:"Reject a role request" tags:"Role"`
+	Id      int64  `json:"id" in:"path" v:"required#Request id is required"`
+	Comment string `json:"comment" v:"max-length:255"`
+}
+
+// RoleRequestRejectRes defines the response structure for rejecting a role request.
+type RoleRequestRejectRes struct {
+	*RoleRequestItem
+}
+
+// RoleMenuListReq defines the request structure for listing the menus granted to a role.
+type RoleMenuListReq struct {
+	g.Meta `path:"/system/role/menu/list" method:"get" perm:"System:Role:List" summary:"List the menus granted to a role" tags:"Role"`
+	Role   string `json:"role" v:"required#Role is required"`
+}
+
+// RoleMenuListRes defines the response structure for listing the menus granted to a role.
+type RoleMenuListRes struct {
+	Role    string   `json:"role"`
+	MenuIds []string `json:"menuIds"`
+}
+
+// RoleMenuSaveReq defines the request structure for replacing the menus granted to a role.
+// The permission codes of the granted menus and buttons become access codes
+// of the role's users.
+type RoleMenuSaveReq struct {
+	g.Meta  `path:"/system/role/menu" method:"put" perm:"System:Role:Edit" summary:"Replace the menus granted to a role" tags:"Role"`
+	Role    string   `json:"role" v:"required#Role is required"`
+	MenuIds []string `json:"menuIds"`
+}
+
+// RoleMenuSaveRes defines the response structure for replacing the menus granted to a role.
+type RoleMenuSaveRes struct {
+	*RoleMenuListRes
+}
@@ -13,4 +13,8 @@ import (
 // IUserV1 defines the user controller interface.
 type IUserV1 interface {
 	Info(ctx context.Context, req *v1.UserInfoReq) (res *v1.UserInfoRes, err error)
+	List(ctx context.Context, req *v1.UserListReq) (res *v1.UserListRes, err error)
+	Update(ctx context.Context, req *v1.UserUpdateReq) (res *v1.UserUpdateRes, err error)
+	Status(ctx context.Context, req *v1.UserStatusReq) (res *v1.UserStatusRes, err error)
+	Delete(ctx context.Context, req *v1.UserDeleteReq) (res *v1.UserDeleteRes, err error)
 }
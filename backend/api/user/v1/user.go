@@ -2,6 +2,7 @@ package v1
 
 import (
 	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
 )
 
 // UserInfoReq defines the request structure for fetching the current user data.
@@ -20,3 +21,69 @@ type UserInfoRes struct {
 	HomePath string   `json:"homePath"`
 	Token    string   `json:"token"`
 }
+
+// UserItem is a user of the caller's tenant.
+type UserItem struct {
+	Id        string      `json:"id"`
+	Username  string      `json:"username"`
+	RealName  string      `json:"realName"`
+	DeptId    string      `json:"deptId"`
+	Status    int         `json:"status"`
+	Roles     []string    `json:"roles"`
+	CreatedAt *gtime.Time `json:"createdAt"`
+}
+
+// UserListReq defines the request structure for listing the users of the
+// caller's tenant within the caller's data scope.
+type UserListReq struct {
+	g.Meta   `path:"/system/user/list" method:"get" perm:"System:User:List" summary:"List users" tags:"User"`
+	Page     int    `json:"page" d:"1" v:"min:1"`
+	PageSize int    `json:"pageSize" d:"20" v:"between:1,100"`
+	Username string `json:"username"`
+	DeptId   string `json:"deptId"`
+}
+
+// UserListRes defines the response structure for listing users.
+type UserListRes struct {
+	Items []*UserItem `json:"items"`
+	Total int         `json:"total"`
+}
+
+// UserUpdateReq defines the request structure for updating the profile and
+// department of a user of the caller's tenant.
+type UserUpdateReq struct {
+	g.Meta   `path:"/system/user/{id}" method:"put" perm:"System:User:Edit" summary:"Update a user" tags:"User"`
+	Id       string `json:"id" in:"path" v:"required#User id is required"`
+	RealName string `json:"realName" v:"max-length:64"`
+	Avatar   string `json:"avatar" v:"max-length:255"`
+	HomePath string `json:"homePath" v:"max-length:255"`
+	DeptId   string `json:"deptId"`
+}
+
+// UserUpdateRes defines the response structure for updating a user.
+type UserUpdateRes struct {
+	*UserItem
+}
+
+// UserStatusReq defines the request structure for enabling or disabling a
+// user of the caller's tenant.
+type UserStatusReq struct {
+	g.Meta `path:"/system/user/{id}/status" method:"put" perm:"System:User:Edit" summary:"Enable or disable a user" tags:"User"`
+	Id     string `json:"id" in:"path" v:"required#User id is required"`
+	Status int    `json:"status" v:"in:0,1"`
+}
+
+// UserStatusRes defines the response structure for enabling or disabling a user.
+type UserStatusRes struct {
+	*UserItem
+}
+
+// UserDeleteReq defines the request structure for deleting a user of the
+// caller's tenant.
+type UserDeleteReq struct {
+	g.Meta `path:"/system/user/{id}" method:"delete" perm:"System:User:Delete" summary:"Delete a user" tags:"User"`
+	Id     string `json:"id" in:"path" v:"required#User id is required"`
+}
+
+// UserDeleteRes defines the response structure for deleting a user.
+type UserDeleteRes struct{}
@@ -0,0 +1,34 @@
+-- Databases migrated before the up migration kept a backup cannot be
+-- restored faithfully: upper-case actions were lowered and rules already
+-- written as /* or .* cannot be told apart from rewritten wildcards. Stop
+-- rather than guess.
+DO $$
+BEGIN
+    IF to_regclass('casbin_rule_pattern_backup') IS NULL THEN
+        RAISE EXCEPTION 'casbin_rule_pattern_backup not found: the policies rewritten by 000009 cannot be restored';
+    END IF;
+END
+$$;
+
+-- Put back the values the up migration rewrote.
+UPDATE casbin_rule r
+SET v2 = b.v2,
+    v3 = b.v3
+FROM casbin_rule_pattern_backup b
+WHERE r.id = b.id;
+
+-- Rules written since use the pattern syntax; restore the bare wildcard
+-- form used before the pattern based model.
+UPDATE casbin_rule
+SET v2 = '*'
+WHERE ptype = 'p'
+  AND v2 = '/*'
+  AND id NOT IN (SELECT id FROM casbin_rule_pattern_backup);
+
+UPDATE casbin_rule
+SET v3 = '*'
+WHERE ptype = 'p'
+  AND v3 = '.*'
+  AND id NOT IN (SELECT id FROM casbin_rule_pattern_backup);
+
+DROP TABLE casbin_rule_pattern_backup;
@@ -0,0 +1,25 @@
+-- Keep the values rewritten below so the down migration can put them back
+-- exactly, action case included.
+CREATE TABLE casbin_rule_pattern_backup AS
+SELECT id, v2, v3
+FROM casbin_rule
+WHERE ptype = 'p'
+  AND (v2 = '*' OR v3 = '*' OR v3 <> LOWER(v3));
+
+-- Rewrite wildcard policies into the pattern syntax understood by the
+-- keyMatch2/keyMatch5 + regexMatch model.
+UPDATE casbin_rule
+SET v2 = '/*'
+WHERE ptype = 'p'
+  AND v2 = '*';
+
+UPDATE casbin_rule
+SET v3 = '.*'
+WHERE ptype = 'p'
+  AND v3 = '*';
+
+-- Actions are matched case-sensitively against lower-case HTTP methods.
+UPDATE casbin_rule
+SET v3 = LOWER(v3)
+WHERE ptype = 'p'
+  AND v3 <> LOWER(v3);
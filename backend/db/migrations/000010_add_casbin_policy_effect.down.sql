@@ -0,0 +1,9 @@
+-- Deny policies cannot be expressed without the effect column.
+DELETE FROM casbin_rule
+WHERE ptype = 'p'
+  AND v4 = 'deny';
+
+UPDATE casbin_rule
+SET v4 = NULL
+WHERE ptype = 'p'
+  AND v4 = 'allow';
@@ -0,0 +1,5 @@
+-- Policies now carry an explicit effect in v4 (allow or deny).
+UPDATE casbin_rule
+SET v4 = 'allow'
+WHERE ptype = 'p'
+  AND (v4 IS NULL OR v4 = '');
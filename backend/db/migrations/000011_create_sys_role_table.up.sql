@@ -0,0 +1,22 @@
+CREATE TABLE sys_role (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES sys_tenant(id),
+    code VARCHAR(64) NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    status SMALLINT NOT NULL DEFAULT 1,
+    remark VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (tenant_id, code)
+);
+
+CREATE INDEX idx_sys_role_tenant ON sys_role (tenant_id);
+
+-- Seed the roles referenced by 000006_seed_casbin_policy.
+INSERT INTO sys_role (tenant_id, code, name)
+VALUES
+  ('00000000-0000-0000-0000-000000000000', 'super', 'Super Admin'),
+  ('00000000-0000-0000-0000-000000000000', 'admin', 'Admin'),
+  ('00000000-0000-0000-0000-000000000000', 'staff', 'Staff')
+ON CONFLICT (tenant_id, code) DO NOTHING;
@@ -0,0 +1,16 @@
+CREATE TABLE sys_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    operator_id UUID,
+    action VARCHAR(64) NOT NULL,
+    resource VARCHAR(64) NOT NULL,
+    resource_id VARCHAR(64),
+    before JSONB,
+    after JSONB,
+    client_ip VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sys_audit_log_tenant ON sys_audit_log (tenant_id);
+CREATE INDEX idx_sys_audit_log_resource ON sys_audit_log (resource, resource_id);
+CREATE INDEX idx_sys_audit_log_created_at ON sys_audit_log (created_at);
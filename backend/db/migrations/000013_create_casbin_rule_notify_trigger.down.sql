@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_casbin_rule_notify_truncate ON casbin_rule;
+DROP TRIGGER IF EXISTS trg_casbin_rule_notify ON casbin_rule;
+DROP FUNCTION IF EXISTS casbin_rule_notify();
@@ -0,0 +1,20 @@
+-- Broadcast a reload on channel casbin_policy whenever casbin_rule changes
+-- outside the application. Writes made by CasbinAdapter set casbin.origin and
+-- are propagated incrementally by the watcher instead. 000028 takes the
+-- channel from the casbin.channel setting.
+CREATE OR REPLACE FUNCTION casbin_rule_notify() RETURNS trigger AS $$
+BEGIN
+    IF COALESCE(current_setting('casbin.origin', true), '') = '' THEN
+        PERFORM pg_notify('casbin_policy', '{"op":"reload"}');
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_casbin_rule_notify
+AFTER INSERT OR UPDATE OR DELETE ON casbin_rule
+FOR EACH ROW EXECUTE FUNCTION casbin_rule_notify();
+
+CREATE TRIGGER trg_casbin_rule_notify_truncate
+AFTER TRUNCATE ON casbin_rule
+FOR EACH STATEMENT EXECUTE FUNCTION casbin_rule_notify();
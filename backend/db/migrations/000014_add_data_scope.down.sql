@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_sys_user_dept;
+ALTER TABLE sys_user DROP COLUMN IF EXISTS created_by;
+ALTER TABLE sys_user DROP COLUMN IF EXISTS dept_id;
+ALTER TABLE sys_role DROP COLUMN IF EXISTS data_scope;
+DROP TABLE IF EXISTS sys_dept;
@@ -0,0 +1,27 @@
+CREATE TABLE sys_dept (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES sys_tenant(id),
+    parent_id UUID REFERENCES sys_dept(id),
+    name VARCHAR(128) NOT NULL,
+    sort INTEGER NOT NULL DEFAULT 0,
+    status SMALLINT NOT NULL DEFAULT 1,
+    remark VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_sys_dept_tenant ON sys_dept (tenant_id);
+CREATE INDEX idx_sys_dept_parent ON sys_dept (parent_id);
+
+-- Rows a role may see: all, dept_and_child (own department and below),
+-- dept (own department only) or self (rows created by the user).
+ALTER TABLE sys_role
+    ADD COLUMN data_scope VARCHAR(32) NOT NULL DEFAULT 'all'
+    CHECK (data_scope IN ('all', 'dept_and_child', 'dept', 'self'));
+
+ALTER TABLE sys_user
+    ADD COLUMN dept_id UUID REFERENCES sys_dept(id),
+    ADD COLUMN created_by UUID;
+
+CREATE INDEX idx_sys_user_dept ON sys_user (dept_id);
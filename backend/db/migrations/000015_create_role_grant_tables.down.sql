@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sys_notification;
+DROP TABLE IF EXISTS sys_role_request;
+DROP TABLE IF EXISTS sys_user_role_grant;
@@ -0,0 +1,52 @@
+-- Role assignments valid for a period of time, in addition to sys_user.roles.
+-- A grant applies while starts_at <= now < expires_at; either bound may be open.
+CREATE TABLE sys_user_role_grant (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES sys_tenant(id),
+    user_id UUID NOT NULL REFERENCES sys_user(id),
+    role_code VARCHAR(64) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    reason VARCHAR(255),
+    granted_by UUID,
+    request_id BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (starts_at IS NULL OR expires_at IS NULL OR starts_at < expires_at)
+);
+
+CREATE INDEX idx_sys_user_role_grant_user ON sys_user_role_grant (user_id);
+CREATE INDEX idx_sys_user_role_grant_expires_at ON sys_user_role_grant (expires_at);
+
+-- Role requests made by users and reviewed by tenant admins.
+CREATE TABLE sys_role_request (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES sys_tenant(id),
+    user_id UUID NOT NULL REFERENCES sys_user(id),
+    role_code VARCHAR(64) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    reason VARCHAR(255),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected')),
+    reviewer_id UUID,
+    review_comment VARCHAR(255),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sys_role_request_tenant_status ON sys_role_request (tenant_id, status);
+CREATE INDEX idx_sys_role_request_user ON sys_role_request (user_id);
+
+CREATE TABLE sys_notification (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES sys_user(id),
+    kind VARCHAR(64) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sys_notification_user ON sys_notification (user_id, read_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sys_policy_condition;
@@ -0,0 +1,17 @@
+-- Request conditions attached to a subject (usually a role) of a domain.
+-- Allow rules of the subject only apply to requests matching every
+-- non-empty condition: client IP in one of cidrs, time inside one of
+-- time_windows (evaluated in timezone) and user agent matching user_agent.
+CREATE TABLE sys_policy_condition (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    subject VARCHAR(64) NOT NULL,
+    cidrs JSONB NOT NULL DEFAULT '[]',
+    time_windows JSONB NOT NULL DEFAULT '[]',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    remark VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, subject)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sys_role_menu;
+
+DELETE FROM sys_menu WHERE id::text LIKE '21000000-0000-0000-0000-%';
@@ -0,0 +1,85 @@
+-- Role to menu grants. Menu and button permission codes granted to a role
+-- make up the access codes of its users (/auth/codes, perm route tags).
+CREATE TABLE sys_role_menu (
+    role_id UUID NOT NULL REFERENCES sys_role(id) ON DELETE CASCADE,
+    menu_id UUID NOT NULL REFERENCES sys_menu(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (role_id, menu_id)
+);
+
+CREATE INDEX idx_sys_role_menu_menu ON sys_role_menu (menu_id);
+
+-- Permission catalog of the default tenant, replacing the access codes that
+-- were compiled into the backend. The role and policy pages are hidden;
+-- 000025 hides and disables every system page, none of which has a frontend
+-- view yet, and 000026 carries over the codes of the user and guest roles.
+INSERT INTO sys_menu (
+    id,
+    tenant_id,
+    parent_id,
+    name,
+    path,
+    component,
+    icon,
+    "order",
+    type,
+    visible,
+    status,
+    permission_code,
+    meta
+) VALUES
+    ('21000000-0000-0000-0000-000000000001', '00000000-0000-0000-0000-000000000000', NULL, 'System', '/system', NULL, 'carbon:settings', 9997, 'catalog', 1, 1, NULL, '{"title":"system.title","order":9997}'),
+    ('21000000-0000-0000-0000-000000000002', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000001', 'SystemMenu', '/system/menu', '/system/menu/list', 'carbon:menu', 0, 'menu', 1, 1, 'System:Menu:List', '{"title":"system.menu.title"}'),
+    ('21000000-0000-0000-0000-000000000201', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000002', 'SystemMenuCreate', '', NULL, NULL, 0, 'button', 1, 1, 'System:Menu:Create', '{"title":"common.create"}'),
+    ('21000000-0000-0000-0000-000000000202', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000002', 'SystemMenuEdit', '', NULL, NULL, 1, 'button', 1, 1, 'System:Menu:Edit', '{"title":"common.edit"}'),
+    ('21000000-0000-0000-0000-000000000203', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000002', 'SystemMenuDelete', '', NULL, NULL, 2, 'button', 1, 1, 'System:Menu:Delete', '{"title":"common.delete"}'),
+    ('21000000-0000-0000-0000-000000000003', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000001', 'SystemDept', '/system/dept', '/system/dept/list', 'carbon:container-services', 1, 'menu', 1, 1, 'System:Dept:List', '{"title":"system.dept.title"}'),
+    ('21000000-0000-0000-0000-000000000301', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000003', 'SystemDeptCreate', '', NULL, NULL, 0, 'button', 1, 1, 'System:Dept:Create', '{"title":"common.create"}'),
+    ('21000000-0000-0000-0000-000000000302', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000003', 'SystemDeptEdit', '', NULL, NULL, 1, 'button', 1, 1, 'System:Dept:Edit', '{"title":"common.edit"}'),
+    ('21000000-0000-0000-0000-000000000303', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000003', 'SystemDeptDelete', '', NULL, NULL, 2, 'button', 1, 1, 'System:Dept:Delete', '{"title":"common.delete"}'),
+    ('21000000-0000-0000-0000-000000000004', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000001', 'SystemRole', '/system/role', '/system/role/list', 'carbon:user-role', 2, 'menu', 0, 1, 'System:Role:List', '{"title":"system.role.title"}'),
+    ('21000000-0000-0000-0000-000000000401', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000004', 'SystemRoleEdit', '', NULL, NULL, 0, 'button', 1, 1, 'System:Role:Edit', '{"title":"common.edit"}'),
+    ('21000000-0000-0000-0000-000000000402', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000004', 'SystemRoleGrantList', '', NULL, NULL, 1, 'button', 1, 1, 'System:RoleGrant:List', '{"title":"system.role.grants"}'),
+    ('21000000-0000-0000-0000-000000000403', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000004', 'SystemRoleGrantCreate', '', NULL, NULL, 2, 'button', 1, 1, 'System:RoleGrant:Create', '{"title":"system.role.grant"}'),
+    ('21000000-0000-0000-0000-000000000404', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000004', 'SystemRoleGrantDelete', '', NULL, NULL, 3, 'button', 1, 1, 'System:RoleGrant:Delete', '{"title":"system.role.revoke"}'),
+    ('21000000-0000-0000-0000-000000000405', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000004', 'SystemRoleRequestList', '', NULL, NULL, 4, 'button', 1, 1, 'System:RoleRequest:List', '{"title":"system.role.requests"}'),
+    ('21000000-0000-0000-0000-000000000406', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000004', 'SystemRoleRequestReview', '', NULL, NULL, 5, 'button', 1, 1, 'System:RoleRequest:Review', '{"title":"system.role.review"}'),
+    ('21000000-0000-0000-0000-000000000005', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000001', 'SystemPolicy', '/system/policy', '/system/policy/list', 'carbon:policy', 3, 'menu', 0, 1, 'System:Policy:List', '{"title":"system.policy.title"}'),
+    ('21000000-0000-0000-0000-000000000501', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000005', 'SystemPolicyCreate', '', NULL, NULL, 0, 'button', 1, 1, 'System:Policy:Create', '{"title":"common.create"}'),
+    ('21000000-0000-0000-0000-000000000502', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000005', 'SystemPolicyEdit', '', NULL, NULL, 1, 'button', 1, 1, 'System:Policy:Edit', '{"title":"common.edit"}'),
+    ('21000000-0000-0000-0000-000000000503', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000005', 'SystemPolicyDelete', '', NULL, NULL, 2, 'button', 1, 1, 'System:Policy:Delete', '{"title":"common.delete"}'),
+    ('21000000-0000-0000-0000-000000000504', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000005', 'SystemPolicyReload', '', NULL, NULL, 3, 'button', 1, 1, 'System:Policy:Reload', '{"title":"system.policy.reload"}'),
+    ('21000000-0000-0000-0000-000000000505', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000005', 'SystemPolicyExplain', '', NULL, NULL, 4, 'button', 1, 1, 'System:Policy:Explain', '{"title":"system.policy.explain"}')
+ON CONFLICT (id) DO NOTHING;
+
+-- super holds every menu of the tenant.
+INSERT INTO sys_role_menu (role_id, menu_id)
+SELECT r.id, m.id
+FROM sys_role r
+JOIN sys_menu m ON m.tenant_id = r.tenant_id AND m.deleted_at IS NULL
+WHERE r.tenant_id = '00000000-0000-0000-0000-000000000000' AND r.code = 'super'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO sys_role_menu (role_id, menu_id)
+SELECT r.id, m.id
+FROM sys_role r
+JOIN sys_menu m ON m.tenant_id = r.tenant_id AND m.deleted_at IS NULL
+WHERE r.tenant_id = '00000000-0000-0000-0000-000000000000' AND r.code = 'admin'
+  AND m.permission_code IN (
+    'System:Menu:List', 'System:Menu:Edit',
+    'System:Dept:List', 'System:Dept:Edit',
+    'System:Role:List', 'System:Role:Edit',
+    'System:RoleGrant:List', 'System:RoleGrant:Create', 'System:RoleGrant:Delete',
+    'System:RoleRequest:List', 'System:RoleRequest:Review',
+    'System:Policy:List', 'System:Policy:Create', 'System:Policy:Edit',
+    'System:Policy:Delete', 'System:Policy:Reload', 'System:Policy:Explain'
+  )
+ON CONFLICT DO NOTHING;
+
+INSERT INTO sys_role_menu (role_id, menu_id)
+SELECT r.id, m.id
+FROM sys_role r
+JOIN sys_menu m ON m.tenant_id = r.tenant_id AND m.deleted_at IS NULL
+WHERE r.tenant_id = '00000000-0000-0000-0000-000000000000' AND r.code = 'staff'
+  AND m.permission_code IN ('System:Menu:List', 'System:Dept:List')
+ON CONFLICT DO NOTHING;
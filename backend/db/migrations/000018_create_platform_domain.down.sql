@@ -0,0 +1,5 @@
+DELETE FROM casbin_rule
+WHERE (ptype = 'p' AND v1 = 'platform')
+   OR (ptype = 'g' AND v2 = 'platform');
+
+DROP TABLE IF EXISTS sys_setting;
@@ -0,0 +1,17 @@
+-- Global settings managed by platform operators.
+CREATE TABLE sys_setting (
+    key VARCHAR(128) PRIMARY KEY,
+    value JSONB NOT NULL,
+    remark VARCHAR(255),
+    updated_by UUID,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Platform operators act in the "platform" casbin domain, which only covers
+-- /platform routes. Tenant roles have no rules there, so tenant admins cannot
+-- reach them. The seeded super user is the first operator.
+INSERT INTO casbin_rule (ptype, v0, v1, v2, v3, v4)
+VALUES ('p', 'operator', 'platform', '/platform/*', '.*', 'allow');
+
+INSERT INTO casbin_rule (ptype, v0, v1, v2)
+VALUES ('g', '11111111-1111-1111-1111-111111111111', 'operator', 'platform');
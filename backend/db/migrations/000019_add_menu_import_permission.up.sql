@@ -0,0 +1,25 @@
+-- Permission to import menu bundles, held by super like every other menu of
+-- the default tenant.
+INSERT INTO sys_menu (
+    id,
+    tenant_id,
+    parent_id,
+    name,
+    path,
+    component,
+    icon,
+    "order",
+    type,
+    visible,
+    status,
+    permission_code,
+    meta
+) VALUES
+    ('21000000-0000-0000-0000-000000000204', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000002', 'SystemMenuImport', '', NULL, NULL, 3, 'button', 1, 1, 'System:Menu:Import', '{"title":"common.import"}')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO sys_role_menu (role_id, menu_id)
+SELECT r.id, '21000000-0000-0000-0000-000000000204'
+FROM sys_role r
+WHERE r.tenant_id = '00000000-0000-0000-0000-000000000000' AND r.code = 'super'
+ON CONFLICT DO NOTHING;
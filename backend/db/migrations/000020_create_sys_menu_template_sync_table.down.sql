@@ -0,0 +1,3 @@
+DELETE FROM sys_setting WHERE key = 'menu.template_tenant';
+
+DROP TABLE IF EXISTS sys_menu_template_sync;
@@ -0,0 +1,12 @@
+-- The menu template as last cloned or pushed into each tenant, the base that
+-- tells template updates apart from the tenant's own customizations.
+CREATE TABLE sys_menu_template_sync (
+    tenant_id UUID PRIMARY KEY REFERENCES sys_tenant(id) ON DELETE CASCADE,
+    template_tenant_id UUID NOT NULL,
+    bundle JSONB NOT NULL,
+    synced_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO sys_setting (key, value, remark)
+VALUES ('menu.template_tenant', '"00000000-0000-0000-0000-000000000000"', 'Tenant whose menus new tenants start from')
+ON CONFLICT (key) DO NOTHING;
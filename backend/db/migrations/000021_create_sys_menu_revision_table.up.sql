@@ -0,0 +1,17 @@
+-- Every change to a sys_menu row, with the row as the menu page shows it
+-- before and after. before is NULL for created menus and after for deleted
+-- ones; restores replay before of the first revision past a point in time.
+CREATE TABLE sys_menu_revision (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES sys_tenant(id) ON DELETE CASCADE,
+    menu_id UUID NOT NULL,
+    operator_id UUID,
+    action VARCHAR(64) NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sys_menu_revision_tenant ON sys_menu_revision (tenant_id, id);
+CREATE INDEX idx_sys_menu_revision_menu ON sys_menu_revision (menu_id, id);
+CREATE INDEX idx_sys_menu_revision_created_at ON sys_menu_revision (tenant_id, created_at);
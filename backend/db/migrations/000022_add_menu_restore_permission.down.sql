@@ -0,0 +1,3 @@
+DELETE FROM sys_role_menu WHERE menu_id = '21000000-0000-0000-0000-000000000205';
+
+DELETE FROM sys_menu WHERE id = '21000000-0000-0000-0000-000000000205';
@@ -0,0 +1,25 @@
+-- Users page of the default tenant. The list is restricted to the data scope
+-- of the caller's roles. 000027 disables the menu until the page has a view.
+INSERT INTO sys_menu (
+    id,
+    tenant_id,
+    parent_id,
+    name,
+    path,
+    component,
+    icon,
+    "order",
+    type,
+    visible,
+    status,
+    permission_code,
+    meta
+) VALUES
+    ('21000000-0000-0000-0000-000000000006', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000001', 'SystemUser', '/system/user', '/system/user/list', 'carbon:user', 4, 'menu', 1, 1, 'System:User:List', '{"title":"system.user.title"}')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO sys_role_menu (role_id, menu_id)
+SELECT r.id, '21000000-0000-0000-0000-000000000006'
+FROM sys_role r
+WHERE r.tenant_id = '00000000-0000-0000-0000-000000000000' AND r.code IN ('super', 'admin')
+ON CONFLICT DO NOTHING;
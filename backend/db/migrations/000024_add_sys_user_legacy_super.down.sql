@@ -0,0 +1,2 @@
+ALTER TABLE sys_user
+    DROP COLUMN legacy_super;
@@ -0,0 +1,19 @@
+-- Users without roles are authorized as super. The flag limits that fallback
+-- to the users it is meant for and is cleared when a user is first given a
+-- time-bound grant, so a user whose only grant expired holds no role rather
+-- than every permission.
+ALTER TABLE sys_user
+    ADD COLUMN legacy_super BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Users that have held a grant, including grants purged since, have left the
+-- fallback already.
+UPDATE sys_user u
+SET legacy_super = FALSE
+WHERE EXISTS (SELECT 1 FROM sys_user_role_grant g WHERE g.user_id = u.id)
+   OR EXISTS (
+       SELECT 1
+       FROM sys_audit_log a
+       WHERE a.resource = 'sys_user_role_grant'
+         AND a.action = 'role_grant.create'
+         AND a.after ->> 'userId' = u.id::text
+   );
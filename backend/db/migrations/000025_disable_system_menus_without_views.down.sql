@@ -0,0 +1,14 @@
+UPDATE sys_menu
+SET visible = 1, status = 1, updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    '21000000-0000-0000-0000-000000000001',
+    '21000000-0000-0000-0000-000000000002',
+    '21000000-0000-0000-0000-000000000003'
+);
+
+UPDATE sys_menu
+SET status = 1, updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    '21000000-0000-0000-0000-000000000004',
+    '21000000-0000-0000-0000-000000000005'
+);
@@ -0,0 +1,13 @@
+-- None of the system pages has a frontend view yet: the apps only ship the
+-- _core, dashboard and demo views. Their menus are hidden and disabled so no
+-- route is registered for a missing component; the permission codes of the
+-- pages and their buttons are still granted through sys_role_menu.
+UPDATE sys_menu
+SET visible = 0, status = 0, updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    '21000000-0000-0000-0000-000000000001',
+    '21000000-0000-0000-0000-000000000002',
+    '21000000-0000-0000-0000-000000000003',
+    '21000000-0000-0000-0000-000000000004',
+    '21000000-0000-0000-0000-000000000005'
+);
@@ -0,0 +1,2 @@
+DELETE FROM sys_role
+WHERE tenant_id = '00000000-0000-0000-0000-000000000000' AND code IN ('user', 'guest');
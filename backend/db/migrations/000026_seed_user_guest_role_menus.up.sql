@@ -0,0 +1,25 @@
+-- The user and guest roles had access codes compiled into the backend before
+-- 000017 moved them to sys_role_menu. Seed the roles and grant them the same
+-- codes. Role codes not found in sys_role no longer receive the codes of
+-- super; they hold only what their menus and casbin rules grant.
+INSERT INTO sys_role (tenant_id, code, name)
+VALUES
+  ('00000000-0000-0000-0000-000000000000', 'user', 'User'),
+  ('00000000-0000-0000-0000-000000000000', 'guest', 'Guest')
+ON CONFLICT (tenant_id, code) DO NOTHING;
+
+INSERT INTO sys_role_menu (role_id, menu_id)
+SELECT r.id, m.id
+FROM sys_role r
+JOIN sys_menu m ON m.tenant_id = r.tenant_id AND m.deleted_at IS NULL
+WHERE r.tenant_id = '00000000-0000-0000-0000-000000000000' AND r.code = 'user'
+  AND m.permission_code IN ('System:Menu:List', 'System:Dept:List')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO sys_role_menu (role_id, menu_id)
+SELECT r.id, m.id
+FROM sys_role r
+JOIN sys_menu m ON m.tenant_id = r.tenant_id AND m.deleted_at IS NULL
+WHERE r.tenant_id = '00000000-0000-0000-0000-000000000000' AND r.code = 'guest'
+  AND m.permission_code = 'System:Menu:List'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+UPDATE sys_menu
+SET visible = 1, status = 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = '21000000-0000-0000-0000-000000000006';
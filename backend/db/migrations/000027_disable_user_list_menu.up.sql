@@ -0,0 +1,5 @@
+-- The users page has no frontend view yet either, see 000025. The menu is
+-- hidden and disabled; System:User:List is still granted to super and admin.
+UPDATE sys_menu
+SET visible = 0, status = 0, updated_at = CURRENT_TIMESTAMP
+WHERE id = '21000000-0000-0000-0000-000000000006';
@@ -0,0 +1,8 @@
+CREATE OR REPLACE FUNCTION casbin_rule_notify() RETURNS trigger AS $$
+BEGIN
+    IF COALESCE(current_setting('casbin.origin', true), '') = '' THEN
+        PERFORM pg_notify('casbin_policy', '{"op":"reload"}');
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,16 @@
+-- Notify on the channel the watcher listens on (casbin.watcher.channel). The
+-- trigger reads it from the casbin.channel setting, which a deployment using
+-- another channel sets for the database:
+--   ALTER DATABASE <name> SET casbin.channel = '<channel>';
+-- Without it the trigger keeps notifying casbin_policy.
+CREATE OR REPLACE FUNCTION casbin_rule_notify() RETURNS trigger AS $$
+BEGIN
+    IF COALESCE(current_setting('casbin.origin', true), '') = '' THEN
+        PERFORM pg_notify(
+            COALESCE(NULLIF(current_setting('casbin.channel', true), ''), 'casbin_policy'),
+            '{"op":"reload"}'
+        );
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+DELETE FROM sys_role_menu WHERE menu_id IN ('21000000-0000-0000-0000-000000000601', '21000000-0000-0000-0000-000000000602');
+
+DELETE FROM sys_menu WHERE id IN ('21000000-0000-0000-0000-000000000601', '21000000-0000-0000-0000-000000000602');
@@ -0,0 +1,31 @@
+-- Buttons of the users page for editing, enabling and disabling, and deleting
+-- users. super holds both, admin may edit like on the other system pages.
+INSERT INTO sys_menu (
+    id,
+    tenant_id,
+    parent_id,
+    name,
+    path,
+    component,
+    icon,
+    "order",
+    type,
+    visible,
+    status,
+    permission_code,
+    meta
+) VALUES
+    ('21000000-0000-0000-0000-000000000601', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000006', 'SystemUserEdit', '', NULL, NULL, 0, 'button', 1, 1, 'System:User:Edit', '{"title":"common.edit"}'),
+    ('21000000-0000-0000-0000-000000000602', '00000000-0000-0000-0000-000000000000', '21000000-0000-0000-0000-000000000006', 'SystemUserDelete', '', NULL, NULL, 1, 'button', 1, 1, 'System:User:Delete', '{"title":"common.delete"}')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO sys_role_menu (role_id, menu_id)
+SELECT r.id, m.id
+FROM sys_role r
+JOIN sys_menu m ON m.tenant_id = r.tenant_id
+WHERE r.tenant_id = '00000000-0000-0000-0000-000000000000'
+  AND (
+    (r.code = 'super' AND m.id IN ('21000000-0000-0000-0000-000000000601', '21000000-0000-0000-0000-000000000602'))
+    OR (r.code = 'admin' AND m.id = '21000000-0000-0000-0000-000000000601')
+  )
+ON CONFLICT DO NOTHING;
@@ -8,12 +8,18 @@ import (
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gcmd"
+	"github.com/gogf/gf/v2/os/gcron"
 
 	"backend/internal/controller/auth"
 	"backend/internal/controller/hello"
 	"backend/internal/controller/menu"
+	"backend/internal/controller/notification"
+	"backend/internal/controller/platform"
+	"backend/internal/controller/policy"
+	"backend/internal/controller/role"
 	"backend/internal/controller/user"
 	"backend/internal/middleware"
+	"backend/internal/service"
 )
 
 var (
@@ -40,14 +46,41 @@ var (
 					}
 				}
 			}
+			controllers := []interface{}{
+				hello.NewV1(),
+				auth.NewV1(),
+				menu.NewV1(),
+				policy.NewV1(),
+				role.NewV1(),
+				notification.NewV1(),
+				platform.NewV1(),
+				user.NewV1(),
+			}
+			report := middleware.BindRoutePermissions(ctx, controllers...)
+			for _, route := range report.Public {
+				g.Log().Infof(ctx, "route %s allows anonymous access", route)
+			}
+			for _, route := range report.Platform {
+				g.Log().Infof(ctx, "route %s is checked in the platform domain", route)
+			}
+			for _, route := range report.Untagged {
+				g.Log().Infof(ctx, "route %s declares no permission code", route)
+			}
+			if err = service.InitMenuCache(ctx); err != nil {
+				return err
+			}
+			if _, err = gcron.AddSingleton(ctx, service.RoleGrantPurgeCron(ctx), func(ctx context.Context) {
+				if purged, err := service.PurgeExpiredRoleGrants(ctx); err != nil {
+					g.Log().Errorf(ctx, "failed to purge expired role grants: %v", err)
+				} else if purged > 0 {
+					g.Log().Infof(ctx, "purged %d expired role grants", purged)
+				}
+			}, "role-grant-purge"); err != nil {
+				return err
+			}
 			s.Group("/", func(group *ghttp.RouterGroup) {
-				group.Middleware(ghttp.MiddlewareHandlerResponse, middleware.CasbinAuthz())
-				group.Bind(
-					hello.NewV1(),
-					auth.NewV1(),
-					menu.NewV1(),
-					user.NewV1(),
-				)
+				group.Middleware(middleware.ConditionalGet(), ghttp.MiddlewareHandlerResponse, middleware.CasbinAuthz())
+				group.Bind(controllers...)
 			})
 			s.Run()
 			return nil
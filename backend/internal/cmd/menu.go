@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gcmd"
+	"github.com/gogf/gf/v2/os/gfile"
+
+	"backend/api/menu/v1"
+	"backend/internal/consts"
+	"backend/internal/service"
+)
+
+var (
+	Menu = gcmd.Command{
+		Name:  "menu",
+		Usage: "menu export|import|lint [OPTION]",
+		Brief: "export, import or lint the menus of a tenant",
+	}
+
+	MenuExport = gcmd.Command{
+		Name:  "export",
+		Usage: "menu export [--tenant ID] [--format json|yaml] [--file PATH]",
+		Brief: "write the menu tree of a tenant as a bundle",
+		Arguments: []gcmd.Argument{
+			{Name: "tenant", Short: "t", Default: consts.DefaultTenantId, Brief: "tenant id"},
+			{Name: "format", Short: "f", Brief: "json or yaml, taken from the file extension when omitted"},
+			{Name: "file", Short: "o", Brief: "output file, stdout when omitted"},
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) error {
+			file := parser.GetOpt("file").String()
+			format := bundleFormat(parser.GetOpt("format").String(), file)
+			content, err := service.ExportTenantMenus(ctx, parser.GetOpt("tenant", consts.DefaultTenantId).String(), format)
+			if err != nil {
+				return err
+			}
+			if file == "" {
+				_, err = os.Stdout.Write(content)
+				return err
+			}
+			return gfile.PutBytes(file, content)
+		},
+	}
+
+	MenuImport = gcmd.Command{
+		Name:  "import",
+		Usage: "menu import [--file PATH] [--tenant ID] [--format json|yaml] [--dry-run]",
+		Brief: "upsert the menus of a bundle into a tenant",
+		Description: "Menus are matched by name, then by path. Matches are updated, the rest are created " +
+			"and menus missing from the bundle are left alone. Nothing is written on a dry run or when " +
+			"the bundle conflicts with the tenant's menus.",
+		Arguments: []gcmd.Argument{
+			{Name: "tenant", Short: "t", Default: consts.DefaultTenantId, Brief: "tenant id"},
+			{Name: "format", Short: "f", Brief: "json or yaml, detected from the content when omitted"},
+			{Name: "file", Short: "i", Brief: "bundle file, stdin when omitted"},
+			{Name: "dry-run", Short: "n", Brief: "print the changes without writing them", Orphan: true},
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) error {
+			file := parser.GetOpt("file").String()
+			var (
+				content []byte
+				err     error
+			)
+			if file == "" {
+				content, err = io.ReadAll(os.Stdin)
+			} else {
+				content, err = os.ReadFile(file)
+			}
+			if err != nil {
+				return err
+			}
+			res, err := service.ImportTenantMenus(
+				ctx,
+				parser.GetOpt("tenant", consts.DefaultTenantId).String(),
+				bundleFormat(parser.GetOpt("format").String(), file),
+				content,
+				parser.GetOpt("dry-run") != nil,
+			)
+			if err != nil {
+				return err
+			}
+			fmt.Print(formatMenuImport(res))
+			if len(res.Conflicts) > 0 {
+				return gerror.Newf("%d conflicts, nothing imported", len(res.Conflicts))
+			}
+			return nil
+		},
+	}
+
+	MenuLint = gcmd.Command{
+		Name:  "lint",
+		Usage: "menu lint [--tenant ID] [--views DIR] [--layouts NAMES]",
+		Brief: "check the menus of a tenant for problems the frontend only shows as blank pages",
+		Description: "Reports duplicate names and paths, missing or button parents, parent cycles " +
+			"and the menus below them, components without a view under the views directory, links " +
+			"and iframes without an absolute http(s) URL, and buttons without a permission code. " +
+			"Problems of disabled menus are warnings; the command fails when there are errors.",
+		Arguments: []gcmd.Argument{
+			{Name: "tenant", Short: "t", Default: consts.DefaultTenantId, Brief: "tenant id"},
+			{Name: "views", Short: "v", Brief: "views directory of the frontend app, e.g. frontend/apps/web-naive/src/views; components are not checked when omitted"},
+			{Name: "layouts", Short: "l", Default: "BasicLayout,IFrameView", Brief: "comma separated components the frontend maps to layouts"},
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) error {
+			views := parser.GetOpt("views").String()
+			issues, err := service.LintTenantMenus(ctx, parser.GetOpt("tenant", consts.DefaultTenantId).String(), service.MenuLintOptions{
+				ViewsDir: views,
+				Layouts:  strings.Split(parser.GetOpt("layouts", "BasicLayout,IFrameView").String(), ","),
+			})
+			if err != nil {
+				return err
+			}
+			if views == "" {
+				fmt.Println("components not checked, pass --views to check them")
+			}
+			report, errors := formatMenuLint(issues)
+			fmt.Print(report)
+			if errors > 0 {
+				return gerror.Newf("%d menu errors", errors)
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	if err := Menu.AddCommand(&MenuExport, &MenuImport, &MenuLint); err != nil {
+		panic(err)
+	}
+	if err := Main.AddCommand(&Menu); err != nil {
+		panic(err)
+	}
+}
+
+// bundleFormat returns format, or the one the extension of file names.
+func bundleFormat(format, file string) string {
+	if format != "" {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return service.MenuBundleYAML
+	case ".json":
+		return service.MenuBundleJSON
+	}
+	return ""
+}
+
+// formatMenuImport renders res as a diff: + created, ~ updated, ! conflicts.
+func formatMenuImport(res *v1.MenuImportRes) string {
+	var b strings.Builder
+	for _, change := range res.Changes {
+		marker := "+"
+		if len(change.Fields) > 0 {
+			marker = "~"
+		}
+		fmt.Fprintf(&b, "%s %s %s", marker, change.Action, change.Name)
+		if change.Path != "" {
+			fmt.Fprintf(&b, " (%s)", change.Path)
+		}
+		b.WriteString("\n")
+		for _, field := range change.Fields {
+			fmt.Fprintf(&b, "    %s: %v -> %v\n", field.Field, field.From, field.To)
+		}
+	}
+	for _, conflict := range res.Conflicts {
+		fmt.Fprintf(&b, "! conflict %s: %s\n", conflict.Name, conflict.Reason)
+	}
+	status := "applied"
+	switch {
+	case len(res.Conflicts) > 0:
+		status = "not applied"
+	case res.DryRun:
+		status = "dry run"
+	case !res.Applied:
+		status = "nothing to apply"
+	}
+	fmt.Fprintf(&b, "%d created, %d updated, %d unchanged, %d conflicts (%s)\n",
+		res.Created, res.Updated, res.Unchanged, len(res.Conflicts), status)
+	return b.String()
+}
+
+// formatMenuLint renders issues one per line followed by a summary line, and
+// returns the number of errors among them.
+func formatMenuLint(issues []*service.MenuLintIssue) (string, int) {
+	var (
+		b        strings.Builder
+		errors   int
+		warnings int
+	)
+	for _, issue := range issues {
+		if issue.Severity == service.MenuLintError {
+			errors++
+		} else {
+			warnings++
+		}
+		fmt.Fprintf(&b, "%-7s %-17s %s (%s): %s\n", issue.Severity, issue.Check, issue.Name, issue.MenuId, issue.Message)
+	}
+	fmt.Fprintf(&b, "%d errors, %d warnings\n", errors, warnings)
+	return b.String(), errors
+}
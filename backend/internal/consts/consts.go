@@ -4,10 +4,37 @@ import (
 	"github.com/gogf/gf/v2/errors/gcode"
 )
 
+// DefaultTenantId is the tenant seeded by 000004_seed_super_user.
+const DefaultTenantId = "00000000-0000-0000-0000-000000000000"
+
+// PlatformDomain is the casbin domain of platform operators, who manage
+// tenants, users across tenants and global settings. Tenant roles never
+// apply in it.
+const PlatformDomain = "platform"
+
+// PlatformOperatorRole is the platform domain role granted to operators.
+const PlatformOperatorRole = "operator"
+
 var (
 	ErrorCodeUserNotFound         = gcode.New(1001, "User not found", nil)
 	ErrorCodeIncorrectPassword    = gcode.New(1002, "Incorrect password", nil)
 	ErrorCodeUnauthorized         = gcode.New(1003, "Unauthorized", nil)
 	ErrorCodeRefreshTokenRequired = gcode.New(1004, "Refresh token required", nil)
 	ErrorCodeRefreshTokenInvalid  = gcode.New(1005, "Refresh token invalid", nil)
+	ErrorCodeForbidden            = gcode.New(1006, "Forbidden", nil)
+	ErrorCodePolicyInvalid        = gcode.New(1007, "Invalid policy", nil)
+	ErrorCodePolicyNotFound       = gcode.New(1008, "Policy not found", nil)
+	ErrorCodePolicyExists         = gcode.New(1009, "Policy already exists", nil)
+	ErrorCodeRoleGrantInvalid     = gcode.New(1010, "Invalid role grant", nil)
+	ErrorCodeRoleGrantNotFound    = gcode.New(1011, "Role grant not found", nil)
+	ErrorCodeRoleRequestNotFound  = gcode.New(1012, "Role request not found", nil)
+	ErrorCodeRoleRequestClosed    = gcode.New(1013, "Role request already reviewed", nil)
+	ErrorCodeNotificationNotFound = gcode.New(1014, "Notification not found", nil)
+	ErrorCodeRoleNotFound         = gcode.New(1015, "Role not found", nil)
+	ErrorCodeMenuNotFound         = gcode.New(1016, "Menu not found", nil)
+	ErrorCodeTenantNotFound       = gcode.New(1017, "Tenant not found", nil)
+	ErrorCodeSettingInvalid       = gcode.New(1018, "Invalid setting", nil)
+	ErrorCodeMenuInvalid          = gcode.New(1019, "Invalid menu", nil)
+	ErrorCodeMenuExists           = gcode.New(1020, "Menu already exists", nil)
+	ErrorCodeUserInvalid          = gcode.New(1021, "Invalid user", nil)
 )
@@ -6,6 +6,8 @@ import (
 	"backend/api/menu"
 	"backend/api/menu/v1"
 	"backend/internal/service"
+
+	"github.com/gogf/gf/v2/frame/g"
 )
 
 // ControllerV1 handles menu endpoints.
@@ -16,7 +18,99 @@ func NewV1() menu.IMenuV1 {
 	return &ControllerV1{}
 }
 
-// All returns the full menu list for the current user.
+// All returns the full menu list for the current user, tagged with an ETag
+// that middleware.ConditionalGet answers If-None-Match with.
 func (c *ControllerV1) All(ctx context.Context, req *v1.MenuAllReq) (res v1.MenuAllRes, err error) {
-	return service.Menu().All(ctx)
+	if res, err = service.Menu().All(ctx); err != nil {
+		return nil, err
+	}
+	if r := g.RequestFromCtx(ctx); r != nil {
+		r.Response.Header().Set("ETag", service.MenuETag(res))
+	}
+	return res, nil
+}
+
+// List returns the tenant's menu tree, including buttons and disabled menus.
+func (c *ControllerV1) List(ctx context.Context, req *v1.MenuListReq) (res v1.MenuListRes, err error) {
+	return service.Menu().List(ctx)
+}
+
+// NameExists reports whether another menu of the tenant uses the name.
+func (c *ControllerV1) NameExists(ctx context.Context, req *v1.MenuNameExistsReq) (res v1.MenuExistsRes, err error) {
+	exists, err := service.Menu().NameExists(ctx, req.Name, req.Id)
+	return v1.MenuExistsRes(exists), err
+}
+
+// PathExists reports whether another menu of the tenant uses the path.
+func (c *ControllerV1) PathExists(ctx context.Context, req *v1.MenuPathExistsReq) (res v1.MenuExistsRes, err error) {
+	exists, err := service.Menu().PathExists(ctx, req.Path, req.Id)
+	return v1.MenuExistsRes(exists), err
+}
+
+// Create adds a menu to the tenant.
+func (c *ControllerV1) Create(ctx context.Context, req *v1.MenuCreateReq) (res *v1.MenuCreateRes, err error) {
+	item, err := service.Menu().Create(ctx, req.MenuInput)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.MenuCreateRes{SystemMenuItem: item}, nil
+}
+
+// Update replaces a menu of the tenant.
+func (c *ControllerV1) Update(ctx context.Context, req *v1.MenuUpdateReq) (res *v1.MenuUpdateRes, err error) {
+	item, err := service.Menu().Update(ctx, req.Id, req.MenuInput)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.MenuUpdateRes{SystemMenuItem: item}, nil
+}
+
+// Delete soft deletes a menu and its descendants.
+func (c *ControllerV1) Delete(ctx context.Context, req *v1.MenuDeleteReq) (res *v1.MenuDeleteRes, err error) {
+	if err = service.Menu().Delete(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &v1.MenuDeleteRes{}, nil
+}
+
+// Move reparents and reorders menus in one transaction.
+func (c *ControllerV1) Move(ctx context.Context, req *v1.MenuMoveReq) (res v1.MenuMoveRes, err error) {
+	tree, err := service.Menu().Move(ctx, req.Moves)
+	return v1.MenuMoveRes(tree), err
+}
+
+// Export encodes the tenant's menu tree as a JSON or YAML bundle.
+func (c *ControllerV1) Export(ctx context.Context, req *v1.MenuExportReq) (res *v1.MenuExportRes, err error) {
+	content, err := service.Menu().Export(ctx, req.Format)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.MenuExportRes{Format: req.Format, Content: string(content)}, nil
+}
+
+// Import upserts the menus of a bundle, or reports what it would change.
+func (c *ControllerV1) Import(ctx context.Context, req *v1.MenuImportReq) (res *v1.MenuImportRes, err error) {
+	return service.Menu().Import(ctx, req.Format, []byte(req.Content), req.DryRun)
+}
+
+// RevisionList lists the menu revisions of the tenant.
+func (c *ControllerV1) RevisionList(ctx context.Context, req *v1.MenuRevisionListReq) (res *v1.MenuRevisionListRes, err error) {
+	return service.Menu().Revisions(ctx, "", req.Page, req.PageSize)
+}
+
+// History lists the revisions of one menu.
+func (c *ControllerV1) History(ctx context.Context, req *v1.MenuHistoryReq) (res *v1.MenuRevisionListRes, err error) {
+	return service.Menu().Revisions(ctx, req.Id, req.Page, req.PageSize)
+}
+
+// Restore returns one menu to an earlier revision.
+func (c *ControllerV1) Restore(ctx context.Context, req *v1.MenuRestoreReq) (res v1.MenuRestoreRes, err error) {
+	tree, err := service.Menu().Restore(ctx, req.Id, req.MenuRestorePoint)
+	return v1.MenuRestoreRes(tree), err
+}
+
+// RestoreTree returns every menu of the tenant to an earlier revision.
+func (c *ControllerV1) RestoreTree(ctx context.Context, req *v1.MenuRestoreTreeReq) (res v1.MenuRestoreRes, err error) {
+	tree, err := service.Menu().Restore(ctx, "", req.MenuRestorePoint)
+	return v1.MenuRestoreRes(tree), err
 }
@@ -0,0 +1,24 @@
+package notification
+
+import (
+	"context"
+
+	"backend/api/notification/v1"
+	"backend/internal/service"
+)
+
+// ControllerV1 handles the caller's notifications.
+type ControllerV1 struct{}
+
+// List returns a page of the caller's notifications.
+func (c *ControllerV1) List(ctx context.Context, req *v1.NotificationListReq) (res *v1.NotificationListRes, err error) {
+	return service.Notification().List(ctx, *req)
+}
+
+// Read marks a notification as read.
+func (c *ControllerV1) Read(ctx context.Context, req *v1.NotificationReadReq) (res *v1.NotificationReadRes, err error) {
+	if err = service.Notification().Read(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &v1.NotificationReadRes{}, nil
+}
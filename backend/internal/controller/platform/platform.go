@@ -0,0 +1,71 @@
+package platform
+
+import (
+	"context"
+
+	"backend/api/platform/v1"
+	"backend/internal/service"
+)
+
+// ControllerV1 handles platform operator endpoints.
+type ControllerV1 struct{}
+
+// TenantList returns a page of tenants.
+func (c *ControllerV1) TenantList(ctx context.Context, req *v1.TenantListReq) (res *v1.TenantListRes, err error) {
+	return service.Platform().ListTenants(ctx, *req)
+}
+
+// TenantCreate creates a tenant.
+func (c *ControllerV1) TenantCreate(ctx context.Context, req *v1.TenantCreateReq) (res *v1.TenantCreateRes, err error) {
+	item, err := service.Platform().CreateTenant(ctx, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.TenantCreateRes{TenantItem: item}, nil
+}
+
+// TenantUpdate renames, enables or disables a tenant.
+func (c *ControllerV1) TenantUpdate(ctx context.Context, req *v1.TenantUpdateReq) (res *v1.TenantUpdateRes, err error) {
+	item, err := service.Platform().UpdateTenant(ctx, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.TenantUpdateRes{TenantItem: item}, nil
+}
+
+// TenantMenuSync pushes the menu template to a tenant, or reports what it would change.
+func (c *ControllerV1) TenantMenuSync(ctx context.Context, req *v1.TenantMenuSyncReq) (res *v1.TenantMenuSyncRes, err error) {
+	result, err := service.Platform().SyncTenantMenus(ctx, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.TenantMenuSyncRes{MenuImportRes: result}, nil
+}
+
+// UserList returns a page of users across tenants.
+func (c *ControllerV1) UserList(ctx context.Context, req *v1.PlatformUserListReq) (res *v1.PlatformUserListRes, err error) {
+	return service.Platform().ListUsers(ctx, *req)
+}
+
+// UserTenant moves a user to another tenant.
+func (c *ControllerV1) UserTenant(ctx context.Context, req *v1.PlatformUserTenantReq) (res *v1.PlatformUserTenantRes, err error) {
+	item, err := service.Platform().MoveUser(ctx, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.PlatformUserTenantRes{PlatformUserItem: item}, nil
+}
+
+// SettingList returns the global settings.
+func (c *ControllerV1) SettingList(ctx context.Context, req *v1.SettingListReq) (res *v1.SettingListRes, err error) {
+	return service.Platform().ListSettings(ctx)
+}
+
+// SettingSave creates or replaces a global setting.
+func (c *ControllerV1) SettingSave(ctx context.Context, req *v1.SettingSaveReq) (res *v1.SettingSaveRes, err error) {
+	item, err := service.Platform().SaveSetting(ctx, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.SettingSaveRes{SettingItem: item}, nil
+}
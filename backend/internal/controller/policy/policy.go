@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"context"
+
+	"backend/api/policy/v1"
+	"backend/internal/service"
+)
+
+// ControllerV1 handles casbin policy administration endpoints.
+type ControllerV1 struct{}
+
+// List returns a page of policies visible to the caller.
+func (c *ControllerV1) List(ctx context.Context, req *v1.PolicyListReq) (res *v1.PolicyListRes, err error) {
+	return service.Policy().List(ctx, *req)
+}
+
+// Create adds a policy.
+func (c *ControllerV1) Create(ctx context.Context, req *v1.PolicyCreateReq) (res *v1.PolicyCreateRes, err error) {
+	item, err := service.Policy().Create(ctx, req.PolicyInput)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.PolicyCreateRes{PolicyItem: item}, nil
+}
+
+// Update replaces a policy identified by id.
+func (c *ControllerV1) Update(ctx context.Context, req *v1.PolicyUpdateReq) (res *v1.PolicyUpdateRes, err error) {
+	item, err := service.Policy().Update(ctx, req.Id, req.PolicyInput)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.PolicyUpdateRes{PolicyItem: item}, nil
+}
+
+// Delete removes a policy identified by id.
+func (c *ControllerV1) Delete(ctx context.Context, req *v1.PolicyDeleteReq) (res *v1.PolicyDeleteRes, err error) {
+	if err = service.Policy().Delete(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &v1.PolicyDeleteRes{}, nil
+}
+
+// Reload reloads policies from storage on every instance.
+func (c *ControllerV1) Reload(ctx context.Context, req *v1.PolicyReloadReq) (res *v1.PolicyReloadRes, err error) {
+	if err = service.Policy().Reload(ctx); err != nil {
+		return nil, err
+	}
+	return &v1.PolicyReloadRes{}, nil
+}
+
+// Explain reports how a request of a user would be authorized.
+func (c *ControllerV1) Explain(ctx context.Context, req *v1.PolicyExplainReq) (res *v1.PolicyExplainRes, err error) {
+	return service.Policy().Explain(ctx, *req)
+}
+
+// ConditionList returns the policy conditions of a domain.
+func (c *ControllerV1) ConditionList(ctx context.Context, req *v1.PolicyConditionListReq) (res *v1.PolicyConditionListRes, err error) {
+	return service.Policy().ListConditions(ctx, req.Domain)
+}
+
+// ConditionSave sets the condition of a subject.
+func (c *ControllerV1) ConditionSave(ctx context.Context, req *v1.PolicyConditionSaveReq) (res *v1.PolicyConditionSaveRes, err error) {
+	item, err := service.Policy().SaveCondition(ctx, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.PolicyConditionSaveRes{PolicyConditionItem: item}, nil
+}
+
+// ConditionDelete removes a policy condition.
+func (c *ControllerV1) ConditionDelete(ctx context.Context, req *v1.PolicyConditionDeleteReq) (res *v1.PolicyConditionDeleteRes, err error) {
+	if err = service.Policy().DeleteCondition(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &v1.PolicyConditionDeleteRes{}, nil
+}
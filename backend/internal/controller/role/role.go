@@ -0,0 +1,80 @@
+package role
+
+import (
+	"context"
+
+	"backend/api/role/v1"
+	"backend/internal/service"
+)
+
+// ControllerV1 handles time-bound role grants and role requests.
+type ControllerV1 struct{}
+
+// GrantList returns a page of the tenant's role grants.
+func (c *ControllerV1) GrantList(ctx context.Context, req *v1.RoleGrantListReq) (res *v1.RoleGrantListRes, err error) {
+	return service.Role().ListGrants(ctx, *req)
+}
+
+// GrantCreate grants a role to a user for a period of time.
+func (c *ControllerV1) GrantCreate(ctx context.Context, req *v1.RoleGrantCreateReq) (res *v1.RoleGrantCreateRes, err error) {
+	item, err := service.Role().CreateGrant(ctx, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.RoleGrantCreateRes{RoleGrantItem: item}, nil
+}
+
+// GrantDelete revokes a role grant.
+func (c *ControllerV1) GrantDelete(ctx context.Context, req *v1.RoleGrantDeleteReq) (res *v1.RoleGrantDeleteRes, err error) {
+	if err = service.Role().DeleteGrant(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &v1.RoleGrantDeleteRes{}, nil
+}
+
+// RequestCreate records the caller's request for a role.
+func (c *ControllerV1) RequestCreate(ctx context.Context, req *v1.RoleRequestCreateReq) (res *v1.RoleRequestCreateRes, err error) {
+	item, err := service.Role().CreateRequest(ctx, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.RoleRequestCreateRes{RoleRequestItem: item}, nil
+}
+
+// RequestMine returns the caller's role requests.
+func (c *ControllerV1) RequestMine(ctx context.Context, req *v1.RoleRequestMineReq) (res *v1.RoleRequestListRes, err error) {
+	return service.Role().MyRequests(ctx, *req)
+}
+
+// RequestList returns the tenant's role requests.
+func (c *ControllerV1) RequestList(ctx context.Context, req *v1.RoleRequestListReq) (res *v1.RoleRequestListRes, err error) {
+	return service.Role().ListRequests(ctx, *req)
+}
+
+// RequestApprove approves a pending role request.
+func (c *ControllerV1) RequestApprove(ctx context.Context, req *v1.RoleRequestApproveReq) (res *v1.RoleRequestApproveRes, err error) {
+	return service.Role().Approve(ctx, req.Id, req.Comment)
+}
+
+// RequestReject rejects a pending role request.
+func (c *ControllerV1) RequestReject(ctx context.Context, req *v1.RoleRequestRejectReq) (res *v1.RoleRequestRejectRes, err error) {
+	item, err := service.Role().Reject(ctx, req.Id, req.Comment)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.RoleRequestRejectRes{RoleRequestItem: item}, nil
+}
+
+// MenuList returns the menus granted to a role.
+func (c *ControllerV1) MenuList(ctx context.Context, req *v1.RoleMenuListReq) (res *v1.RoleMenuListRes, err error) {
+	return service.Role().ListMenus(ctx, req.Role)
+}
+
+// MenuSave replaces the menus granted to a role.
+func (c *ControllerV1) MenuSave(ctx context.Context, req *v1.RoleMenuSaveReq) (res *v1.RoleMenuSaveRes, err error) {
+	out, err := service.Role().SaveMenus(ctx, req.Role, req.MenuIds)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.RoleMenuSaveRes{RoleMenuListRes: out}, nil
+}
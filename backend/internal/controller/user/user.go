@@ -29,3 +29,34 @@ func (c *ControllerV1) Info(ctx context.Context, req *v1.UserInfoReq) (res *v1.U
 	}
 	return service.User().Info(ctx, token)
 }
+
+// List returns a page of the users the caller may see.
+func (c *ControllerV1) List(ctx context.Context, req *v1.UserListReq) (res *v1.UserListRes, err error) {
+	return service.User().List(ctx, *req)
+}
+
+// Update changes the profile and department of a user.
+func (c *ControllerV1) Update(ctx context.Context, req *v1.UserUpdateReq) (res *v1.UserUpdateRes, err error) {
+	item, err := service.User().Update(ctx, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.UserUpdateRes{UserItem: item}, nil
+}
+
+// Status enables or disables a user.
+func (c *ControllerV1) Status(ctx context.Context, req *v1.UserStatusReq) (res *v1.UserStatusRes, err error) {
+	item, err := service.User().SetStatus(ctx, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.UserStatusRes{UserItem: item}, nil
+}
+
+// Delete soft deletes a user.
+func (c *ControllerV1) Delete(ctx context.Context, req *v1.UserDeleteReq) (res *v1.UserDeleteRes, err error) {
+	if err = service.User().Delete(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &v1.UserDeleteRes{}, nil
+}
@@ -1,103 +1,123 @@
 package middleware
 
 import (
+	"context"
+	"slices"
 	"strings"
 
 	"backend/internal/consts"
-	"backend/internal/dao"
-	"backend/internal/model/entity"
 	"backend/internal/service"
 
 	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gmode"
 )
 
-var publicPaths = map[string]struct{}{
-	"/auth/login":   {},
-	"/auth/refresh": {},
-}
+// authzDebugHeader carries the decision and deciding rule of CasbinAuthz when
+// casbin.debugHeader is enabled outside production.
+const authzDebugHeader = "X-Authz-Decision"
 
-// CasbinAuthz enforces interface-level permission checks using Casbin.
+// CasbinAuthz enforces interface-level permission checks using Casbin, plus
+// the permission code a route declares with a perm tag. Routes tagged
+// public:"true" and paths listed in casbin.publicPaths are served anonymously.
+// Platform routes are checked in the platform domain with the user's operator
+// roles; every other route is checked in the caller's own tenant only.
 func CasbinAuthz() ghttp.HandlerFunc {
+	var (
+		ctx         = context.Background()
+		debugHeader = authzDebugHeaderEnabled(ctx)
+		paths       = loadPublicPaths(ctx)
+		proxies     = loadTrustedProxies(ctx)
+	)
 	return func(r *ghttp.Request) {
 		if r.Method == "OPTIONS" {
 			r.Middleware.Next()
 			return
 		}
-		if _, ok := publicPaths[r.URL.Path]; ok {
+		if paths.Match(r.URL.Path) || isPublicRoute(r) {
 			r.Middleware.Next()
 			return
 		}
 
-		token, err := service.ResolveAccessToken(r.Context(), "")
+		principal, err := service.ResolvePrincipal(r.Context())
 		if err != nil {
 			r.SetError(err)
 			r.Exit()
 			return
 		}
-		claims, err := service.ParseAccessToken(token)
+		r.SetCtx(service.WithPrincipal(r.Context(), principal))
+
+		platform := isPlatformRoute(r)
+		domain, subjects := service.AuthzTarget(principal, platform)
+		obj := r.URL.Path
+		act := strings.ToLower(r.Method)
+
+		enforcer, err := service.Casbin(r.Context(), domain)
 		if err != nil {
 			r.SetError(err)
 			r.Exit()
 			return
 		}
 
-		userID, _ := claims["id"].(string)
-		if userID == "" {
-			r.SetError(gerror.NewCode(consts.ErrorCodeUnauthorized, "invalid token subject"))
-			r.Exit()
-			return
-		}
-
-		var user entity.SysUser
-		if err := dao.SysUser.Ctx(r.Context()).Where(dao.SysUser.Columns().Id, userID).Scan(&user); err != nil {
+		env, err := service.NewRequestEnv(r.Context(), domain, proxies.clientIp(r), r.UserAgent())
+		if err != nil {
 			r.SetError(err)
 			r.Exit()
 			return
 		}
-		if user.Id == "" {
-			r.SetError(gerror.NewCode(consts.ErrorCodeUserNotFound, "user not found"))
+		allowed, decisions, err := service.EvaluateSubjects(enforcer, subjects, domain, obj, act, env)
+		if err != nil {
+			r.SetError(err)
 			r.Exit()
 			return
 		}
-
-		roles := service.ParseRoles(user.Roles)
-		if len(roles) == 0 {
-			roles = []string{"super"}
-		}
-
-		tenantID := user.TenantId
-		if claimTenant, ok := claims["tenantId"].(string); ok && strings.TrimSpace(claimTenant) != "" {
-			tenantID = claimTenant
+		if debugHeader {
+			r.Response.Header().Set(authzDebugHeader, formatAuthzDecision(allowed, decisions))
 		}
-		domain := service.NormalizeDomain(tenantID)
-		obj := r.URL.Path
-		act := strings.ToLower(r.Method)
-
-		enforcer, err := service.Casbin(r.Context())
-		if err != nil {
-			r.SetError(err)
+		if !allowed {
+			r.SetError(gerror.NewCode(consts.ErrorCodeUnauthorized, "permission denied"))
 			r.Exit()
 			return
 		}
 
-		for _, role := range roles {
-			if strings.TrimSpace(role) == "" {
-				continue
-			}
-			allowed, err := enforcer.Enforce(role, domain, obj, act)
+		// Tenant routes declaring a perm tag also require that code, as
+		// returned by /auth/codes.
+		if code := requiredPermission(r); code != "" && !platform {
+			codes, err := service.UserAccessCodes(r.Context(), principal)
 			if err != nil {
 				r.SetError(err)
 				r.Exit()
 				return
 			}
-			if allowed {
-				r.Middleware.Next()
+			if !slices.Contains(codes, code) {
+				r.SetError(gerror.NewCodef(consts.ErrorCodeForbidden, "permission %s required", code))
+				r.Exit()
 				return
 			}
 		}
+		r.Middleware.Next()
+	}
+}
 
-		r.SetError(gerror.NewCode(consts.ErrorCodeUnauthorized, "permission denied"))
-		r.Exit()
+func authzDebugHeaderEnabled(ctx context.Context) bool {
+	if gmode.IsProduct() {
+		return false
+	}
+	v, err := g.Cfg().Get(ctx, "casbin.debugHeader")
+	return err == nil && v != nil && v.Bool()
+}
+
+// formatAuthzDecision renders a decision as e.g.
+// "deny; rule=admin, t1, /tenant/billing/*, .*, deny".
+func formatAuthzDecision(allowed bool, decisions []service.SubjectDecision) string {
+	decision := service.PolicyEffectDeny
+	if allowed {
+		decision = service.PolicyEffectAllow
+	}
+	rule := service.DecisiveRule(allowed, decisions)
+	if len(rule) == 0 {
+		return decision + "; no matching rule"
 	}
+	return decision + "; rule=" + strings.Join(rule, ", ")
 }
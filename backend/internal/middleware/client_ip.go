@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// trustedProxies are the networks of the reverse proxies whose forwarding
+// headers are believed, as listed in casbin.trustedProxies.
+type trustedProxies []*net.IPNet
+
+// loadTrustedProxies reads casbin.trustedProxies.
+func loadTrustedProxies(ctx context.Context) trustedProxies {
+	v, err := g.Cfg().Get(ctx, "casbin.trustedProxies")
+	if err != nil || v == nil {
+		return nil
+	}
+	return parseTrustedProxies(ctx, v.Strings())
+}
+
+// parseTrustedProxies parses CIDR ranges and single addresses; invalid
+// entries are logged and skipped.
+func parseTrustedProxies(ctx context.Context, entries []string) trustedProxies {
+	var proxies trustedProxies
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil {
+				bits := 8 * len(ip.To16())
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 32
+				}
+				proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+				continue
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			g.Log().Warningf(ctx, "ignoring invalid trusted proxy %q: %v", entry, err)
+			continue
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies
+}
+
+// contains reports whether ip is the address of a trusted proxy.
+func (p trustedProxies) contains(ip string) bool {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIp returns the address policy conditions are checked against. It is
+// the peer of the connection unless that peer is a trusted proxy; then it is
+// the nearest address in X-Forwarded-For that is not a trusted proxy, or
+// X-Real-IP when there is no X-Forwarded-For. Headers sent by any other peer
+// are ignored, so clients cannot claim an address they do not connect from.
+func (p trustedProxies) clientIp(r *ghttp.Request) string {
+	remote := r.GetRemoteIp()
+	if !p.contains(remote) {
+		return remote
+	}
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// The proxies wrote valid addresses up to here; what
+				// precedes came from the client.
+				break
+			}
+			client = hop
+			if !p.contains(hop) {
+				break
+			}
+		}
+		return client
+	}
+	if real := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(real) != nil {
+		return real
+	}
+	return remote
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies := parseTrustedProxies(context.Background(), []string{"10.0.0.0/8", " 192.168.1.1 ", "::1", "proxy.local", ""})
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(len(proxies), 3)
+		t.Assert(proxies.contains("10.1.2.3"), true)
+		t.Assert(proxies.contains("192.168.1.1"), true)
+		t.Assert(proxies.contains("192.168.1.2"), false)
+		t.Assert(proxies.contains("::1"), true)
+		t.Assert(proxies.contains("11.1.2.3"), false)
+		t.Assert(proxies.contains("not-an-ip"), false)
+		t.Assert(trustedProxies(nil).contains("10.1.2.3"), false)
+	})
+}
+
+// TestClientIp_OfficeCondition replays the IP range check of CasbinAuthz for
+// a policy restricted to the office network, with the server reached from
+// 127.0.0.1.
+func TestClientIp_OfficeCondition(t *testing.T) {
+	_, office, _ := net.ParseCIDR("203.0.113.0/24")
+	var proxies trustedProxies
+	s := g.Server(guid.S())
+	s.SetDumpRouterMap(false)
+	s.SetAccessLogEnabled(false)
+	s.BindHandler("/office", func(r *ghttp.Request) {
+		if ip := net.ParseIP(proxies.clientIp(r)); ip == nil || !office.Contains(ip) {
+			r.Response.WriteStatus(http.StatusForbidden)
+			return
+		}
+		r.Response.Write("ok")
+	})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown()
+	time.Sleep(100 * time.Millisecond)
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/office", s.GetListenedPort())
+	get := func(headers map[string]string) int {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	gtest.C(t, func(t *gtest.T) {
+		// A client outside the office claiming an office address is denied
+		// when it connects directly.
+		t.Assert(get(map[string]string{"X-Forwarded-For": "203.0.113.7"}), http.StatusForbidden)
+		t.Assert(get(map[string]string{"X-Real-IP": "203.0.113.7"}), http.StatusForbidden)
+		t.Assert(get(map[string]string{"Proxy-Client-IP": "203.0.113.7"}), http.StatusForbidden)
+
+		// Behind a trusted proxy the forwarded address counts.
+		_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+		proxies = trustedProxies{loopback}
+		t.Assert(get(map[string]string{"X-Forwarded-For": "203.0.113.7"}), http.StatusOK)
+		t.Assert(get(map[string]string{"X-Real-IP": "203.0.113.7"}), http.StatusOK)
+		// The proxy appends the real peer; what the client sent before it
+		// is not believed.
+		t.Assert(get(map[string]string{"X-Forwarded-For": "203.0.113.7, 198.51.100.9"}), http.StatusForbidden)
+		t.Assert(get(map[string]string{"X-Forwarded-For": "198.51.100.9, 203.0.113.7, 127.0.0.2"}), http.StatusOK)
+		t.Assert(get(nil), http.StatusForbidden)
+	})
+}
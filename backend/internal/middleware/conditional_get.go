@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// ConditionalGet answers GET and HEAD requests with 304 Not Modified when the
+// handler set an ETag header that the request's If-None-Match names. It must
+// run before ghttp.MiddlewareHandlerResponse so that it sees the written
+// response.
+func ConditionalGet() ghttp.HandlerFunc {
+	return func(r *ghttp.Request) {
+		r.Middleware.Next()
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			return
+		}
+		if r.GetError() != nil || (r.Response.Status != 0 && r.Response.Status != http.StatusOK) {
+			return
+		}
+		etag := r.Response.Header().Get("ETag")
+		if etag == "" || !etagMatches(r.Header.Get("If-None-Match"), etag) {
+			return
+		}
+		r.Response.ClearBuffer()
+		r.Response.WriteHeader(http.StatusNotModified)
+	}
+}
+
+// etagMatches reports whether the If-None-Match header value ifNoneMatch
+// names etag, comparing weakly as RFC 9110 requires for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
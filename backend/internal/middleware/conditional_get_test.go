@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+type testEtagReq struct {
+	g.Meta `path:"/menus" method:"get,post"`
+}
+
+type testEtagController struct{}
+
+func (c *testEtagController) Menus(ctx context.Context, req *testEtagReq) (res g.Map, err error) {
+	g.RequestFromCtx(ctx).Response.Header().Set("ETag", `W/"v1"`)
+	return g.Map{"menus": 1}, nil
+}
+
+func TestEtagMatches(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(etagMatches(`W/"abc"`, `W/"abc"`), true)
+		t.Assert(etagMatches(`"abc"`, `W/"abc"`), true)
+		t.Assert(etagMatches(`"x", W/"abc"`, `W/"abc"`), true)
+		t.Assert(etagMatches(`*`, `W/"abc"`), true)
+		t.Assert(etagMatches(`W/"abd"`, `W/"abc"`), false)
+		t.Assert(etagMatches(``, `W/"abc"`), false)
+	})
+}
+
+func TestConditionalGet(t *testing.T) {
+	s := g.Server(guid.S())
+	s.SetDumpRouterMap(false)
+	s.SetAccessLogEnabled(false)
+	s.Group("/", func(group *ghttp.RouterGroup) {
+		group.Middleware(ConditionalGet(), ghttp.MiddlewareHandlerResponse)
+		group.Bind(&testEtagController{})
+	})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown()
+	time.Sleep(100 * time.Millisecond)
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/menus", s.GetListenedPort())
+	get := func(method, ifNoneMatch string) *http.Response {
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res
+	}
+	gtest.C(t, func(t *gtest.T) {
+		res := get(http.MethodGet, "")
+		t.Assert(res.StatusCode, http.StatusOK)
+		t.Assert(res.Header.Get("ETag"), `W/"v1"`)
+
+		res = get(http.MethodGet, `W/"v1"`)
+		t.Assert(res.StatusCode, http.StatusNotModified)
+		t.Assert(res.Header.Get("ETag"), `W/"v1"`)
+
+		t.Assert(get(http.MethodGet, `W/"v0"`).StatusCode, http.StatusOK)
+		t.Assert(get(http.MethodPost, `W/"v1"`).StatusCode, http.StatusOK)
+	})
+}
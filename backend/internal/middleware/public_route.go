@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// publicRoutes holds the request struct types whose g.Meta carries
+// public:"true". It is filled by BindRoutePermissions before the server starts.
+var publicRoutes = map[reflect.Type]struct{}{}
+
+// publicPaths matches request paths configured as anonymous by
+// casbin.publicPaths. Entries ending in "*" match by prefix, e.g. "/oidc/*".
+type publicPaths struct {
+	exact    map[string]struct{}
+	prefixes []string
+}
+
+func loadPublicPaths(ctx context.Context) *publicPaths {
+	paths := &publicPaths{exact: make(map[string]struct{})}
+	v, err := g.Cfg().Get(ctx, "casbin.publicPaths")
+	if err != nil || v == nil {
+		return paths
+	}
+	for _, entry := range v.Strings() {
+		paths.add(entry)
+	}
+	return paths
+}
+
+func (p *publicPaths) add(entry string) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return
+	}
+	if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+		p.prefixes = append(p.prefixes, prefix)
+		return
+	}
+	p.exact[entry] = struct{}{}
+}
+
+// Match reports whether requestPath is public. The path is cleaned first so
+// "/public/../system" does not pass as "/public/".
+func (p *publicPaths) Match(requestPath string) bool {
+	if requestPath == "" {
+		return false
+	}
+	cleaned := path.Clean(requestPath)
+	if strings.HasSuffix(requestPath, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	if _, ok := p.exact[cleaned]; ok {
+		return true
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(cleaned, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns the configured entries.
+func (p *publicPaths) Entries() []string {
+	entries := make([]string, 0, len(p.exact)+len(p.prefixes))
+	for entry := range p.exact {
+		entries = append(entries, entry)
+	}
+	for _, prefix := range p.prefixes {
+		entries = append(entries, prefix+"*")
+	}
+	return entries
+}
+
+// isPublicRoute reports whether the route serving r is tagged public.
+func isPublicRoute(r *ghttp.Request) bool {
+	reqType := servedRequestType(r)
+	if reqType == nil {
+		return false
+	}
+	_, ok := publicRoutes[reqType]
+	return ok
+}
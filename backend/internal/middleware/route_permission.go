@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	"backend/internal/consts"
+	"backend/internal/service"
+
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gmeta"
+)
+
+// routePermissions maps request struct types to the permission code declared
+// by the perm tag of their g.Meta. It is filled before the server starts.
+var routePermissions = map[reflect.Type]string{}
+
+// platformRoutes holds the request struct types whose g.Meta carries
+// domain:"platform". CasbinAuthz checks them in the platform domain.
+var platformRoutes = map[reflect.Type]struct{}{}
+
+// RouteAccessReport lists how CasbinAuthz treats the bound routes, as
+// "METHOD path" entries.
+type RouteAccessReport struct {
+	// Public routes are served without a token, by public tag or by
+	// casbin.publicPaths.
+	Public []string
+	// Platform routes are checked in the platform domain.
+	Platform []string
+	// Untagged routes require a token but declare no permission code.
+	Untagged []string
+}
+
+// BindRoutePermissions collects the perm and public tags of the request
+// structs handled by controllers and reports the anonymous routes and the
+// routes declaring no permission code.
+func BindRoutePermissions(ctx context.Context, controllers ...interface{}) RouteAccessReport {
+	var (
+		report  RouteAccessReport
+		paths   = loadPublicPaths(ctx)
+		matched = make(map[string]bool)
+	)
+	for _, controller := range controllers {
+		controllerType := reflect.TypeOf(controller)
+		for i := 0; i < controllerType.NumMethod(); i++ {
+			reqType := handlerRequestType(controllerType.Method(i).Type)
+			if reqType == nil {
+				continue
+			}
+			req := reflect.New(reqType.Elem()).Interface()
+			path := gmeta.Get(req, "path").String()
+			if path == "" {
+				continue
+			}
+			name := routeName(gmeta.Get(req, "method").String(), path)
+			if gmeta.Get(req, "public").Bool() {
+				publicRoutes[reqType] = struct{}{}
+				report.Public = append(report.Public, name)
+				continue
+			}
+			if paths.Match(path) {
+				matched[path] = true
+				report.Public = append(report.Public, name)
+				continue
+			}
+			if strings.TrimSpace(gmeta.Get(req, "domain").String()) == consts.PlatformDomain {
+				platformRoutes[reqType] = struct{}{}
+				report.Platform = append(report.Platform, name)
+				continue
+			}
+			if code := strings.TrimSpace(gmeta.Get(req, "perm").String()); code != "" {
+				routePermissions[reqType] = code
+				continue
+			}
+			report.Untagged = append(report.Untagged, name)
+		}
+	}
+	// Configured paths may also cover routes bound elsewhere, e.g. static files.
+	for _, entry := range paths.Entries() {
+		if !matched[entry] {
+			report.Public = append(report.Public, routeName("", entry))
+		}
+	}
+	sort.Strings(report.Public)
+	sort.Strings(report.Platform)
+	sort.Strings(report.Untagged)
+	return report
+}
+
+// handlerRequestType returns the request type of a controller method of the
+// form func(ctrl, context.Context, *XxxReq) (*XxxRes, error).
+func handlerRequestType(method reflect.Type) reflect.Type {
+	if method.NumIn() != 3 || method.In(1) != reflect.TypeOf((*context.Context)(nil)).Elem() {
+		return nil
+	}
+	reqType := method.In(2)
+	if reqType.Kind() != reflect.Ptr || reqType.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return reqType
+}
+
+// requiredPermission returns the permission code declared for the route
+// serving r, if any.
+func requiredPermission(r *ghttp.Request) string {
+	reqType := servedRequestType(r)
+	if reqType == nil {
+		return ""
+	}
+	return routePermissions[reqType]
+}
+
+// isPlatformRoute reports whether r is checked in the platform domain: its
+// route declares domain:"platform" or its path is under /platform.
+func isPlatformRoute(r *ghttp.Request) bool {
+	if reqType := servedRequestType(r); reqType != nil {
+		if _, ok := platformRoutes[reqType]; ok {
+			return true
+		}
+	}
+	return service.IsPlatformPath(r.URL.Path)
+}
+
+// servedRequestType returns the request struct type of the handler serving r.
+func servedRequestType(r *ghttp.Request) reflect.Type {
+	handler := r.GetServeHandler()
+	if handler == nil || handler.Handler == nil || handler.Handler.Info.Type == nil {
+		return nil
+	}
+	funcType := handler.Handler.Info.Type
+	if funcType.NumIn() != 2 {
+		return nil
+	}
+	return funcType.In(1)
+}
+
+func routeName(method, path string) string {
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if method == "" {
+		method = "ALL"
+	}
+	return method + " " + path
+}
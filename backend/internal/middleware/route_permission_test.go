@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+type testTaggedReq struct {
+	g.Meta `path:"/system/thing" method:"post" perm:"System:Thing:Create"`
+}
+
+type testUntaggedReq struct {
+	g.Meta `path:"/thing/list" method:"get"`
+}
+
+type testPublicReq struct {
+	g.Meta `path:"/thing/callback" method:"get" public:"true"`
+}
+
+type testPlatformReq struct {
+	g.Meta `path:"/platform/thing/list" method:"get" domain:"platform" perm:"Platform:Thing:List"`
+}
+
+type testController struct{}
+
+func (c *testController) Callback(ctx context.Context, req *testPublicReq) (res *struct{}, err error) {
+	return nil, nil
+}
+
+func (c *testController) Create(ctx context.Context, req *testTaggedReq) (res *struct{}, err error) {
+	return nil, nil
+}
+
+func (c *testController) List(ctx context.Context, req *testUntaggedReq) (res *struct{}, err error) {
+	return nil, nil
+}
+
+func (c *testController) PlatformList(ctx context.Context, req *testPlatformReq) (res *struct{}, err error) {
+	return nil, nil
+}
+
+func (c *testController) Helper(name string) string { return name }
+
+func TestBindRoutePermissions(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		report := BindRoutePermissions(context.Background(), &testController{})
+		t.Assert(report.Untagged, []string{"GET /thing/list"})
+		t.Assert(report.Public, []string{"GET /thing/callback"})
+		t.Assert(report.Platform, []string{"GET /platform/thing/list"})
+		t.Assert(routePermissions[reflect.TypeOf(&testTaggedReq{})], "System:Thing:Create")
+		_, ok := routePermissions[reflect.TypeOf(&testUntaggedReq{})]
+		t.Assert(ok, false)
+		_, ok = publicRoutes[reflect.TypeOf(&testPublicReq{})]
+		t.Assert(ok, true)
+		_, ok = platformRoutes[reflect.TypeOf(&testPlatformReq{})]
+		t.Assert(ok, true)
+		// Tenant access codes never gate platform routes.
+		_, ok = routePermissions[reflect.TypeOf(&testPlatformReq{})]
+		t.Assert(ok, false)
+	})
+}
+
+func TestPublicPaths_Match(t *testing.T) {
+	paths := &publicPaths{exact: make(map[string]struct{})}
+	for _, entry := range []string{"/health", "/oidc/*", " ", "/static*"} {
+		paths.add(entry)
+	}
+	testCases := []struct {
+		path string
+		want bool
+	}{
+		{path: "/health", want: true},
+		{path: "/health/", want: false},
+		{path: "/healthz", want: false},
+		{path: "/oidc/callback", want: true},
+		{path: "/oidc/", want: true},
+		{path: "/oidc", want: false},
+		{path: "/oidc/../system/policy/list", want: false},
+		{path: "/oidc//callback", want: true},
+		{path: "/staticfiles/app.js", want: true},
+		{path: "/system/menu", want: false},
+		{path: "", want: false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.path, func(t *testing.T) {
+			gtest.C(t, func(t *gtest.T) {
+				t.Assert(paths.Match(tc.path), tc.want)
+			})
+		})
+	}
+}
@@ -11,17 +11,20 @@ import (
 
 // SysUser is the golang structure of table sys_user for DAO operations like Where/Data.
 type SysUser struct {
-	g.Meta    `orm:"table:sys_user, do:true"`
-	Id        any         //
-	TenantId  any         //
-	Username  any         //
-	Password  any         //
-	RealName  any         //
-	Avatar    any         //
-	HomePath  any         //
-	Status    any         //
-	Roles     any         //
-	CreatedAt *gtime.Time //
-	UpdatedAt *gtime.Time //
-	DeletedAt *gtime.Time //
+	g.Meta      `orm:"table:sys_user, do:true"`
+	Id          any         //
+	TenantId    any         //
+	Username    any         //
+	Password    any         //
+	RealName    any         //
+	Avatar      any         //
+	HomePath    any         //
+	Status      any         //
+	Roles       any         //
+	CreatedAt   *gtime.Time //
+	UpdatedAt   *gtime.Time //
+	DeletedAt   *gtime.Time //
+	DeptId      any         //
+	CreatedBy   any         //
+	LegacySuper any         //
 }
@@ -10,16 +10,19 @@ import (
 
 // SysUser is the golang structure for table sys_user.
 type SysUser struct {
-	Id        string      `json:"id"        orm:"id"         description:""` //
-	TenantId  string      `json:"tenantId"  orm:"tenant_id"  description:""` //
-	Username  string      `json:"username"  orm:"username"   description:""` //
-	Password  string      `json:"password"  orm:"password"   description:""` //
-	RealName  string      `json:"realName"  orm:"real_name"  description:""` //
-	Avatar    string      `json:"avatar"    orm:"avatar"     description:""` //
-	HomePath  string      `json:"homePath"  orm:"home_path"  description:""` //
-	Status    int         `json:"status"    orm:"status"     description:""` //
-	Roles     string      `json:"roles"     orm:"roles"      description:""` //
-	CreatedAt *gtime.Time `json:"createdAt" orm:"created_at" description:""` //
-	UpdatedAt *gtime.Time `json:"updatedAt" orm:"updated_at" description:""` //
-	DeletedAt *gtime.Time `json:"deletedAt" orm:"deleted_at" description:""` //
+	Id          string      `json:"id"          orm:"id"           description:""` //
+	TenantId    string      `json:"tenantId"    orm:"tenant_id"    description:""` //
+	Username    string      `json:"username"    orm:"username"     description:""` //
+	Password    string      `json:"password"    orm:"password"     description:""` //
+	RealName    string      `json:"realName"    orm:"real_name"    description:""` //
+	Avatar      string      `json:"avatar"      orm:"avatar"       description:""` //
+	HomePath    string      `json:"homePath"    orm:"home_path"    description:""` //
+	Status      int         `json:"status"      orm:"status"       description:""` //
+	Roles       string      `json:"roles"       orm:"roles"        description:""` //
+	CreatedAt   *gtime.Time `json:"createdAt"   orm:"created_at"   description:""` //
+	UpdatedAt   *gtime.Time `json:"updatedAt"   orm:"updated_at"   description:""` //
+	DeletedAt   *gtime.Time `json:"deletedAt"   orm:"deleted_at"   description:""` //
+	DeptId      string      `json:"deptId"      orm:"dept_id"      description:""` //
+	CreatedBy   string      `json:"createdBy"   orm:"created_by"   description:""` //
+	LegacySuper bool        `json:"legacySuper" orm:"legacy_super" description:""` //
 }
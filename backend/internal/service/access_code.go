@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	menuTable     = "sys_menu"
+	roleMenuTable = "sys_role_menu"
+)
+
+// loadRoleMenuCodes returns the permission codes of the enabled menus and
+// buttons granted to the enabled roles with the given codes in domain.
+var loadRoleMenuCodes = func(ctx context.Context, domain string, roles []string) ([]string, error) {
+	if !isUUID(domain) || len(roles) == 0 {
+		return nil, nil
+	}
+	values, err := g.DB().Ctx(ctx).Model(roleMenuTable+" rm").
+		InnerJoin(roleTable+" r", "r.id = rm.role_id").
+		InnerJoin(menuTable+" m", "m.id = rm.menu_id").
+		Fields("DISTINCT m.permission_code").
+		Where("r.tenant_id", domain).
+		WhereIn("r.code", roles).
+		Where("r.status", 1).
+		Where("r.deleted_at is null").
+		Where("m.status", 1).
+		Where("m.deleted_at is null").
+		Where("coalesce(m.permission_code, '') <> ''").
+		Array()
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, len(values))
+	for _, v := range values {
+		codes = append(codes, v.String())
+	}
+	return codes, nil
+}
+
+// UserAccessCodes returns the permission codes of the principal, as served by
+// /auth/codes and required by routes declaring a perm tag. They are computed
+// once per principal.
+func UserAccessCodes(ctx context.Context, p *Principal) ([]string, error) {
+	p.accessCodesOnce.Do(func() {
+		domain := NormalizeDomain(p.TenantId)
+		var enforcer *casbin.SyncedEnforcer
+		if enforcer, p.accessCodesErr = Casbin(ctx, domain); p.accessCodesErr != nil {
+			return
+		}
+		p.accessCodes, p.accessCodesErr = accessCodes(ctx, enforcer, domain, p.Subjects())
+	})
+	return p.accessCodes, p.accessCodesErr
+}
+
+// accessCodes returns the permission codes of subjects in domain: the codes
+// of the menus and buttons granted to their roles, including roles inherited
+// through "g" rules, plus the codes named by casbin rules of those roles.
+func accessCodes(ctx context.Context, enforcer casbin.IEnforcer, domain string, subjects []string) ([]string, error) {
+	roles, err := effectiveRoles(enforcer, domain, subjects)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := loadRoleMenuCodes(ctx, domain, roles)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]struct{}, len(catalog))
+	for _, code := range catalog {
+		set[code] = struct{}{}
+	}
+	for _, code := range accessCodesFromCasbin(enforcer, domain, roles) {
+		set[code] = struct{}{}
+	}
+	codes := make([]string, 0, len(set))
+	for code := range set {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes, nil
+}
+
+// effectiveRoles returns subjects and every role they inherit in domain.
+func effectiveRoles(enforcer casbin.IEnforcer, domain string, subjects []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(subjects))
+	roles := make([]string, 0, len(subjects))
+	add := func(role string) {
+		if _, ok := seen[role]; ok || role == "" {
+			return
+		}
+		seen[role] = struct{}{}
+		roles = append(roles, role)
+	}
+	for _, subject := range subjects {
+		subject = strings.TrimSpace(subject)
+		add(subject)
+		inherited, err := enforcer.GetImplicitRolesForUser(subject, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range inherited {
+			add(role)
+		}
+	}
+	return roles, nil
+}
+
+// accessCodesFromCasbin returns the codes named by the allow rules of roles
+// in domain. Rules on URL patterns authorize requests and name no code.
+func accessCodesFromCasbin(enforcer casbin.IEnforcer, domain string, roles []string) []string {
+	set := make(map[string]struct{})
+	for _, role := range roles {
+		for _, perm := range enforcer.GetPermissionsForUserInDomain(role, domain) {
+			if len(perm) < 3 || policyEffect(perm) == PolicyEffectDeny {
+				continue
+			}
+			code := strings.TrimSpace(perm[2])
+			if code == "" || code == "*" || strings.HasPrefix(code, "/") {
+				continue
+			}
+			set[code] = struct{}{}
+		}
+	}
+	codes := make([]string, 0, len(set))
+	for code := range set {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
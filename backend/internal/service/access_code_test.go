@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+// stubRoleMenuCodes serves the menu codes granted to each role from memory.
+func stubRoleMenuCodes(tb testing.TB, byRole map[string][]string) {
+	tb.Helper()
+	previous := loadRoleMenuCodes
+	loadRoleMenuCodes = func(ctx context.Context, domain string, roles []string) ([]string, error) {
+		var codes []string
+		for _, role := range roles {
+			codes = append(codes, byRole[role]...)
+		}
+		return codes, nil
+	}
+	tb.Cleanup(func() { loadRoleMenuCodes = previous })
+}
+
+func TestAccessCodes(t *testing.T) {
+	stubRoleMenuCodes(t, map[string][]string{
+		"admin":   {"System:Menu:List", "System:Menu:Edit"},
+		"auditor": {"System:Menu:List"},
+	})
+	cache := newTestEnforcerCache(t, 10, newMemoryRules(
+		"p, admin, t1, /system/*, .*, allow",
+		"p, auditor, t1, System:Audit:List, get, allow",
+		"p, auditor, t1, System:Audit:Export, get, deny",
+		"g, lead, admin, t1",
+	))
+	enforcer, err := cache.Get("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	gtest.C(t, func(t *gtest.T) {
+		// Roles inherited through g contribute their menu grants.
+		codes, err := accessCodes(ctx, enforcer, "t1", []string{"u1", "lead"})
+		t.AssertNil(err)
+		t.Assert(codes, []string{"System:Menu:Edit", "System:Menu:List"})
+
+		// Menu grants and code rules are merged; deny rules grant nothing.
+		codes, err = accessCodes(ctx, enforcer, "t1", []string{"u2", "auditor"})
+		t.AssertNil(err)
+		t.Assert(codes, []string{"System:Audit:List", "System:Menu:List"})
+
+		// Roles without stored grants have no codes.
+		codes, err = accessCodes(ctx, enforcer, "t1", []string{"u3", "super"})
+		t.AssertNil(err)
+		t.Assert(len(codes), 0)
+	})
+}
+
+func TestAccessCodes_Error(t *testing.T) {
+	previous := loadRoleMenuCodes
+	loadRoleMenuCodes = func(ctx context.Context, domain string, roles []string) ([]string, error) {
+		return nil, errors.New("connection refused")
+	}
+	t.Cleanup(func() { loadRoleMenuCodes = previous })
+	cache := newTestEnforcerCache(t, 10, newMemoryRules("p, admin, t1, /*, .*, allow"))
+	enforcer, err := cache.Get("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gtest.C(t, func(t *gtest.T) {
+		codes, err := accessCodes(context.Background(), enforcer, "t1", []string{"u1", "admin"})
+		t.AssertNE(err, nil)
+		t.Assert(codes, nil)
+	})
+}
+
+func TestEffectiveRoles(t *testing.T) {
+	cache := newTestEnforcerCache(t, 10, newMemoryRules(
+		"g, alice, lead, t1",
+		"g, lead, admin, t1",
+		"g, alice, admin, t2",
+	))
+	enforcer, err := cache.Get("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gtest.C(t, func(t *gtest.T) {
+		roles, err := effectiveRoles(enforcer, "t1", []string{"alice", "staff", "lead"})
+		t.AssertNil(err)
+		slices.Sort(roles)
+		t.Assert(roles, []string{"admin", "alice", "lead", "staff"})
+	})
+}
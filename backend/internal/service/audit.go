@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const auditLogTable = "sys_audit_log"
+
+// auditEntry describes one administrative change written to sys_audit_log.
+type auditEntry struct {
+	TenantId   string
+	OperatorId string
+	Action     string
+	Resource   string
+	ResourceId string
+	Before     interface{}
+	After      interface{}
+}
+
+// writeAuditLog records an administrative change. Before/After are stored as JSON.
+func writeAuditLog(ctx context.Context, entry auditEntry) error {
+	data := g.Map{
+		"tenant_id":   NormalizeDomain(entry.TenantId),
+		"action":      entry.Action,
+		"resource":    entry.Resource,
+		"resource_id": entry.ResourceId,
+		"before":      auditJSON(entry.Before),
+		"after":       auditJSON(entry.After),
+	}
+	if isUUID(entry.OperatorId) {
+		data["operator_id"] = entry.OperatorId
+	}
+	if req := g.RequestFromCtx(ctx); req != nil {
+		data["client_ip"] = req.GetClientIp()
+	}
+	_, err := g.DB().Ctx(ctx).Model(auditLogTable).Data(data).Insert()
+	return err
+}
+
+func auditJSON(value interface{}) interface{} {
+	if g.IsNil(value) {
+		return nil
+	}
+	return gjson.MustEncodeString(value)
+}
@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"net/http"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +15,7 @@ import (
 	"github.com/gogf/gf/v2/errors/gerror"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/guid"
 	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -34,32 +34,6 @@ var (
 	localAuth          IAuth
 )
 
-var roleAccessCodes = map[string][]string{
-	"super": {
-		"System:Menu:List",
-		"System:Menu:Create",
-		"System:Menu:Edit",
-		"System:Menu:Delete",
-		"System:Dept:List",
-		"System:Dept:Create",
-		"System:Dept:Edit",
-		"System:Dept:Delete",
-	},
-	"admin": {
-		"System:Menu:List",
-		"System:Menu:Edit",
-		"System:Dept:List",
-		"System:Dept:Edit",
-	},
-	"user": {
-		"System:Menu:List",
-		"System:Dept:List",
-	},
-	"guest": {
-		"System:Menu:List",
-	},
-}
-
 func Auth() IAuth {
 	return localAuth
 }
@@ -144,7 +118,7 @@ func (s *sAuth) Login(ctx context.Context, in v1.LoginReq) (out *v1.LoginRes, er
 	}
 
 	roles := parseRoles(user.Roles)
-	if len(roles) == 0 {
+	if len(roles) == 0 && user.LegacySuper {
 		roles = []string{"super"}
 	}
 
@@ -196,7 +170,7 @@ func (s *sAuth) CreateUserForTest(ctx context.Context, username, password string
 	_, err = dao.SysUser.Ctx(ctx).Data(g.Map{
 		dao.SysUser.Columns().Username: username,
 		dao.SysUser.Columns().Password: string(hashedPassword),
-		dao.SysUser.Columns().TenantId: "00000000-0000-0000-0000-000000000000",
+		dao.SysUser.Columns().TenantId: consts.DefaultTenantId,
 	}).Insert()
 	return err
 }
@@ -263,36 +237,19 @@ func (s *sAuth) Logout(ctx context.Context, in v1.LogoutReq) (out *v1.LogoutRes,
 
 // GetAccessCodes implements interface IAuth.GetAccessCodes.
 func (s *sAuth) GetAccessCodes(ctx context.Context, in v1.GetAccessCodesReq) (out *v1.GetAccessCodesRes, err error) {
-	token, err := resolveAccessToken(ctx, in.Token)
-	if err != nil {
-		return nil, err
+	var p *Principal
+	if in.Token == "" {
+		p, err = ResolvePrincipal(ctx)
+	} else {
+		p, err = principalFromToken(ctx, in.Token)
 	}
-
-	claims, err := parseToken(token)
 	if err != nil {
 		return nil, err
 	}
-
-	userID, _ := claims["id"].(string)
-	if userID == "" {
-		return nil, gerror.NewCode(consts.ErrorCodeUnauthorized, "invalid access token")
-	}
-
-	var user entity.SysUser
-	err = dao.SysUser.Ctx(ctx).Where(dao.SysUser.Columns().Id, userID).Scan(&user)
+	codes, err := UserAccessCodes(ctx, p)
 	if err != nil {
 		return nil, err
 	}
-	if user.Id == "" {
-		return nil, gerror.NewCode(consts.ErrorCodeUserNotFound, "user not found")
-	}
-
-	roles := parseRoles(user.Roles)
-	codes, err := accessCodesFromCasbin(ctx, user.TenantId, roles)
-	if err != nil || len(codes) == 0 {
-		codes = buildAccessCodes(roles)
-	}
-
 	out = &v1.GetAccessCodesRes{
 		Codes: codes,
 	}
@@ -304,6 +261,7 @@ func (s *sAuth) generateAccessToken(user *entity.SysUser) (string, error) {
 		"id":       user.Id,
 		"username": user.Username,
 		"tenantId": user.TenantId,
+		"jti":      guid.S(),
 		"exp":      time.Now().Add(AccessTokenTTL).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -406,26 +364,3 @@ func writeAccessTokenResponse(ctx context.Context, token string) {
 	}
 	req.Response.Write(token)
 }
-
-func buildAccessCodes(roles []string) []string {
-	if len(roles) == 0 {
-		roles = []string{"super"}
-	}
-	set := make(map[string]struct{})
-	for _, role := range roles {
-		role = strings.TrimSpace(role)
-		codes, ok := roleAccessCodes[role]
-		if !ok {
-			codes = roleAccessCodes["super"]
-		}
-		for _, code := range codes {
-			set[code] = struct{}{}
-		}
-	}
-	result := make([]string, 0, len(set))
-	for code := range set {
-		result = append(result, code)
-	}
-	sort.Strings(result)
-	return result
-}
@@ -2,11 +2,15 @@ package service
 
 import (
 	"context"
-	"sort"
+	"slices"
 	"strings"
 	"sync"
 
+	"backend/internal/model/entity"
+
 	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
 	"github.com/gogf/gf/v2/errors/gerror"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gfile"
@@ -15,30 +19,161 @@ import (
 const (
 	casbinDefaultModelPath = "resource/casbin/model.conf"
 	casbinDefaultDomain    = "default"
+	casbinEffectIndex      = 4
+)
+
+// Policy effects stored in the eft column of "p" rules.
+const (
+	PolicyEffectAllow = "allow"
+	PolicyEffectDeny  = "deny"
 )
 
 var (
 	casbinOnce      sync.Once
-	casbinEnforcer  *casbin.Enforcer
+	casbinEnforcers *casbinEnforcerCache
+	casbinAdapter   *CasbinAdapter
+	casbinWatcher   *PgWatcher
+	casbinSync      *casbinPolicySync
 	casbinInitError error
 )
 
-// Casbin returns a singleton enforcer initialized with the local adapter.
-func Casbin(ctx context.Context) (*casbin.Enforcer, error) {
+// Casbin returns the enforcer of one domain. Enforcers load only the rules of
+// their domain and are kept in an LRU of casbin.cacheSize entries.
+// When casbin.watcher.enabled is not false, policy changes made by other
+// instances or directly in the database are picked up via LISTEN/NOTIFY.
+func Casbin(ctx context.Context, domain string) (*casbin.SyncedEnforcer, error) {
+	if err := initCasbin(ctx); err != nil {
+		return nil, err
+	}
+	return casbinEnforcers.Get(NormalizeDomain(domain))
+}
+
+func initCasbin(ctx context.Context) error {
 	casbinOnce.Do(func() {
+		// Enforcers outlive the request that first creates them.
+		ctx = context.WithoutCancel(ctx)
 		modelPath, err := resolveCasbinModelPath(ctx)
 		if err != nil {
 			casbinInitError = err
 			return
 		}
-		enforcer, err := casbin.NewEnforcer(modelPath, NewCasbinAdapter(ctx))
-		if err != nil {
+		modelText := gfile.GetContents(modelPath)
+		if _, err = model.NewModelFromString(modelText); err != nil {
 			casbinInitError = err
 			return
 		}
-		casbinEnforcer = enforcer
+		casbinAdapter = NewCasbinAdapter(ctx)
+		casbinEnforcers = newCasbinEnforcerCache(casbinCacheSize(ctx), func(domain string) (*casbin.SyncedEnforcer, error) {
+			return newDomainEnforcer(NewCasbinAdapter(ctx), modelText, domain)
+		})
+		casbinSync = newCasbinPolicySync(ctx, casbinEnforcers)
+		if casbinWatcherEnabled(ctx) {
+			if err := attachCasbinWatcher(ctx); err != nil {
+				g.Log().Warningf(ctx, "casbin watcher disabled: %v", err)
+			}
+		}
 	})
-	return casbinEnforcer, casbinInitError
+	return casbinInitError
+}
+
+// newDomainEnforcer creates an enforcer holding only the rules of domain.
+// Writers persist through casbinAdapter and apply changes with
+// applyCasbinChange, so the enforcer never writes back to storage.
+func newDomainEnforcer(adapter persist.FilteredAdapter, modelText, domain string) (*casbin.SyncedEnforcer, error) {
+	m, err := model.NewModelFromString(modelText)
+	if err != nil {
+		return nil, err
+	}
+	enforcer, err := casbin.NewSyncedEnforcer(m)
+	if err != nil {
+		return nil, err
+	}
+	enforcer.EnableAutoSave(false)
+	enforcer.AddFunction("conditionMatch", conditionMatch)
+	enforcer.SetAdapter(adapter)
+	if err = enforcer.LoadFilteredPolicy(CasbinFilter{Domains: []string{domain}}); err != nil {
+		return nil, err
+	}
+	return enforcer, nil
+}
+
+// applyCasbinChange applies a persisted change to the cached enforcers of
+// this instance and broadcasts it to the others.
+func applyCasbinChange(ctx context.Context, msg watcherMessage) error {
+	if err := initCasbin(ctx); err != nil {
+		return err
+	}
+	casbinSync.apply(msg)
+	if casbinWatcher != nil {
+		return casbinWatcher.publish(msg)
+	}
+	return nil
+}
+
+// ReloadCasbinPolicy drops the enforcers cached on this instance, so they are
+// reloaded from storage on next use, and asks all other instances to do the same.
+func ReloadCasbinPolicy(ctx context.Context) error {
+	if err := initCasbin(ctx); err != nil {
+		return err
+	}
+	casbinEnforcers.Purge()
+	if casbinWatcher != nil {
+		return casbinWatcher.Update()
+	}
+	return nil
+}
+
+func casbinCacheSize(ctx context.Context) int {
+	v, err := g.Cfg().Get(ctx, "casbin.cacheSize")
+	if err != nil || v == nil || v.IsNil() {
+		return casbinDefaultCacheSize
+	}
+	return v.Int()
+}
+
+func casbinWatcherEnabled(ctx context.Context) bool {
+	v, err := g.Cfg().Get(ctx, "casbin.watcher.enabled")
+	if err != nil || v == nil || v.IsNil() {
+		return true
+	}
+	return v.Bool()
+}
+
+func attachCasbinWatcher(ctx context.Context) error {
+	dsn, err := casbinWatcherDSN(ctx)
+	if err != nil {
+		return err
+	}
+	channel := casbinWatcherDefaultChannel
+	if v, err := g.Cfg().Get(ctx, "casbin.watcher.channel"); err == nil && v != nil && v.String() != "" {
+		channel = v.String()
+	}
+	watcher, err := NewPgWatcher(ctx, dsn, channel)
+	if err != nil {
+		return err
+	}
+	warnCasbinTriggerChannel(ctx, channel)
+	if err = watcher.SetUpdateCallback(casbinSync.Handle); err != nil {
+		watcher.Close()
+		return err
+	}
+	casbinWatcher = watcher
+	return nil
+}
+
+// warnCasbinTriggerChannel logs when the casbin_rule trigger notifies another
+// channel than the watcher listens on, as rules changed outside the
+// application would then never reach the enforcers.
+func warnCasbinTriggerChannel(ctx context.Context, channel string) {
+	v, err := g.DB().GetValue(ctx,
+		"SELECT COALESCE(NULLIF(current_setting('casbin.channel', true), ''), ?::text)", casbinWatcherDefaultChannel)
+	if err != nil {
+		g.Log().Warningf(ctx, "failed to read the casbin_rule trigger channel: %v", err)
+		return
+	}
+	if v.String() != channel {
+		g.Log().Warningf(ctx, "casbin_rule trigger notifies %q but the watcher listens on %q; set casbin.channel for the database", v.String(), channel)
+	}
 }
 
 func resolveCasbinModelPath(ctx context.Context) (string, error) {
@@ -65,40 +200,96 @@ func NormalizeDomain(domain string) string {
 	return domain
 }
 
-func accessCodesFromCasbin(ctx context.Context, domain string, roles []string) ([]string, error) {
-	enforcer, err := Casbin(ctx)
-	if err != nil || enforcer == nil {
-		return nil, err
-	}
-	if len(roles) == 0 {
-		return nil, nil
+// SubjectDecision is the outcome of one request for a single subject.
+type SubjectDecision struct {
+	Subject string
+	Allowed bool
+	// Rule is the rule that decided the outcome, empty when none matched.
+	Rule []string
+}
+
+// EnforceSubjects checks a request for a set of subjects (typically the roles of
+// one user) and resolves conflicts across them: a deny rule matched by any
+// subject wins over allow rules matched by the others.
+func EnforceSubjects(enforcer casbin.IEnforcer, subjects []string, domain, obj, act string) (bool, error) {
+	allowed, _, err := EvaluateSubjects(enforcer, subjects, domain, obj, act, nil)
+	return allowed, err
+}
+
+// EvaluateSubjects is EnforceSubjects that also reports the outcome for every
+// subject, for explaining decisions. When env is set, allow rules of subjects
+// carrying a policy condition only apply if the condition accepts env; a nil
+// env ignores conditions.
+func EvaluateSubjects(enforcer casbin.IEnforcer, subjects []string, domain, obj, act string, env *RequestEnv) (bool, []SubjectDecision, error) {
+	allowed, denied := false, false
+	decisions := make([]SubjectDecision, 0, len(subjects))
+	for _, subject := range subjects {
+		subject = strings.TrimSpace(subject)
+		if subject == "" {
+			continue
+		}
+		var (
+			ok      bool
+			explain []string
+			err     error
+		)
+		if env != nil {
+			ok, explain, err = enforcer.EnforceEx(casbinConditionContext, subject, domain, obj, act, env)
+		} else {
+			ok, explain, err = enforcer.EnforceEx(subject, domain, obj, act)
+		}
+		if err != nil {
+			return false, nil, err
+		}
+		decisions = append(decisions, SubjectDecision{Subject: subject, Allowed: ok, Rule: explain})
+		if ok {
+			allowed = true
+		} else if policyEffect(explain) == PolicyEffectDeny {
+			denied = true
+		}
 	}
-	domain = NormalizeDomain(domain)
-	set := make(map[string]struct{})
-	for _, role := range roles {
-		role = strings.TrimSpace(role)
-		if role == "" {
+	return allowed && !denied, decisions, nil
+}
+
+// DecisiveRule returns the rule that determined the combined outcome of
+// decisions: a matched deny rule when denied, the first allow rule otherwise.
+func DecisiveRule(allowed bool, decisions []SubjectDecision) []string {
+	for _, decision := range decisions {
+		if len(decision.Rule) == 0 {
 			continue
 		}
-		permissions := enforcer.GetPermissionsForUserInDomain(role, domain)
-		for _, perm := range permissions {
-			if len(perm) < 3 {
-				continue
-			}
-			code := strings.TrimSpace(perm[2])
-			if code == "" {
-				continue
-			}
-			set[code] = struct{}{}
+		if allowed == decision.Allowed && (allowed || policyEffect(decision.Rule) == PolicyEffectDeny) {
+			return decision.Rule
+		}
+	}
+	return nil
+}
+
+// AuthzSubjects returns the roles of user and the subjects CasbinAuthz checks
+// for it: the user ID, so "g" rules granting roles to it apply, then the roles.
+// granted adds the user's active time-bound grants. Users without any roles
+// are treated as super while they are still marked legacy_super, which is
+// cleared once they are first given a time-bound grant.
+func AuthzSubjects(user *entity.SysUser, granted ...string) (roles []string, subjects []string) {
+	roles = ParseRoles(user.Roles)
+	for _, role := range granted {
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
 		}
 	}
-	if len(set) == 0 {
-		return nil, nil
+	if len(roles) == 0 && user.LegacySuper {
+		roles = []string{"super"}
+	}
+	return roles, append([]string{user.Id}, roles...)
+}
+
+// policyEffect returns the effect of a "p" rule, treating rules without one as allow.
+func policyEffect(rule []string) string {
+	if len(rule) == 0 {
+		return ""
 	}
-	codes := make([]string, 0, len(set))
-	for code := range set {
-		codes = append(codes, code)
+	if len(rule) <= casbinEffectIndex || strings.TrimSpace(rule[casbinEffectIndex]) == "" {
+		return PolicyEffectAllow
 	}
-	sort.Strings(codes)
-	return codes, nil
+	return strings.TrimSpace(rule[casbinEffectIndex])
 }
@@ -3,8 +3,11 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
+	"backend/internal/consts"
+
 	"github.com/casbin/casbin/v2/model"
 	"github.com/casbin/casbin/v2/persist"
 	"github.com/gogf/gf/v2/database/gdb"
@@ -12,13 +15,65 @@ import (
 	"github.com/gogf/gf/v2/frame/g"
 )
 
-const casbinRuleTable = "casbin_rule"
+const (
+	casbinRuleTable = "casbin_rule"
+
+	// casbinOriginSetting marks writes made through the adapter so the
+	// casbin_rule trigger leaves change propagation to the watcher.
+	casbinOriginSetting = "casbin.origin"
+)
+
+// Positions of the domain within "p" and "g" rules.
+const (
+	casbinPolicyDomainIndex = 1
+	casbinGroupDomainIndex  = 2
+)
+
+// casbinBatchSize bounds the rules written by one statement.
+const casbinBatchSize = 500
+
+var (
+	_ persist.FilteredAdapter  = (*CasbinAdapter)(nil)
+	_ persist.BatchAdapter     = (*CasbinAdapter)(nil)
+	_ persist.UpdatableAdapter = (*CasbinAdapter)(nil)
+)
 
 // CasbinAdapter persists policy rules using GoFrame's database layer.
 type CasbinAdapter struct {
-	ctx   context.Context
-	db    gdb.DB
-	table string
+	ctx      context.Context
+	db       gdb.DB
+	table    string
+	filtered bool
+}
+
+// CasbinFilter selects the rules of some domains for LoadFilteredPolicy:
+// "p" rules by their domain column (v1) and "g" rules by theirs (v2).
+type CasbinFilter struct {
+	Domains []string
+}
+
+// Match reports whether a rule of the given section ("p" or "g") belongs to
+// one of the filter's domains.
+func (f CasbinFilter) Match(sec string, rule []string) bool {
+	domain := ruleDomain(sec, rule)
+	for _, value := range f.Domains {
+		if value == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleDomain returns the domain of a "p" or "g" rule.
+func ruleDomain(sec string, rule []string) string {
+	index := casbinPolicyDomainIndex
+	if strings.HasPrefix(sec, "g") {
+		index = casbinGroupDomainIndex
+	}
+	if len(rule) <= index {
+		return ""
+	}
+	return rule[index]
 }
 
 // NewCasbinAdapter creates a new adapter with the provided context.
@@ -39,52 +94,138 @@ func (a *CasbinAdapter) LoadPolicy(model model.Model) error {
 	if err != nil {
 		return err
 	}
+	a.filtered = false
+	return loadPolicyRecords(records, model)
+}
+
+// LoadFilteredPolicy loads the rules selected by a CasbinFilter.
+func (a *CasbinAdapter) LoadFilteredPolicy(model model.Model, filter interface{}) error {
+	var domains []string
+	switch f := filter.(type) {
+	case CasbinFilter:
+		domains = f.Domains
+	case *CasbinFilter:
+		if f == nil {
+			return a.LoadPolicy(model)
+		}
+		domains = f.Domains
+	default:
+		return gerror.Newf("unsupported casbin filter type %T", filter)
+	}
+	a.filtered = true
+	if len(domains) == 0 {
+		return nil
+	}
+	query := a.db.Ctx(a.ctx).Model(a.table)
+	builder := query.Builder()
+	records, err := query.
+		Where(builder.WhereLike("ptype", "p%").WhereIn(fmt.Sprintf("v%d", casbinPolicyDomainIndex), domains)).
+		WhereOr(builder.WhereLike("ptype", "g%").WhereIn(fmt.Sprintf("v%d", casbinGroupDomainIndex), domains)).
+		All()
+	if err != nil {
+		return err
+	}
+	return loadPolicyRecords(records, model)
+}
+
+// IsFiltered reports whether the last load was filtered, in which case the
+// enforcer refuses SavePolicy.
+func (a *CasbinAdapter) IsFiltered() bool {
+	return a.filtered
+}
+
+func loadPolicyRecords(records gdb.Result, model model.Model) error {
 	for _, record := range records {
-		line := recordToPolicyLine(record)
-		if line == "" {
+		rule := recordToPolicyRule(record, model)
+		if len(rule) == 0 {
 			continue
 		}
-		persist.LoadPolicyLine(line, model)
+		if err := persist.LoadPolicyArray(rule, model); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// SavePolicy saves all policy rules to storage.
+// SavePolicy makes storage match the model, deleting and inserting only the
+// rules that differ.
 func (a *CasbinAdapter) SavePolicy(model model.Model) error {
-	records := policyRecordsFromModel(model)
-	return a.db.Transaction(a.ctx, func(ctx context.Context, tx gdb.TX) error {
-		if _, err := tx.Model(a.table).Ctx(ctx).Delete(); err != nil {
+	if a.filtered {
+		return gerror.New("cannot save a filtered policy")
+	}
+	return a.transaction(func(ctx context.Context, tx gdb.TX) error {
+		records, err := tx.Model(a.table).Ctx(ctx).LockUpdate().All()
+		if err != nil {
 			return err
 		}
-		if len(records) == 0 {
+		deleteIds, inserts := diffPolicyRecords(records, model)
+		for start := 0; start < len(deleteIds); start += casbinBatchSize {
+			end := min(start+casbinBatchSize, len(deleteIds))
+			if _, err = tx.Model(a.table).Ctx(ctx).WhereIn("id", deleteIds[start:end]).Delete(); err != nil {
+				return err
+			}
+		}
+		if len(inserts) == 0 {
 			return nil
 		}
-		_, err := tx.Model(a.table).Ctx(ctx).Data(records).Insert()
+		_, err = tx.Model(a.table).Ctx(ctx).Data(inserts).Batch(casbinBatchSize).Insert()
 		return err
 	})
 }
 
 // AddPolicy adds a policy rule to storage.
 func (a *CasbinAdapter) AddPolicy(sec string, ptype string, rule []string) error {
+	return a.AddPolicies(sec, ptype, [][]string{rule})
+}
+
+// AddPolicies adds policy rules to storage in one transaction.
+func (a *CasbinAdapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+	return a.AddPoliciesCtx(a.ctx, sec, ptype, rules)
+}
+
+// AddPoliciesCtx is AddPolicies joining the transaction carried by ctx.
+func (a *CasbinAdapter) AddPoliciesCtx(ctx context.Context, sec string, ptype string, rules [][]string) error {
 	if strings.TrimSpace(ptype) == "" {
 		return gerror.New("policy type is empty")
 	}
-	record := policyToRecord(ptype, rule)
-	_, err := a.db.Ctx(a.ctx).Model(a.table).Data(record).Insert()
-	return err
+	if len(rules) == 0 {
+		return nil
+	}
+	records := make([]map[string]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		records = append(records, policyToRecord(ptype, rule))
+	}
+	return a.transactionCtx(ctx, func(ctx context.Context, tx gdb.TX) error {
+		_, err := tx.Model(a.table).Ctx(ctx).Data(records).Batch(casbinBatchSize).Insert()
+		return err
+	})
 }
 
 // RemovePolicy removes a policy rule from storage.
 func (a *CasbinAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
-	builder := a.db.Ctx(a.ctx).Model(a.table).Where("ptype", ptype)
-	for i, value := range rule {
-		if i > 5 {
-			break
-		}
-		builder = builder.Where(fmt.Sprintf("v%d", i), value)
+	return a.RemovePolicies(sec, ptype, [][]string{rule})
+}
+
+// RemovePolicies removes policy rules from storage in one transaction, with
+// one DELETE per casbinBatchSize rules.
+func (a *CasbinAdapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+	if len(rules) == 0 {
+		return nil
 	}
-	_, err := builder.Delete()
-	return err
+	return a.transaction(func(ctx context.Context, tx gdb.TX) error {
+		for start := 0; start < len(rules); start += casbinBatchSize {
+			end := min(start+casbinBatchSize, len(rules))
+			query := tx.Model(a.table).Ctx(ctx)
+			condition := query.Builder()
+			for _, rule := range rules[start:end] {
+				condition = condition.WhereOr(ruleCondition(query.Builder(), ptype, rule))
+			}
+			if _, err := query.Where(condition).Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // RemoveFilteredPolicy removes policy rules that match the filter.
@@ -92,60 +233,225 @@ func (a *CasbinAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldInde
 	if fieldIndex < 0 || fieldIndex > 5 {
 		return nil
 	}
-	builder := a.db.Ctx(a.ctx).Model(a.table).Where("ptype", ptype)
-	for i, value := range fieldValues {
-		if value == "" {
-			continue
+	return a.transaction(func(ctx context.Context, tx gdb.TX) error {
+		query := tx.Model(a.table).Ctx(ctx)
+		_, err := query.Where(fieldCondition(query.Builder(), ptype, fieldIndex, fieldValues)).Delete()
+		return err
+	})
+}
+
+// UpdatePolicy replaces a policy rule in storage.
+func (a *CasbinAdapter) UpdatePolicy(sec string, ptype string, oldRule, newRule []string) error {
+	return a.UpdatePolicies(sec, ptype, [][]string{oldRule}, [][]string{newRule})
+}
+
+// UpdatePolicies replaces policy rules pairwise in one transaction.
+func (a *CasbinAdapter) UpdatePolicies(sec string, ptype string, oldRules, newRules [][]string) error {
+	return a.UpdatePoliciesCtx(a.ctx, sec, ptype, oldRules, newRules)
+}
+
+// UpdatePoliciesCtx is UpdatePolicies joining the transaction carried by ctx.
+// It fails with ErrorCodePolicyNotFound when an old rule is not stored.
+func (a *CasbinAdapter) UpdatePoliciesCtx(ctx context.Context, sec string, ptype string, oldRules, newRules [][]string) error {
+	if len(oldRules) != len(newRules) {
+		return gerror.Newf("cannot update %d rules with %d new rules", len(oldRules), len(newRules))
+	}
+	return a.transactionCtx(ctx, func(ctx context.Context, tx gdb.TX) error {
+		for i, oldRule := range oldRules {
+			query := tx.Model(a.table).Ctx(ctx)
+			result, err := query.Data(policyToRecord(ptype, newRules[i])).
+				Where(ruleCondition(query.Builder(), ptype, oldRule)).
+				Update()
+			if err != nil {
+				return err
+			}
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				return gerror.NewCodef(consts.ErrorCodePolicyNotFound, "policy %s %v not found", ptype, oldRule)
+			}
 		}
-		index := fieldIndex + i
-		if index > 5 {
-			break
+		return nil
+	})
+}
+
+// UpdateFilteredPolicies replaces the rules matching the filter with
+// newRules and returns the rules that were replaced.
+func (a *CasbinAdapter) UpdateFilteredPolicies(sec string, ptype string, newRules [][]string, fieldIndex int, fieldValues ...string) ([][]string, error) {
+	if fieldIndex < 0 || fieldIndex > 5 {
+		return nil, gerror.Newf("invalid field index %d", fieldIndex)
+	}
+	var oldRules [][]string
+	err := a.transaction(func(ctx context.Context, tx gdb.TX) error {
+		query := tx.Model(a.table).Ctx(ctx)
+		condition := fieldCondition(query.Builder(), ptype, fieldIndex, fieldValues)
+		records, err := query.Where(condition).LockUpdate().All()
+		if err != nil {
+			return err
 		}
-		builder = builder.Where(fmt.Sprintf("v%d", index), value)
+		ids := make([]int64, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, record["id"].Int64())
+			oldRules = append(oldRules, recordToPolicyRule(record, nil)[1:])
+		}
+		if len(ids) > 0 {
+			if _, err = tx.Model(a.table).Ctx(ctx).WhereIn("id", ids).Delete(); err != nil {
+				return err
+			}
+		}
+		if len(newRules) == 0 {
+			return nil
+		}
+		newRecords := make([]map[string]interface{}, 0, len(newRules))
+		for _, rule := range newRules {
+			newRecords = append(newRecords, policyToRecord(ptype, rule))
+		}
+		_, err = tx.Model(a.table).Ctx(ctx).Data(newRecords).Batch(casbinBatchSize).Insert()
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	_, err := builder.Delete()
-	return err
+	return oldRules, nil
+}
+
+// DeleteRow removes a single casbin_rule row by primary key.
+func (a *CasbinAdapter) DeleteRow(ctx context.Context, id int64) error {
+	return a.transactionCtx(ctx, func(ctx context.Context, tx gdb.TX) error {
+		_, err := tx.Model(a.table).Ctx(ctx).Where("id", id).Delete()
+		return err
+	})
+}
+
+func (a *CasbinAdapter) transaction(f func(ctx context.Context, tx gdb.TX) error) error {
+	return a.transactionCtx(a.ctx, f)
+}
+
+// transactionCtx runs f in a transaction tagged with casbinOriginSetting.
+func (a *CasbinAdapter) transactionCtx(ctx context.Context, f func(ctx context.Context, tx gdb.TX) error) error {
+	return a.db.Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if _, err := tx.Exec("SELECT set_config(?, ?, true)", casbinOriginSetting, "adapter"); err != nil {
+			return err
+		}
+		return f(ctx, tx)
+	})
 }
 
-func recordToPolicyLine(record gdb.Record) string {
+// recordToPolicyRule converts a casbin_rule row into "ptype, v0, v1, ..." form.
+// Columns keep their position, and rows written before a column was added to
+// the policy definition are padded so they still load, e.g. legacy "p" rows
+// without an effect default to allow.
+func recordToPolicyRule(record gdb.Record, model model.Model) []string {
 	ptype := strings.TrimSpace(record["ptype"].String())
 	if ptype == "" {
-		return ""
+		return nil
 	}
-	values := []string{ptype}
+	values := make([]string, 0, 6)
 	for i := 0; i <= 5; i++ {
-		value := strings.TrimSpace(record[fmt.Sprintf("v%d", i)].String())
-		if value != "" {
+		values = append(values, strings.TrimSpace(record[fmt.Sprintf("v%d", i)].String()))
+	}
+	for len(values) > 0 && values[len(values)-1] == "" {
+		values = values[:len(values)-1]
+	}
+	if assertion, ok := model[ptype[:1]][ptype]; ok {
+		for i := len(values); i < len(assertion.Tokens); i++ {
+			value := ""
+			if strings.HasSuffix(assertion.Tokens[i], "_eft") {
+				value = PolicyEffectAllow
+			}
 			values = append(values, value)
 		}
 	}
-	return strings.Join(values, ", ")
+	return append([]string{ptype}, values...)
 }
 
-func policyRecordsFromModel(model model.Model) []map[string]interface{} {
-	records := make([]map[string]interface{}, 0)
-	for ptype, assertion := range model["p"] {
-		for _, rule := range assertion.Policy {
-			records = append(records, policyToRecord(ptype, rule))
+// diffPolicyRecords compares stored rows with the rules of model and returns
+// the ids of rows to delete and the records to insert. Duplicate rows beyond
+// those present in the model are deleted.
+func diffPolicyRecords(records gdb.Result, model model.Model) ([]int64, []map[string]interface{}) {
+	stored := make(map[string][]int64, len(records))
+	for _, record := range records {
+		rule := recordToPolicyRule(record, model)
+		if len(rule) == 0 {
+			continue
 		}
+		key := policyKey(rule[0], rule[1:])
+		stored[key] = append(stored[key], record["id"].Int64())
 	}
-	for ptype, assertion := range model["g"] {
-		for _, rule := range assertion.Policy {
-			records = append(records, policyToRecord(ptype, rule))
+	inserts := make([]map[string]interface{}, 0)
+	for _, sec := range []string{"p", "g"} {
+		ptypes := make([]string, 0, len(model[sec]))
+		for ptype := range model[sec] {
+			ptypes = append(ptypes, ptype)
 		}
+		sort.Strings(ptypes)
+		for _, ptype := range ptypes {
+			for _, rule := range model[sec][ptype].Policy {
+				key := policyKey(ptype, rule)
+				if ids := stored[key]; len(ids) > 0 {
+					stored[key] = ids[1:]
+					continue
+				}
+				inserts = append(inserts, policyToRecord(ptype, rule))
+			}
+		}
+	}
+	deleteIds := make([]int64, 0)
+	for _, ids := range stored {
+		deleteIds = append(deleteIds, ids...)
 	}
-	return records
+	sort.Slice(deleteIds, func(i, j int) bool { return deleteIds[i] < deleteIds[j] })
+	return deleteIds, inserts
 }
 
+func policyKey(ptype string, rule []string) string {
+	return ptype + "\x00" + strings.Join(rule, "\x00")
+}
+
+// policyToRecord converts a rule into a casbin_rule row. Every value column
+// is present, unused ones as NULL, so rows of different lengths can be
+// inserted in one statement and updates clear stale columns.
 func policyToRecord(ptype string, rule []string) map[string]interface{} {
 	record := map[string]interface{}{
 		"ptype": ptype,
 	}
+	for i := 0; i <= 5; i++ {
+		var value interface{}
+		if i < len(rule) {
+			value = rule[i]
+		}
+		record[fmt.Sprintf("v%d", i)] = value
+	}
+	return record
+}
+
+// ruleCondition matches the rows storing rule.
+func ruleCondition(builder *gdb.WhereBuilder, ptype string, rule []string) *gdb.WhereBuilder {
+	builder = builder.Where("ptype", ptype)
 	for i, value := range rule {
 		if i > 5 {
 			break
 		}
-		record[fmt.Sprintf("v%d", i)] = value
+		builder = builder.Where(fmt.Sprintf("v%d", i), value)
 	}
-	return record
+	return builder
+}
+
+// fieldCondition matches the rows whose columns from fieldIndex on equal
+// fieldValues; empty values match anything.
+func fieldCondition(builder *gdb.WhereBuilder, ptype string, fieldIndex int, fieldValues []string) *gdb.WhereBuilder {
+	builder = builder.Where("ptype", ptype)
+	for i, value := range fieldValues {
+		if value == "" {
+			continue
+		}
+		index := fieldIndex + i
+		if index > 5 {
+			break
+		}
+		builder = builder.Where(fmt.Sprintf("v%d", index), value)
+	}
+	return builder
 }
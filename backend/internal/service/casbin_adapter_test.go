@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+func TestRecordToPolicyRule(t *testing.T) {
+	m, err := model.NewModelFromFile(testCasbinModelPath)
+	if err != nil {
+		t.Fatalf("failed to load model: %v", err)
+	}
+	record := func(values ...string) gdb.Record {
+		r := gdb.Record{}
+		keys := []string{"ptype", "v0", "v1", "v2", "v3", "v4", "v5"}
+		for i, value := range values {
+			r[keys[i]] = gvar.New(value)
+		}
+		return r
+	}
+
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(recordToPolicyRule(record("p", "admin", "t1", "/*", ".*"), m),
+			[]string{"p", "admin", "t1", "/*", ".*", PolicyEffectAllow})
+		t.Assert(recordToPolicyRule(record("p", "admin", "t1", "/billing/*", ".*", "deny"), m),
+			[]string{"p", "admin", "t1", "/billing/*", ".*", "deny"})
+		t.Assert(recordToPolicyRule(record("p", "admin", "t1", "/x", "(get)|(post)", "allow", ""), m),
+			[]string{"p", "admin", "t1", "/x", "(get)|(post)", "allow"})
+		t.Assert(recordToPolicyRule(record("g", "alice", "admin", "t1"), m),
+			[]string{"g", "alice", "admin", "t1"})
+		t.Assert(recordToPolicyRule(record(""), m), nil)
+	})
+}
+
+func TestDiffPolicyRecords(t *testing.T) {
+	m, err := model.NewModelFromFile(testCasbinModelPath)
+	if err != nil {
+		t.Fatalf("failed to load model: %v", err)
+	}
+	for _, line := range [][]string{
+		{"p", "admin", "t1", "/*", ".*", "allow"},
+		{"p", "staff", "t1", "/system/*", "get", "allow"},
+		{"g", "alice", "admin", "t1"},
+		{"g", "bob", "staff", "t1"},
+	} {
+		if err := persist.LoadPolicyArray(line, m); err != nil {
+			t.Fatalf("failed to load rule: %v", err)
+		}
+	}
+	record := func(id int64, values ...string) gdb.Record {
+		r := gdb.Record{"id": gvar.New(id)}
+		keys := []string{"ptype", "v0", "v1", "v2", "v3", "v4", "v5"}
+		for i, value := range values {
+			r[keys[i]] = gvar.New(value)
+		}
+		return r
+	}
+
+	gtest.C(t, func(t *gtest.T) {
+		deleteIds, inserts := diffPolicyRecords(gdb.Result{
+			// Legacy row without an effect matches the allow rule.
+			record(1, "p", "admin", "t1", "/*", ".*"),
+			record(2, "p", "staff", "t1", "/system/*", "(get)|(post)", "allow"),
+			record(3, "g", "alice", "admin", "t1"),
+			record(4, "g", "alice", "admin", "t1"),
+		}, m)
+		t.Assert(deleteIds, []int64{2, 4})
+		t.Assert(len(inserts), 2)
+		t.Assert(inserts[0], policyToRecord("p", []string{"staff", "t1", "/system/*", "get", "allow"}))
+		t.Assert(inserts[1], policyToRecord("g", []string{"bob", "staff", "t1"}))
+		t.AssertNil(inserts[1]["v3"])
+
+		deleteIds, inserts = diffPolicyRecords(gdb.Result{
+			record(1, "p", "admin", "t1", "/*", ".*", "allow"),
+			record(2, "p", "staff", "t1", "/system/*", "get", "allow"),
+			record(3, "g", "alice", "admin", "t1"),
+			record(5, "g", "bob", "staff", "t1"),
+		}, m)
+		t.Assert(len(deleteIds), 0)
+		t.Assert(len(inserts), 0)
+	})
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/gogf/gf/v2/os/gfile"
+)
+
+const benchTenantCount = 10000
+
+// benchTenantRules returns a policy set shaped like production data: a few
+// role rules and role assignments for every tenant.
+func benchTenantRules(tenants int) map[string][][]string {
+	lines := make([]string, 0, tenants*7)
+	for i := 0; i < tenants; i++ {
+		domain := benchDomain(i)
+		lines = append(lines,
+			fmt.Sprintf("p, super, %s, /*, .*, allow", domain),
+			fmt.Sprintf("p, admin, %s, /system/*, .*, allow", domain),
+			fmt.Sprintf("p, admin, %s, /tenant/billing/*, .*, deny", domain),
+			fmt.Sprintf("p, staff, %s, /system/dept/:id, get, allow", domain),
+			fmt.Sprintf("g, user-%d-0, super, %s", i, domain),
+			fmt.Sprintf("g, user-%d-1, admin, %s", i, domain),
+			fmt.Sprintf("g, user-%d-2, staff, %s", i, domain),
+		)
+	}
+	return newMemoryRules(lines...)
+}
+
+func benchDomain(i int) string {
+	return fmt.Sprintf("tenant-%05d", i)
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// benchEnforce runs random requests across tenants and reports heapMB
+// alongside the latency, as ResetTimer drops metrics reported earlier.
+func benchEnforce(b *testing.B, get func(domain string) (*casbin.SyncedEnforcer, error), tenants int, heapMB float64) {
+	rng := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tenant := rng.Intn(tenants)
+		domain := benchDomain(tenant)
+		enforcer, err := get(domain)
+		if err != nil {
+			b.Fatal(err)
+		}
+		subjects := []string{fmt.Sprintf("user-%d-1", tenant), "admin"}
+		if _, err = EnforceSubjects(enforcer, subjects, domain, "/system/dept/7", "get"); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(heapMB, "heap-MB")
+}
+
+// BenchmarkCasbinEnforce_SingleEnforcer is the previous layout: one enforcer
+// holding the rules of every tenant.
+func BenchmarkCasbinEnforce_SingleEnforcer(b *testing.B) {
+	rules := benchTenantRules(benchTenantCount)
+	m, err := model.NewModelFromString(gfile.GetContents(testCasbinModelPath))
+	if err != nil {
+		b.Fatal(err)
+	}
+	before := heapInUse()
+	enforcer, err := casbin.NewSyncedEnforcer(m, &memoryFilteredAdapter{byDomain: rules})
+	if err != nil {
+		b.Fatal(err)
+	}
+	heapMB := float64(heapInUse()-before) / (1 << 20)
+	benchEnforce(b, func(string) (*casbin.SyncedEnforcer, error) { return enforcer, nil }, benchTenantCount, heapMB)
+}
+
+// BenchmarkCasbinEnforce_TenantCache measures per-tenant enforcers behind the
+// LRU. "hot" requests stay within the cached tenants, "cold" spread evenly
+// over all tenants so most requests load a tenant.
+func BenchmarkCasbinEnforce_TenantCache(b *testing.B) {
+	rules := benchTenantRules(benchTenantCount)
+	for _, bc := range []struct {
+		name    string
+		tenants int
+	}{
+		{name: "hot", tenants: casbinDefaultCacheSize},
+		{name: "cold", tenants: benchTenantCount},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			before := heapInUse()
+			cache := newTestEnforcerCache(b, casbinDefaultCacheSize, rules)
+			for i := 0; i < casbinDefaultCacheSize; i++ {
+				if _, err := cache.Get(benchDomain(i)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			heapMB := float64(heapInUse()-before) / (1 << 20)
+			benchEnforce(b, cache.Get, bc.tenants, heapMB)
+		})
+	}
+}
+
+// BenchmarkCasbinEnforce_LoadTenant measures creating one tenant's enforcer.
+func BenchmarkCasbinEnforce_LoadTenant(b *testing.B) {
+	rules := benchTenantRules(benchTenantCount)
+	modelText := gfile.GetContents(testCasbinModelPath)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := newDomainEnforcer(&memoryFilteredAdapter{byDomain: rules}, modelText, benchDomain(i%benchTenantCount)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/casbin/casbin/v2"
+)
+
+// casbinDefaultCacheSize bounds the number of per-domain enforcers kept in
+// memory when casbin.cacheSize is not configured.
+const casbinDefaultCacheSize = 1000
+
+// casbinEnforcerCache keeps up to capacity per-domain enforcers and evicts the
+// least recently used one when full. Enforcers are created on demand by load.
+type casbinEnforcerCache struct {
+	capacity int
+	load     func(domain string) (*casbin.SyncedEnforcer, error)
+
+	mu      sync.Mutex
+	items   map[string]*list.Element
+	order   *list.List
+	version uint64
+}
+
+type casbinCacheEntry struct {
+	domain   string
+	enforcer *casbin.SyncedEnforcer
+}
+
+func newCasbinEnforcerCache(capacity int, load func(domain string) (*casbin.SyncedEnforcer, error)) *casbinEnforcerCache {
+	if capacity <= 0 {
+		capacity = casbinDefaultCacheSize
+	}
+	return &casbinEnforcerCache{
+		capacity: capacity,
+		load:     load,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the enforcer of domain, loading it on a miss.
+func (c *casbinEnforcerCache) Get(domain string) (*casbin.SyncedEnforcer, error) {
+	c.mu.Lock()
+	if element, ok := c.items[domain]; ok {
+		c.order.MoveToFront(element)
+		c.mu.Unlock()
+		return element.Value.(*casbinCacheEntry).enforcer, nil
+	}
+	version := c.version
+	c.mu.Unlock()
+
+	// Load outside the lock so one slow tenant does not block the others.
+	enforcer, err := c.load(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[domain]; ok {
+		c.order.MoveToFront(element)
+		return element.Value.(*casbinCacheEntry).enforcer, nil
+	}
+	// A change applied while loading may be missing from this copy; serve it
+	// once but let the next request load again.
+	if version != c.version {
+		return enforcer, nil
+	}
+	c.items[domain] = c.order.PushFront(&casbinCacheEntry{domain: domain, enforcer: enforcer})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*casbinCacheEntry).domain)
+	}
+	return enforcer, nil
+}
+
+// Peek returns the cached enforcer of domain without loading it or changing
+// its recency.
+func (c *casbinEnforcerCache) Peek(domain string) *casbin.SyncedEnforcer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[domain]; ok {
+		return element.Value.(*casbinCacheEntry).enforcer
+	}
+	return nil
+}
+
+// Each calls f for a snapshot of the cached enforcers.
+func (c *casbinEnforcerCache) Each(f func(domain string, enforcer *casbin.SyncedEnforcer)) {
+	c.mu.Lock()
+	entries := make([]*casbinCacheEntry, 0, c.order.Len())
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		entries = append(entries, element.Value.(*casbinCacheEntry))
+	}
+	c.mu.Unlock()
+	for _, entry := range entries {
+		f(entry.domain, entry.enforcer)
+	}
+}
+
+// Touch marks the cache as changed so enforcers being loaded concurrently
+// are not cached.
+func (c *casbinEnforcerCache) Touch() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+}
+
+// Remove drops the enforcer of domain so the next request reloads it.
+func (c *casbinEnforcerCache) Remove(domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	if element, ok := c.items[domain]; ok {
+		c.order.Remove(element)
+		delete(c.items, domain)
+	}
+}
+
+// Purge drops every cached enforcer.
+func (c *casbinEnforcerCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// Len returns the number of cached enforcers.
+func (c *casbinEnforcerCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+// memoryFilteredAdapter is a read-only persist.FilteredAdapter over rules
+// indexed by domain, standing in for casbin_rule in tests and benchmarks.
+type memoryFilteredAdapter struct {
+	byDomain map[string][][]string
+	filtered bool
+}
+
+func newMemoryRules(lines ...string) map[string][][]string {
+	byDomain := make(map[string][][]string)
+	for _, line := range lines {
+		rule := strings.Split(line, ", ")
+		domain := ruleDomain(rule[0], rule[1:])
+		byDomain[domain] = append(byDomain[domain], rule)
+	}
+	return byDomain
+}
+
+func (a *memoryFilteredAdapter) LoadPolicy(model model.Model) error {
+	a.filtered = false
+	for _, rules := range a.byDomain {
+		for _, rule := range rules {
+			if err := persist.LoadPolicyArray(rule, model); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *memoryFilteredAdapter) LoadFilteredPolicy(model model.Model, filter interface{}) error {
+	a.filtered = true
+	for _, domain := range filter.(CasbinFilter).Domains {
+		for _, rule := range a.byDomain[domain] {
+			if err := persist.LoadPolicyArray(rule, model); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *memoryFilteredAdapter) IsFiltered() bool { return a.filtered }
+
+func (a *memoryFilteredAdapter) SavePolicy(model.Model) error { return errors.New("read-only") }
+
+func (a *memoryFilteredAdapter) AddPolicy(string, string, []string) error {
+	return errors.New("read-only")
+}
+
+func (a *memoryFilteredAdapter) RemovePolicy(string, string, []string) error {
+	return errors.New("read-only")
+}
+
+func (a *memoryFilteredAdapter) RemoveFilteredPolicy(string, string, int, ...string) error {
+	return errors.New("read-only")
+}
+
+// newTestEnforcerCache returns a cache whose enforcers load from lines.
+func newTestEnforcerCache(t testing.TB, capacity int, byDomain map[string][][]string) *casbinEnforcerCache {
+	t.Helper()
+	modelText := gfile.GetContents(testCasbinModelPath)
+	if modelText == "" {
+		t.Fatalf("casbin model not found at %s", testCasbinModelPath)
+	}
+	return newCasbinEnforcerCache(capacity, func(domain string) (*casbin.SyncedEnforcer, error) {
+		return newDomainEnforcer(&memoryFilteredAdapter{byDomain: byDomain}, modelText, domain)
+	})
+}
+
+func TestCasbinFilter_Match(t *testing.T) {
+	filter := CasbinFilter{Domains: []string{"t1", "t2"}}
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(filter.Match("p", []string{"admin", "t1", "/*", ".*", "allow"}), true)
+		t.Assert(filter.Match("p", []string{"admin", "t3", "/*", ".*", "allow"}), false)
+		t.Assert(filter.Match("g", []string{"alice", "admin", "t2"}), true)
+		t.Assert(filter.Match("g", []string{"alice", "t1", "t3"}), false)
+		t.Assert(filter.Match("g", []string{"alice"}), false)
+	})
+}
+
+func TestCasbinEnforcerCache_LoadsOnlyOwnDomain(t *testing.T) {
+	cache := newTestEnforcerCache(t, 10, newMemoryRules(
+		"p, admin, t1, /*, .*, allow",
+		"p, admin, t2, /*, .*, allow",
+		"g, alice, admin, t1",
+		"g, bob, admin, t2",
+	))
+	gtest.C(t, func(t *gtest.T) {
+		enforcer, err := cache.Get("t1")
+		t.AssertNil(err)
+		t.Assert(len(enforcer.GetPolicy()), 1)
+		t.Assert(len(enforcer.GetGroupingPolicy()), 1)
+		t.Assert(enforcer.IsFiltered(), true)
+
+		allowed, err := enforcer.Enforce("alice", "t1", "/system/menu", "get")
+		t.AssertNil(err)
+		t.Assert(allowed, true)
+		allowed, err = enforcer.Enforce("bob", "t2", "/system/menu", "get")
+		t.AssertNil(err)
+		t.Assert(allowed, false)
+	})
+}
+
+func TestCasbinEnforcerCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	loads := make(map[string]int)
+	cache := newCasbinEnforcerCache(2, func(domain string) (*casbin.SyncedEnforcer, error) {
+		loads[domain]++
+		return &casbin.SyncedEnforcer{}, nil
+	})
+	gtest.C(t, func(t *gtest.T) {
+		first, _ := cache.Get("t1")
+		_, _ = cache.Get("t2")
+		again, _ := cache.Get("t1")
+		t.Assert(first == again, true)
+
+		_, _ = cache.Get("t3")
+		t.Assert(cache.Len(), 2)
+		t.AssertNil(cache.Peek("t2"))
+		t.AssertNE(cache.Peek("t1"), nil)
+
+		_, _ = cache.Get("t2")
+		t.Assert(loads, map[string]int{"t1": 1, "t2": 2, "t3": 1})
+
+		cache.Remove("t2")
+		t.AssertNil(cache.Peek("t2"))
+		cache.Purge()
+		t.Assert(cache.Len(), 0)
+	})
+}
+
+func TestCasbinEnforcerCache_SkipsStaleLoad(t *testing.T) {
+	var cache *casbinEnforcerCache
+	cache = newCasbinEnforcerCache(2, func(domain string) (*casbin.SyncedEnforcer, error) {
+		// A change arrives while the domain is being loaded.
+		cache.Touch()
+		return &casbin.SyncedEnforcer{}, nil
+	})
+	gtest.C(t, func(t *gtest.T) {
+		enforcer, err := cache.Get("t1")
+		t.AssertNil(err)
+		t.AssertNE(enforcer, nil)
+		t.AssertNil(cache.Peek("t1"))
+	})
+}
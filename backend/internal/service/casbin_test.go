@@ -0,0 +1,175 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	stringadapter "github.com/casbin/casbin/v2/persist/string-adapter"
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+const testCasbinModelPath = "../../resource/casbin/model.conf"
+
+func newTestEnforcer(t *testing.T, lines ...string) *casbin.Enforcer {
+	t.Helper()
+	enforcer, err := casbin.NewEnforcer(testCasbinModelPath, stringadapter.NewAdapter(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatalf("failed to create enforcer: %v", err)
+	}
+	enforcer.EnableAutoSave(false)
+	return enforcer
+}
+
+func TestCasbinModel_PathPatterns(t *testing.T) {
+	enforcer := newTestEnforcer(t,
+		"p, admin, t1, /system/dept/:id, get, allow",
+		"p, admin, t1, /system/role/{id}, get, allow",
+		"p, staff, t1, /system/*, get, allow",
+	)
+
+	testCases := []struct {
+		name string
+		sub  string
+		dom  string
+		obj  string
+		act  string
+		want bool
+	}{
+		{name: "colon parameter", sub: "admin", dom: "t1", obj: "/system/dept/42", act: "get", want: true},
+		{name: "colon parameter single segment", sub: "admin", dom: "t1", obj: "/system/dept/42/users", act: "get", want: false},
+		{name: "colon parameter requires value", sub: "admin", dom: "t1", obj: "/system/dept/", act: "get", want: false},
+		{name: "brace parameter", sub: "admin", dom: "t1", obj: "/system/role/7", act: "get", want: true},
+		{name: "brace parameter ignores query", sub: "admin", dom: "t1", obj: "/system/role/7?page=1", act: "get", want: true},
+		{name: "prefix wildcard", sub: "staff", dom: "t1", obj: "/system/menu/list", act: "get", want: true},
+		{name: "prefix wildcard nested", sub: "staff", dom: "t1", obj: "/system/dept/1/users", act: "get", want: true},
+		{name: "prefix wildcard excludes bare prefix", sub: "staff", dom: "t1", obj: "/system", act: "get", want: false},
+		{name: "prefix wildcard other tree", sub: "staff", dom: "t1", obj: "/menu/all", act: "get", want: false},
+		{name: "wrong domain", sub: "staff", dom: "t2", obj: "/system/menu/list", act: "get", want: false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			gtest.C(t, func(t *gtest.T) {
+				allowed, err := enforcer.Enforce(tc.sub, tc.dom, tc.obj, tc.act)
+				t.AssertNil(err)
+				t.Assert(allowed, tc.want)
+			})
+		})
+	}
+}
+
+func TestCasbinModel_ActionPatterns(t *testing.T) {
+	enforcer := newTestEnforcer(t,
+		"p, editor, t1, /system/menu, (get)|(post), allow",
+		"p, viewer, t1, /system/menu, get, allow",
+		"p, super, t1, /*, .*, allow",
+		"p, legacy, t1, *, *, allow",
+	)
+
+	testCases := []struct {
+		sub  string
+		act  string
+		want bool
+	}{
+		{sub: "editor", act: "get", want: true},
+		{sub: "editor", act: "post", want: true},
+		{sub: "editor", act: "delete", want: false},
+		{sub: "viewer", act: "get", want: true},
+		{sub: "viewer", act: "forget", want: false},
+		{sub: "viewer", act: "gets", want: false},
+		{sub: "super", act: "delete", want: true},
+		{sub: "legacy", act: "patch", want: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.sub+"/"+tc.act, func(t *testing.T) {
+			gtest.C(t, func(t *gtest.T) {
+				allowed, err := enforcer.Enforce(tc.sub, "t1", "/system/menu", tc.act)
+				t.AssertNil(err)
+				t.Assert(allowed, tc.want)
+			})
+		})
+	}
+}
+
+func TestCasbinModel_DomainRoles(t *testing.T) {
+	enforcer := newTestEnforcer(t,
+		"p, admin, t1, /system/dept/:id, (get)|(put)|(delete), allow",
+		"g, alice, admin, t1",
+	)
+	gtest.C(t, func(t *gtest.T) {
+		allowed, err := enforcer.Enforce("alice", "t1", "/system/dept/9", "put")
+		t.AssertNil(err)
+		t.Assert(allowed, true)
+
+		allowed, err = enforcer.Enforce("alice", "t2", "/system/dept/9", "put")
+		t.AssertNil(err)
+		t.Assert(allowed, false)
+	})
+}
+
+func TestEnforceSubjects_DenyOverride(t *testing.T) {
+	enforcer := newTestEnforcer(t,
+		"p, admin, t1, /*, .*, allow",
+		"p, admin, t1, /tenant/billing/*, .*, deny",
+		"p, auditor, t1, /tenant/billing/*, get, allow",
+		"p, support, t1, /system/user/:id, (get)|(put), allow",
+		"p, restricted, t1, /system/user/:id, put, deny",
+	)
+
+	testCases := []struct {
+		name     string
+		subjects []string
+		obj      string
+		act      string
+		want     bool
+	}{
+		{name: "allow everything else", subjects: []string{"admin"}, obj: "/system/menu/list", act: "get", want: true},
+		{name: "deny within own role", subjects: []string{"admin"}, obj: "/tenant/billing/invoices", act: "get", want: false},
+		{name: "deny from one role beats allow from another", subjects: []string{"auditor", "admin"}, obj: "/tenant/billing/invoices", act: "get", want: false},
+		{name: "deny order independent", subjects: []string{"admin", "auditor"}, obj: "/tenant/billing/invoices", act: "get", want: false},
+		{name: "allow without deny", subjects: []string{"auditor"}, obj: "/tenant/billing/invoices", act: "get", want: true},
+		{name: "deny limited to action", subjects: []string{"support", "restricted"}, obj: "/system/user/5", act: "get", want: true},
+		{name: "deny on matching action", subjects: []string{"support", "restricted"}, obj: "/system/user/5", act: "put", want: false},
+		{name: "no matching rule", subjects: []string{"restricted"}, obj: "/system/user/5", act: "get", want: false},
+		{name: "no subjects", subjects: nil, obj: "/system/menu/list", act: "get", want: false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			gtest.C(t, func(t *gtest.T) {
+				allowed, err := EnforceSubjects(enforcer, tc.subjects, "t1", tc.obj, tc.act)
+				t.AssertNil(err)
+				t.Assert(allowed, tc.want)
+			})
+		})
+	}
+}
+
+func TestEvaluateSubjects_DecisiveRule(t *testing.T) {
+	enforcer := newTestEnforcer(t,
+		"p, admin, t1, /*, .*, allow",
+		"p, admin, t1, /tenant/billing/*, .*, deny",
+		"p, auditor, t1, /tenant/billing/*, get, allow",
+	)
+	gtest.C(t, func(t *gtest.T) {
+		allowed, decisions, err := EvaluateSubjects(enforcer, []string{"auditor", "admin"}, "t1", "/tenant/billing/invoices", "get", nil)
+		t.AssertNil(err)
+		t.Assert(allowed, false)
+		t.Assert(len(decisions), 2)
+		t.Assert(decisions[0].Allowed, true)
+		t.Assert(DecisiveRule(allowed, decisions), []string{"admin", "t1", "/tenant/billing/*", ".*", "deny"})
+
+		allowed, decisions, err = EvaluateSubjects(enforcer, []string{"nobody", "admin"}, "t1", "/system/menu", "get", nil)
+		t.AssertNil(err)
+		t.Assert(allowed, true)
+		t.Assert(decisions[0].Rule, []string{})
+		t.Assert(DecisiveRule(allowed, decisions), []string{"admin", "t1", "/*", ".*", "allow"})
+
+		allowed, decisions, err = EvaluateSubjects(enforcer, []string{"nobody"}, "t1", "/system/menu", "get", nil)
+		t.AssertNil(err)
+		t.Assert(allowed, false)
+		t.AssertNil(DecisiveRule(allowed, decisions))
+	})
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/guid"
+	"github.com/lib/pq"
+)
+
+const (
+	casbinWatcherDefaultChannel = "casbin_policy"
+	// Postgres rejects NOTIFY payloads of 8000 bytes or more; larger
+	// incremental updates are sent as a full reload instead.
+	casbinWatcherMaxPayload = 7900
+)
+
+// Operations carried by watcher messages.
+const (
+	watcherOpReload         = "reload"
+	watcherOpAdd            = "add"
+	watcherOpRemove         = "remove"
+	watcherOpRemoveFiltered = "remove_filtered"
+	watcherOpUpdate         = "update"
+)
+
+var (
+	_ persist.Watcher          = (*PgWatcher)(nil)
+	_ persist.WatcherEx        = (*PgWatcher)(nil)
+	_ persist.UpdatableWatcher = (*PgWatcher)(nil)
+)
+
+// watcherMessage is the NOTIFY payload exchanged between instances. Messages
+// without an instance come from the casbin_rule trigger, i.e. direct DB edits.
+type watcherMessage struct {
+	Instance    string     `json:"instance,omitempty"`
+	Op          string     `json:"op"`
+	Sec         string     `json:"sec,omitempty"`
+	Ptype       string     `json:"ptype,omitempty"`
+	Rules       [][]string `json:"rules,omitempty"`
+	NewRules    [][]string `json:"newRules,omitempty"`
+	FieldIndex  int        `json:"fieldIndex,omitempty"`
+	FieldValues []string   `json:"fieldValues,omitempty"`
+}
+
+// PgWatcher propagates policy changes between instances with Postgres
+// LISTEN/NOTIFY. It implements persist.Watcher, persist.WatcherEx and
+// persist.UpdatableWatcher.
+type PgWatcher struct {
+	ctx      context.Context
+	channel  string
+	instance string
+	listener *pq.Listener
+
+	mu       sync.RWMutex
+	callback func(string)
+	closed   chan struct{}
+	once     sync.Once
+}
+
+// NewPgWatcher listens on channel using a dedicated connection to dsn.
+func NewPgWatcher(ctx context.Context, dsn, channel string) (*PgWatcher, error) {
+	if strings.TrimSpace(channel) == "" {
+		channel = casbinWatcherDefaultChannel
+	}
+	w := &PgWatcher{
+		ctx:      ctx,
+		channel:  channel,
+		instance: guid.S(),
+		closed:   make(chan struct{}),
+	}
+	w.listener = pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			g.Log().Warningf(ctx, "casbin watcher connection event %d: %v", event, err)
+		}
+	})
+	if err := w.listener.Listen(channel); err != nil {
+		w.listener.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// SetUpdateCallback implements persist.Watcher.
+func (w *PgWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+// Update implements persist.Watcher and asks every other instance to reload.
+func (w *PgWatcher) Update() error {
+	return w.publish(watcherMessage{Op: watcherOpReload})
+}
+
+// Close implements persist.Watcher.
+func (w *PgWatcher) Close() {
+	w.once.Do(func() {
+		close(w.closed)
+		_ = w.listener.Close()
+	})
+}
+
+// UpdateForAddPolicy implements persist.WatcherEx.
+func (w *PgWatcher) UpdateForAddPolicy(sec, ptype string, params ...string) error {
+	return w.publish(watcherMessage{Op: watcherOpAdd, Sec: sec, Ptype: ptype, Rules: [][]string{params}})
+}
+
+// UpdateForRemovePolicy implements persist.WatcherEx.
+func (w *PgWatcher) UpdateForRemovePolicy(sec, ptype string, params ...string) error {
+	return w.publish(watcherMessage{Op: watcherOpRemove, Sec: sec, Ptype: ptype, Rules: [][]string{params}})
+}
+
+// UpdateForRemoveFilteredPolicy implements persist.WatcherEx.
+func (w *PgWatcher) UpdateForRemoveFilteredPolicy(sec, ptype string, fieldIndex int, fieldValues ...string) error {
+	return w.publish(watcherMessage{
+		Op: watcherOpRemoveFiltered, Sec: sec, Ptype: ptype, FieldIndex: fieldIndex, FieldValues: fieldValues,
+	})
+}
+
+// UpdateForSavePolicy implements persist.WatcherEx.
+func (w *PgWatcher) UpdateForSavePolicy(model model.Model) error {
+	return w.Update()
+}
+
+// UpdateForAddPolicies implements persist.WatcherEx.
+func (w *PgWatcher) UpdateForAddPolicies(sec string, ptype string, rules ...[]string) error {
+	return w.publish(watcherMessage{Op: watcherOpAdd, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+// UpdateForRemovePolicies implements persist.WatcherEx.
+func (w *PgWatcher) UpdateForRemovePolicies(sec string, ptype string, rules ...[]string) error {
+	return w.publish(watcherMessage{Op: watcherOpRemove, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+// UpdateForUpdatePolicy implements persist.UpdatableWatcher.
+func (w *PgWatcher) UpdateForUpdatePolicy(sec string, ptype string, oldRule, newRule []string) error {
+	return w.publish(watcherMessage{
+		Op: watcherOpUpdate, Sec: sec, Ptype: ptype, Rules: [][]string{oldRule}, NewRules: [][]string{newRule},
+	})
+}
+
+// UpdateForUpdatePolicies implements persist.UpdatableWatcher.
+func (w *PgWatcher) UpdateForUpdatePolicies(sec string, ptype string, oldRules, newRules [][]string) error {
+	return w.publish(watcherMessage{Op: watcherOpUpdate, Sec: sec, Ptype: ptype, Rules: oldRules, NewRules: newRules})
+}
+
+func (w *PgWatcher) publish(msg watcherMessage) error {
+	msg.Instance = w.instance
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if len(payload) > casbinWatcherMaxPayload {
+		payload, _ = json.Marshal(watcherMessage{Instance: w.instance, Op: watcherOpReload})
+	}
+	_, err = g.DB().Exec(w.ctx, "SELECT pg_notify(?, ?)", w.channel, string(payload))
+	return err
+}
+
+func (w *PgWatcher) run() {
+	for {
+		select {
+		case <-w.closed:
+			return
+		case notification, ok := <-w.listener.Notify:
+			if !ok {
+				return
+			}
+			// A nil notification means the connection was re-established and
+			// messages may have been missed.
+			payload := `{"op":"reload"}`
+			if notification != nil {
+				payload = notification.Extra
+			}
+			var msg watcherMessage
+			if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+				g.Log().Warningf(w.ctx, "casbin watcher ignored malformed payload %q: %v", payload, err)
+				continue
+			}
+			if msg.Instance == w.instance {
+				continue
+			}
+			w.mu.RLock()
+			callback := w.callback
+			w.mu.RUnlock()
+			if callback != nil {
+				callback(payload)
+			}
+		}
+	}
+}
+
+// casbinWatcherDSN returns the connection string used for LISTEN, either
+// casbin.watcher.dsn or one derived from the default database group.
+func casbinWatcherDSN(ctx context.Context) (string, error) {
+	if v, err := g.Cfg().Get(ctx, "casbin.watcher.dsn"); err == nil && v != nil {
+		if dsn := strings.TrimSpace(v.String()); dsn != "" {
+			return dsn, nil
+		}
+	}
+	node := g.DB().GetConfig()
+	if node == nil || node.Host == "" {
+		return "", gerror.New("casbin watcher: database configuration not found")
+	}
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s", node.Host, node.User, node.Pass, node.Name)
+	if node.Port != "" {
+		dsn += " port=" + node.Port
+	}
+	if node.Extra != "" {
+		dsn += " " + strings.ReplaceAll(node.Extra, "&", " ")
+	}
+	return dsn, nil
+}
+
+// casbinPolicySync applies policy changes to the cached per-domain
+// enforcers. Rules only reach the enforcer of their own domain; enforcers
+// that are not cached load the change from storage when first used.
+type casbinPolicySync struct {
+	ctx       context.Context
+	enforcers *casbinEnforcerCache
+}
+
+func newCasbinPolicySync(ctx context.Context, enforcers *casbinEnforcerCache) *casbinPolicySync {
+	return &casbinPolicySync{ctx: ctx, enforcers: enforcers}
+}
+
+// Handle is the watcher update callback.
+func (s *casbinPolicySync) Handle(payload string) {
+	var msg watcherMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		msg = watcherMessage{Op: watcherOpReload}
+	}
+	s.apply(msg)
+}
+
+// apply updates the enforcers affected by msg. An enforcer that fails to
+// apply a change is dropped so it is reloaded on next use.
+func (s *casbinPolicySync) apply(msg watcherMessage) {
+	s.enforcers.Touch()
+	switch msg.Op {
+	case watcherOpAdd, watcherOpRemove:
+		for domain, rules := range groupRulesByDomain(msg.Sec, msg.Rules) {
+			enforcer := s.enforcers.Peek(domain)
+			if enforcer == nil {
+				continue
+			}
+			var err error
+			if msg.Op == watcherOpAdd {
+				_, err = enforcer.SelfAddPolicies(msg.Sec, msg.Ptype, rules)
+			} else {
+				_, err = enforcer.SelfRemovePolicies(msg.Sec, msg.Ptype, rules)
+			}
+			s.check(domain, msg.Op, err)
+		}
+	case watcherOpUpdate:
+		if len(msg.Rules) != len(msg.NewRules) {
+			s.enforcers.Purge()
+			return
+		}
+		for i, oldRule := range msg.Rules {
+			newRule := msg.NewRules[i]
+			oldDomain, newDomain := ruleDomain(msg.Sec, oldRule), ruleDomain(msg.Sec, newRule)
+			if oldDomain == newDomain {
+				if enforcer := s.enforcers.Peek(oldDomain); enforcer != nil {
+					_, err := enforcer.SelfUpdatePolicy(msg.Sec, msg.Ptype, oldRule, newRule)
+					s.check(oldDomain, msg.Op, err)
+				}
+				continue
+			}
+			// The rule moved to another domain.
+			if enforcer := s.enforcers.Peek(oldDomain); enforcer != nil {
+				_, err := enforcer.SelfRemovePolicies(msg.Sec, msg.Ptype, [][]string{oldRule})
+				s.check(oldDomain, msg.Op, err)
+			}
+			if enforcer := s.enforcers.Peek(newDomain); enforcer != nil {
+				_, err := enforcer.SelfAddPolicies(msg.Sec, msg.Ptype, [][]string{newRule})
+				s.check(newDomain, msg.Op, err)
+			}
+		}
+	case watcherOpRemoveFiltered:
+		s.enforcers.Each(func(domain string, enforcer *casbin.SyncedEnforcer) {
+			_, err := enforcer.SelfRemoveFilteredPolicy(msg.Sec, msg.Ptype, msg.FieldIndex, msg.FieldValues...)
+			s.check(domain, msg.Op, err)
+		})
+	default:
+		s.enforcers.Purge()
+	}
+}
+
+func (s *casbinPolicySync) check(domain, op string, err error) {
+	if err == nil {
+		return
+	}
+	g.Log().Warningf(s.ctx, "casbin could not apply %s to domain %s, reloading: %v", op, domain, err)
+	s.enforcers.Remove(domain)
+}
+
+func groupRulesByDomain(sec string, rules [][]string) map[string][][]string {
+	groups := make(map[string][][]string)
+	for _, rule := range rules {
+		domain := ruleDomain(sec, rule)
+		groups[domain] = append(groups[domain], rule)
+	}
+	return groups
+}
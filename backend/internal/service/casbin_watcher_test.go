@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+func TestCasbinPolicySync_Incremental(t *testing.T) {
+	enforcers := newTestEnforcerCache(t, 10, newMemoryRules(
+		"p, admin, t1, /system/*, get, allow",
+	))
+	if _, err := enforcers.Get("t1"); err != nil {
+		t.Fatalf("failed to load enforcer: %v", err)
+	}
+	sync := newCasbinPolicySync(context.Background(), enforcers)
+	send := func(msg watcherMessage) {
+		payload, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatalf("failed to encode message: %v", err)
+		}
+		sync.Handle(string(payload))
+	}
+	allowed := func(sub, dom, obj, act string) bool {
+		enforcer := enforcers.Peek(dom)
+		if enforcer == nil {
+			return false
+		}
+		ok, err := enforcer.Enforce(sub, dom, obj, act)
+		if err != nil {
+			t.Fatalf("enforce failed: %v", err)
+		}
+		return ok
+	}
+
+	gtest.C(t, func(t *gtest.T) {
+		send(watcherMessage{Op: watcherOpAdd, Sec: "p", Ptype: "p", Rules: [][]string{
+			{"staff", "t1", "/menu/all", "get", "allow"},
+			{"staff", "t2", "/menu/all", "get", "allow"},
+		}})
+		t.Assert(allowed("staff", "t1", "/menu/all", "get"), true)
+		// Uncached domains are not loaded by watcher messages.
+		t.AssertNil(enforcers.Peek("t2"))
+
+		send(watcherMessage{Op: watcherOpAdd, Sec: "g", Ptype: "g", Rules: [][]string{{"alice", "admin", "t1"}}})
+		t.Assert(allowed("alice", "t1", "/system/menu", "get"), true)
+
+		send(watcherMessage{Op: watcherOpUpdate, Sec: "p", Ptype: "p",
+			Rules:    [][]string{{"admin", "t1", "/system/*", "get", "allow"}},
+			NewRules: [][]string{{"admin", "t1", "/system/*", "(get)|(post)", "allow"}},
+		})
+		t.Assert(allowed("alice", "t1", "/system/menu", "post"), true)
+
+		send(watcherMessage{Op: watcherOpRemove, Sec: "g", Ptype: "g", Rules: [][]string{{"alice", "admin", "t1"}}})
+		t.Assert(allowed("alice", "t1", "/system/menu", "get"), false)
+
+		send(watcherMessage{Op: watcherOpRemoveFiltered, Sec: "p", Ptype: "p", FieldIndex: 0, FieldValues: []string{"staff"}})
+		t.Assert(allowed("staff", "t1", "/menu/all", "get"), false)
+
+		send(watcherMessage{Op: watcherOpUpdate, Sec: "p", Ptype: "p",
+			Rules:    [][]string{{"admin", "t1", "/system/*", "(get)|(post)", "allow"}},
+			NewRules: [][]string{{"admin", "t2", "/system/*", "(get)|(post)", "allow"}},
+		})
+		t.Assert(len(enforcers.Peek("t1").GetPolicy()), 0)
+
+		send(watcherMessage{Op: watcherOpReload})
+		t.Assert(enforcers.Len(), 0)
+	})
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// Data scopes of a role, from widest to narrowest.
+const (
+	DataScopeAll          = "all"
+	DataScopeDeptAndChild = "dept_and_child"
+	DataScopeDept         = "dept"
+	DataScopeSelf         = "self"
+)
+
+const (
+	deptTable = "sys_dept"
+
+	dataScopeDeptColumn    = "dept_id"
+	dataScopeCreatorColumn = "created_by"
+)
+
+var (
+	// loadRoleDataScopes returns the data scopes of the enabled roles with
+	// the given codes in domain.
+	loadRoleDataScopes = func(ctx context.Context, domain string, roles []string) ([]string, error) {
+		if !isUUID(domain) || len(roles) == 0 {
+			return nil, nil
+		}
+		values, err := g.DB().Ctx(ctx).Model(roleTable).
+			Fields("data_scope").
+			Where("tenant_id", domain).
+			WhereIn("code", roles).
+			Where("status", 1).
+			Where("deleted_at is null").
+			Array()
+		if err != nil {
+			return nil, err
+		}
+		scopes := make([]string, 0, len(values))
+		for _, v := range values {
+			scopes = append(scopes, v.String())
+		}
+		return scopes, nil
+	}
+
+	// loadDeptDescendants returns dept and every department below it.
+	loadDeptDescendants = func(ctx context.Context, dept string) ([]string, error) {
+		values, err := g.DB().Ctx(ctx).GetArray(ctx, `
+WITH RECURSIVE tree AS (
+    SELECT id FROM `+deptTable+` WHERE id = ? AND deleted_at IS NULL
+    UNION ALL
+    SELECT d.id FROM `+deptTable+` d JOIN tree t ON d.parent_id = t.id WHERE d.deleted_at IS NULL
+)
+SELECT id FROM tree`, dept)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(values))
+		for _, v := range values {
+			ids = append(ids, v.String())
+		}
+		return ids, nil
+	}
+
+	// dataScopeColumns reports which scope columns the table of m has.
+	dataScopeColumns = func(m *gdb.Model) (hasDept, hasCreator bool, err error) {
+		if hasDept, err = m.HasField(dataScopeDeptColumn); err != nil {
+			return false, false, err
+		}
+		hasCreator, err = m.HasField(dataScopeCreatorColumn)
+		return hasDept, hasCreator, err
+	}
+)
+
+// DataScope is the set of rows a principal may see on tables carrying
+// dept_id or created_by columns.
+type DataScope struct {
+	// All disables row filtering.
+	All bool
+	// DeptIds are the departments whose rows are visible.
+	DeptIds []string
+	// UserId, when set, makes rows created by that user visible.
+	UserId string
+}
+
+// ResolveDataScope returns the data scope of the current request's
+// principal. It is resolved once per principal.
+func ResolveDataScope(ctx context.Context) (*DataScope, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.dataScopeOnce.Do(func() {
+		p.dataScope, p.dataScopeErr = resolveDataScope(ctx, p)
+	})
+	return p.dataScope, p.dataScopeErr
+}
+
+func resolveDataScope(ctx context.Context, p *Principal) (*DataScope, error) {
+	scopes, err := loadRoleDataScopes(ctx, p.TenantId, p.Roles)
+	if err != nil {
+		return nil, err
+	}
+	dept := ""
+	if p.User != nil {
+		dept = p.User.DeptId
+	}
+	return mergeDataScopes(ctx, p.Roles, scopes, p.UserId, dept, loadDeptDescendants)
+}
+
+// mergeDataScopes combines the scopes of a user's roles: the user sees the
+// union of what each role allows. Only the super role and roles scoped to
+// all see every row; users without role scopes and unknown scope values fall
+// back to the rows the user created.
+func mergeDataScopes(ctx context.Context, roles, scopes []string, userID, dept string,
+	descendants func(ctx context.Context, dept string) ([]string, error)) (*DataScope, error) {
+	if slices.Contains(roles, "super") {
+		return &DataScope{All: true}, nil
+	}
+	if len(scopes) == 0 {
+		return &DataScope{UserId: userID}, nil
+	}
+	var (
+		scope = &DataScope{}
+		depts = make(map[string]struct{})
+	)
+	for _, s := range scopes {
+		switch strings.TrimSpace(s) {
+		case DataScopeAll:
+			return &DataScope{All: true}, nil
+		case DataScopeDeptAndChild:
+			if dept == "" {
+				continue
+			}
+			ids, err := descendants(ctx, dept)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				depts[id] = struct{}{}
+			}
+		case DataScopeDept:
+			if dept != "" {
+				depts[dept] = struct{}{}
+			}
+		default:
+			// Unknown values narrow to self rather than widen to all.
+			scope.UserId = userID
+		}
+	}
+	for id := range depts {
+		scope.DeptIds = append(scope.DeptIds, id)
+	}
+	sort.Strings(scope.DeptIds)
+	return scope, nil
+}
+
+// Condition returns the WHERE clause restricting a table to the scope, given
+// which scope columns the table has. An empty clause means no restriction.
+func (s *DataScope) Condition(hasDept, hasCreator bool) (string, []interface{}) {
+	if s == nil || s.All || (!hasDept && !hasCreator) {
+		return "", nil
+	}
+	var (
+		parts []string
+		args  []interface{}
+	)
+	if hasDept && len(s.DeptIds) > 0 {
+		parts = append(parts, dataScopeDeptColumn+" IN(?)")
+		args = append(args, s.DeptIds)
+	}
+	if hasCreator && s.UserId != "" {
+		parts = append(parts, dataScopeCreatorColumn+" = ?")
+		args = append(args, s.UserId)
+	}
+	if len(parts) == 0 {
+		return "1 = 0", nil
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+// DataScopeHandler is a gdb.ModelHandler restricting queries to the rows the
+// current principal may see. Apply it after Ctx so it sees the request:
+//
+//	dao.SysUser.Ctx(ctx).Handler(service.DataScopeHandler)
+//
+// Queries without a principal and tables without dept_id and created_by are
+// left unchanged. When the scope or the table columns cannot be resolved no
+// rows are returned.
+func DataScopeHandler(m *gdb.Model) *gdb.Model {
+	ctx := m.GetCtx()
+	if PrincipalFromCtx(ctx) == nil {
+		return m
+	}
+	scope, err := ResolveDataScope(ctx)
+	if err != nil {
+		g.Log().Warningf(ctx, "failed to resolve data scope: %v", err)
+		return m.Where("1 = 0")
+	}
+	if scope.All {
+		return m
+	}
+	hasDept, hasCreator, err := dataScopeColumns(m)
+	if err != nil {
+		g.Log().Warningf(ctx, "failed to read data scope columns: %v", err)
+		return m.Where("1 = 0")
+	}
+	where, args := scope.Condition(hasDept, hasCreator)
+	if where == "" {
+		return m
+	}
+	return m.Where(where, args...)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"backend/internal/model/entity"
+
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+// testDeptDescendants serves a tree of d1 with children d2 and d3, and d4 below d2.
+func testDeptDescendants(ctx context.Context, dept string) ([]string, error) {
+	switch dept {
+	case "d1":
+		return []string{"d1", "d2", "d3", "d4"}, nil
+	case "d2":
+		return []string{"d2", "d4"}, nil
+	default:
+		return []string{dept}, nil
+	}
+}
+
+func TestMergeDataScopes(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name   string
+		roles  []string
+		scopes []string
+		dept   string
+		want   DataScope
+	}{
+		{name: "no role scopes", scopes: nil, dept: "d2", want: DataScope{UserId: "u1"}},
+		{name: "super", roles: []string{"super"}, scopes: nil, dept: "d2", want: DataScope{All: true}},
+		{name: "all", scopes: []string{DataScopeAll}, dept: "d2", want: DataScope{All: true}},
+		{name: "dept and child", scopes: []string{DataScopeDeptAndChild}, dept: "d2", want: DataScope{DeptIds: []string{"d2", "d4"}}},
+		{name: "dept", scopes: []string{DataScopeDept}, dept: "d2", want: DataScope{DeptIds: []string{"d2"}}},
+		{name: "self", scopes: []string{DataScopeSelf}, dept: "d2", want: DataScope{UserId: "u1"}},
+		{name: "dept and self", scopes: []string{DataScopeSelf, DataScopeDept}, dept: "d2", want: DataScope{DeptIds: []string{"d2"}, UserId: "u1"}},
+		{name: "widest role wins", scopes: []string{DataScopeSelf, DataScopeAll}, dept: "d2", want: DataScope{All: true}},
+		{name: "overlapping depts", scopes: []string{DataScopeDept, DataScopeDeptAndChild}, dept: "d1", want: DataScope{DeptIds: []string{"d1", "d2", "d3", "d4"}}},
+		{name: "no department", scopes: []string{DataScopeDeptAndChild}, dept: "", want: DataScope{}},
+		{name: "unknown scope", scopes: []string{"everything"}, dept: "d2", want: DataScope{UserId: "u1"}},
+		{name: "unknown scope and dept", scopes: []string{DataScopeDept, "everything"}, dept: "d2", want: DataScope{DeptIds: []string{"d2"}, UserId: "u1"}},
+	}
+	gtest.C(t, func(t *gtest.T) {
+		for _, c := range cases {
+			scope, err := mergeDataScopes(ctx, c.roles, c.scopes, "u1", c.dept, testDeptDescendants)
+			t.AssertNil(err)
+			t.Assert(scope.All, c.want.All)
+			t.Assert(scope.DeptIds, c.want.DeptIds)
+			t.Assert(scope.UserId, c.want.UserId)
+		}
+	})
+}
+
+func TestDataScope_Condition(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		where, args := (&DataScope{All: true}).Condition(true, true)
+		t.Assert(where, "")
+		t.Assert(len(args), 0)
+
+		scope := &DataScope{DeptIds: []string{"d2", "d4"}, UserId: "u1"}
+		where, args = scope.Condition(true, true)
+		t.Assert(where, "(dept_id IN(?) OR created_by = ?)")
+		t.Assert(args, []interface{}{[]string{"d2", "d4"}, "u1"})
+
+		where, args = scope.Condition(true, false)
+		t.Assert(where, "(dept_id IN(?))")
+		t.Assert(args, []interface{}{[]string{"d2", "d4"}})
+
+		where, args = scope.Condition(false, true)
+		t.Assert(where, "(created_by = ?)")
+		t.Assert(args, []interface{}{"u1"})
+
+		// Tables without scope columns are not restricted.
+		where, _ = scope.Condition(false, false)
+		t.Assert(where, "")
+
+		// A self scope sees nothing on a table without created_by.
+		where, args = (&DataScope{UserId: "u1"}).Condition(true, false)
+		t.Assert(where, "1 = 0")
+		t.Assert(len(args), 0)
+
+		where, _ = (&DataScope{}).Condition(true, true)
+		t.Assert(where, "1 = 0")
+	})
+}
+
+func TestResolveDataScope_Memoized(t *testing.T) {
+	var loads atomic.Int64
+	previous := loadRoleDataScopes
+	loadRoleDataScopes = func(ctx context.Context, domain string, roles []string) ([]string, error) {
+		loads.Add(1)
+		return []string{DataScopeDept}, nil
+	}
+	t.Cleanup(func() { loadRoleDataScopes = previous })
+
+	p := &Principal{UserId: "u1", TenantId: "t1", Roles: []string{"staff"}, User: &entity.SysUser{Id: "u1", DeptId: "d2"}}
+	ctx := WithPrincipal(context.Background(), p)
+
+	gtest.C(t, func(t *gtest.T) {
+		for i := 0; i < 3; i++ {
+			scope, err := ResolveDataScope(ctx)
+			t.AssertNil(err)
+			t.Assert(scope.DeptIds, []string{"d2"})
+		}
+		t.Assert(loads.Load(), 1)
+	})
+}
@@ -36,23 +36,45 @@ func NewMenu() *sMenu {
 // IMenu defines the menu service interface.
 type IMenu interface {
 	All(ctx context.Context) (v1.MenuAllRes, error)
+	List(ctx context.Context) (v1.MenuListRes, error)
+	NameExists(ctx context.Context, name, excludeID string) (bool, error)
+	PathExists(ctx context.Context, path, excludeID string) (bool, error)
+	Create(ctx context.Context, in v1.MenuInput) (*v1.SystemMenuItem, error)
+	Update(ctx context.Context, id string, in v1.MenuInput) (*v1.SystemMenuItem, error)
+	Delete(ctx context.Context, id string) error
+	Move(ctx context.Context, moves []v1.MenuMoveItem) (v1.MenuListRes, error)
+	Export(ctx context.Context, format string) ([]byte, error)
+	Import(ctx context.Context, format string, content []byte, dryRun bool) (*v1.MenuImportRes, error)
+	Revisions(ctx context.Context, menuID string, page, pageSize int) (*v1.MenuRevisionListRes, error)
+	Restore(ctx context.Context, menuID string, point v1.MenuRestorePoint) (v1.MenuListRes, error)
 }
 
 type sMenu struct{}
 
-// All returns the menu tree for the current tenant.
+// All returns the menu tree of the current tenant that the caller may see:
+// menus whose permission code the caller lacks are removed, unless they set
+// meta.menuVisibleWithForbidden, and catalogs left empty are pruned. The
+// tenant's tree is built once per cache TTL and menu change.
 func (s *sMenu) All(ctx context.Context) (v1.MenuAllRes, error) {
-	menus, err := fetchMenuFromDB(ctx)
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := UserAccessCodes(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	menus, err := tenantMenuTree(ctx, NormalizeDomain(p.TenantId))
 	if err != nil || len(menus) == 0 {
-		return filterMenuRoutes(defaultMenuList()), nil
+		menus = filterMenuRoutes(defaultMenuList())
 	}
-	return filterMenuRoutes(menus), nil
+	return filterMenusByCodes(menus, codes), nil
 }
 
 func defaultMenuList() v1.MenuAllRes {
 	return v1.MenuAllRes{
 		{
-			Id:        1,
+			Id:        "1",
 			Name:      "Workspace",
 			Status:    1,
 			Type:      "menu",
@@ -67,7 +89,7 @@ func defaultMenuList() v1.MenuAllRes {
 			},
 		},
 		{
-			Id:     2,
+			Id:     "2",
 			Name:   "System",
 			Status: 1,
 			Type:   "catalog",
@@ -82,8 +104,8 @@ func defaultMenuList() v1.MenuAllRes {
 			},
 			Children: []*v1.MenuItem{
 				{
-					Id:       201,
-					Pid:      2,
+					Id:       "201",
+					Pid:      "2",
 					Path:     "/system/menu",
 					Name:     "SystemMenu",
 					AuthCode: "System:Menu:List",
@@ -96,8 +118,8 @@ func defaultMenuList() v1.MenuAllRes {
 					Component: "/system/menu/list",
 					Children: []*v1.MenuItem{
 						{
-							Id:       20101,
-							Pid:      201,
+							Id:       "20101",
+							Pid:      "201",
 							Name:     "SystemMenuCreate",
 							Status:   1,
 							Type:     "button",
@@ -107,8 +129,8 @@ func defaultMenuList() v1.MenuAllRes {
 							},
 						},
 						{
-							Id:       20102,
-							Pid:      201,
+							Id:       "20102",
+							Pid:      "201",
 							Name:     "SystemMenuEdit",
 							Status:   1,
 							Type:     "button",
@@ -118,8 +140,8 @@ func defaultMenuList() v1.MenuAllRes {
 							},
 						},
 						{
-							Id:       20103,
-							Pid:      201,
+							Id:       "20103",
+							Pid:      "201",
 							Name:     "SystemMenuDelete",
 							Status:   1,
 							Type:     "button",
@@ -131,8 +153,8 @@ func defaultMenuList() v1.MenuAllRes {
 					},
 				},
 				{
-					Id:       202,
-					Pid:      2,
+					Id:       "202",
+					Pid:      "2",
 					Path:     "/system/dept",
 					Name:     "SystemDept",
 					Status:   1,
@@ -145,8 +167,8 @@ func defaultMenuList() v1.MenuAllRes {
 					Component: "/system/dept/list",
 					Children: []*v1.MenuItem{
 						{
-							Id:       20401,
-							Pid:      202,
+							Id:       "20401",
+							Pid:      "202",
 							Name:     "SystemDeptCreate",
 							Status:   1,
 							Type:     "button",
@@ -156,8 +178,8 @@ func defaultMenuList() v1.MenuAllRes {
 							},
 						},
 						{
-							Id:       20402,
-							Pid:      202,
+							Id:       "20402",
+							Pid:      "202",
 							Name:     "SystemDeptEdit",
 							Status:   1,
 							Type:     "button",
@@ -167,8 +189,8 @@ func defaultMenuList() v1.MenuAllRes {
 							},
 						},
 						{
-							Id:       20403,
-							Pid:      202,
+							Id:       "20403",
+							Pid:      "202",
 							Name:     "SystemDeptDelete",
 							Status:   1,
 							Type:     "button",
@@ -182,7 +204,7 @@ func defaultMenuList() v1.MenuAllRes {
 			},
 		},
 		{
-			Id:     9,
+			Id:     "9",
 			Name:   "Project",
 			Path:   "/vben-admin",
 			Type:   "catalog",
@@ -195,8 +217,8 @@ func defaultMenuList() v1.MenuAllRes {
 			},
 			Children: []*v1.MenuItem{
 				{
-					Id:        901,
-					Pid:       9,
+					Id:        "901",
+					Pid:       "9",
 					Name:      "VbenDocument",
 					Path:      "/vben-admin/document",
 					Component: "IFrameView",
@@ -209,8 +231,8 @@ func defaultMenuList() v1.MenuAllRes {
 					},
 				},
 				{
-					Id:        902,
-					Pid:       9,
+					Id:        "902",
+					Pid:       "9",
 					Name:      "VbenGithub",
 					Path:      "/vben-admin/github",
 					Component: "IFrameView",
@@ -223,8 +245,8 @@ func defaultMenuList() v1.MenuAllRes {
 					},
 				},
 				{
-					Id:        903,
-					Pid:       9,
+					Id:        "903",
+					Pid:       "9",
 					Name:      "VbenAntdv",
 					Path:      "/vben-admin/antdv",
 					Component: "IFrameView",
@@ -240,7 +262,7 @@ func defaultMenuList() v1.MenuAllRes {
 			},
 		},
 		{
-			Id:        10,
+			Id:        "10",
 			Component: "_core/about/index",
 			Type:      "menu",
 			Status:    1,
@@ -275,6 +297,43 @@ func filterMenuRoutes(items []*v1.MenuItem) v1.MenuAllRes {
 	return filtered
 }
 
+// filterMenusByCodes returns the menus of items the holder of codes may see,
+// the same codes CasbinAuthz requires for perm tagged routes. A menu with a
+// permission code the holder lacks is dropped with its children, unless its
+// meta sets menuVisibleWithForbidden; the frontend then shows it and
+// answers with 403. Catalogs without visible children are dropped.
+// items are not modified.
+func filterMenusByCodes(items []*v1.MenuItem, codes []string) v1.MenuAllRes {
+	granted := make(map[string]struct{}, len(codes))
+	for _, code := range codes {
+		granted[code] = struct{}{}
+	}
+	return filterMenuTree(items, granted)
+}
+
+func filterMenuTree(items []*v1.MenuItem, granted map[string]struct{}) v1.MenuAllRes {
+	filtered := make(v1.MenuAllRes, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if code := strings.TrimSpace(item.AuthCode); code != "" {
+			if _, ok := granted[code]; !ok && (item.Meta == nil || !item.Meta.MenuVisibleWithForbidden) {
+				continue
+			}
+		}
+		visible := *item
+		if len(item.Children) > 0 {
+			visible.Children = filterMenuTree(item.Children, granted)
+		}
+		if visible.Type == MenuTypeCatalog && len(visible.Children) == 0 {
+			continue
+		}
+		filtered = append(filtered, &visible)
+	}
+	return filtered
+}
+
 type menuRecord struct {
 	Id             string `json:"id" orm:"id"`
 	TenantId       string `json:"tenantId" orm:"tenant_id"`
@@ -291,12 +350,13 @@ type menuRecord struct {
 	Meta           string `json:"meta" orm:"meta"`
 }
 
-func fetchMenuFromDB(ctx context.Context) (v1.MenuAllRes, error) {
-	tenantID := resolveTenantID(ctx)
+func fetchMenuFromDB(ctx context.Context, tenantID string) (v1.MenuAllRes, error) {
+	if !isUUID(tenantID) {
+		return nil, nil
+	}
 	var records []menuRecord
-	err := g.DB().Ctx(ctx).Model("sys_menu").
+	err := g.DB().Ctx(ctx).Model(menuTable).
 		Where("tenant_id", tenantID).
-		Where("status", 1).
 		Where("deleted_at is null").
 		Order("\"order\" asc").
 		Scan(&records)
@@ -307,34 +367,57 @@ func fetchMenuFromDB(ctx context.Context) (v1.MenuAllRes, error) {
 		return nil, nil
 	}
 
-	itemsByID := make(map[string]*v1.MenuItem, len(records))
+	if orphans, cycles := menuTreeIssues(records); len(orphans) > 0 || len(cycles) > 0 {
+		g.Log().Warningf(ctx, "menus of %s left out of the menu tree: orphans %v, cycles %v", tenantID, orphans, cycles)
+	}
+	enabled := make([]menuRecord, 0, len(records))
 	for _, record := range records {
-		item := &v1.MenuItem{
-			Id:        0,
-			Pid:       0,
-			Name:      record.Name,
-			Path:      record.Path,
-			Component: record.Component,
-			Type:      record.Type,
-			Status:    record.Status,
-			Icon:      record.Icon,
-			AuthCode:  record.PermissionCode,
+		if record.Status == 1 {
+			enabled = append(enabled, record)
 		}
+	}
+	return buildMenuTree(enabled), nil
+}
 
-		if record.Meta != "" {
-			var meta v1.MenuMeta
-			if err := json.Unmarshal([]byte(record.Meta), &meta); err == nil {
-				item.Meta = &meta
-			}
-		}
-		if item.Meta == nil && record.Order != 0 {
-			item.Meta = &v1.MenuMeta{}
+func recordToMenuItem(record menuRecord) *v1.MenuItem {
+	item := &v1.MenuItem{
+		Id:        record.Id,
+		Pid:       record.ParentId,
+		Name:      record.Name,
+		Path:      record.Path,
+		Component: record.Component,
+		Type:      record.Type,
+		Status:    record.Status,
+		Icon:      record.Icon,
+		AuthCode:  record.PermissionCode,
+	}
+	if record.Meta != "" {
+		var meta v1.MenuMeta
+		if err := json.Unmarshal([]byte(record.Meta), &meta); err == nil {
+			item.Meta = &meta
 		}
-		if item.Meta != nil {
-			item.Meta.Order = record.Order
+	}
+	if item.Meta == nil && (record.Order != 0 || record.Visible == 0) {
+		item.Meta = &v1.MenuMeta{}
+	}
+	if item.Meta != nil {
+		item.Meta.Order = record.Order
+		// A hidden menu stays hidden; hideInMenu stored in meta by seeds and
+		// older writes is kept for visible menus.
+		if record.Visible == 0 {
+			item.Meta.HideInMenu = true
 		}
+	}
+	return item
+}
 
-		itemsByID[record.Id] = item
+// buildMenuTree nests records under their parents, keeping their order.
+// Menus that cannot be reached from a root, because an ancestor is missing or
+// they sit on a parent cycle, are left out.
+func buildMenuTree(records []menuRecord) v1.MenuAllRes {
+	itemsByID := make(map[string]*v1.MenuItem, len(records))
+	for _, record := range records {
+		itemsByID[record.Id] = recordToMenuItem(record)
 	}
 
 	var roots []*v1.MenuItem
@@ -344,30 +427,33 @@ func fetchMenuFromDB(ctx context.Context) (v1.MenuAllRes, error) {
 			roots = append(roots, item)
 			continue
 		}
-		parent := itemsByID[record.ParentId]
-		if parent == nil {
-			roots = append(roots, item)
-			continue
+		if parent := itemsByID[record.ParentId]; parent != nil {
+			parent.Children = append(parent.Children, item)
 		}
-		parent.Children = append(parent.Children, item)
 	}
-
-	return roots, nil
+	return roots
 }
 
-func resolveTenantID(ctx context.Context) string {
-	const defaultTenantID = "00000000-0000-0000-0000-000000000000"
-	token, err := resolveAccessToken(ctx, "")
-	if err != nil {
-		return defaultTenantID
-	}
-	claims, err := parseToken(token)
-	if err != nil {
-		return defaultTenantID
+// menuTreeIssues returns the ids of records whose parent is missing and of
+// records on a parent cycle, in the order of records.
+func menuTreeIssues(records []menuRecord) (orphans, cycles []string) {
+	parents := make(map[string]string, len(records))
+	for _, record := range records {
+		parents[record.Id] = record.ParentId
 	}
-	tenantID, _ := claims["tenantId"].(string)
-	if strings.TrimSpace(tenantID) == "" {
-		return defaultTenantID
+	for _, record := range records {
+		if _, ok := parents[record.ParentId]; record.ParentId != "" && !ok {
+			orphans = append(orphans, record.Id)
+			continue
+		}
+		seen := map[string]bool{}
+		for ancestor := record.ParentId; ancestor != "" && !seen[ancestor]; ancestor = parents[ancestor] {
+			if ancestor == record.Id {
+				cycles = append(cycles, record.Id)
+				break
+			}
+			seen[ancestor] = true
+		}
 	}
-	return tenantID
+	return orphans, cycles
 }
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"backend/api/menu/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/encoding/gyaml"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// Menu bundle encodings.
+const (
+	MenuBundleJSON = "json"
+	MenuBundleYAML = "yaml"
+)
+
+// menuBundleVersion is the bundle layout written by export and read by import.
+const menuBundleVersion = 1
+
+// Import change actions.
+const (
+	menuImportCreate = "create"
+	menuImportUpdate = "update"
+)
+
+// menuImportFields are the sys_menu columns an import compares, in report
+// order. The parent is compared by name separately.
+var menuImportFields = []string{"name", "path", "component", "type", "status", "permission_code", "icon", "order", "visible", "meta"}
+
+// Export encodes the caller's tenant menu tree as a bundle.
+func (s *sMenu) Export(ctx context.Context, format string) ([]byte, error) {
+	_, domain, err := menuOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ExportTenantMenus(ctx, domain, format)
+}
+
+// Import upserts the menus of a bundle into the caller's tenant.
+func (s *sMenu) Import(ctx context.Context, format string, content []byte, dryRun bool) (*v1.MenuImportRes, error) {
+	p, domain, err := menuOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return importMenus(ctx, domain, p.UserId, format, content, dryRun)
+}
+
+// ExportTenantMenus encodes the live menus of tenantID, buttons and disabled
+// menus included, as a bundle in format, JSON when empty.
+func ExportTenantMenus(ctx context.Context, tenantID, format string) ([]byte, error) {
+	if !isUUID(tenantID) {
+		return nil, gerror.NewCodef(consts.ErrorCodeTenantNotFound, "tenant %s not found", tenantID)
+	}
+	records, err := loadTenantMenus(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return encodeMenuBundle(menuBundleFromTree(buildSystemMenuTree(records)), format)
+}
+
+// ImportTenantMenus upserts the menus of a bundle into tenantID outside of a
+// request, as the menu import command does.
+func ImportTenantMenus(ctx context.Context, tenantID, format string, content []byte, dryRun bool) (*v1.MenuImportRes, error) {
+	if !isUUID(tenantID) {
+		return nil, gerror.NewCodef(consts.ErrorCodeTenantNotFound, "tenant %s not found", tenantID)
+	}
+	return importMenus(ctx, tenantID, "", format, content, dryRun)
+}
+
+func importMenus(ctx context.Context, domain, operatorID, format string, content []byte, dryRun bool) (*v1.MenuImportRes, error) {
+	bundle, err := decodeMenuBundle(content, format)
+	if err != nil {
+		return nil, err
+	}
+	var res *v1.MenuImportRes
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		// Hold the tree so the plan still matches the menus when it is applied.
+		if err := lockTenantMenus(ctx, domain); err != nil {
+			return err
+		}
+		records, err := loadTenantMenus(ctx, domain)
+		if err != nil {
+			return err
+		}
+		var items []*menuImportItem
+		items, res, err = planMenuImport(records, bundle)
+		if err != nil {
+			return err
+		}
+		res.DryRun = dryRun
+		if dryRun || len(res.Conflicts) > 0 || len(res.Changes) == 0 {
+			return nil
+		}
+		if err = applyMenuImport(ctx, domain, records, items); err != nil {
+			return err
+		}
+		if err = writeMenuRevisions(ctx, domain, operatorID, "menu.import", records); err != nil {
+			return err
+		}
+		res.Applied = true
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   domain,
+			OperatorId: operatorID,
+			Action:     "menu.import",
+			Resource:   menuTable,
+			After:      res.Changes,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Applied {
+		invalidateMenuTree(ctx, domain)
+	}
+	return res, nil
+}
+
+// menuImportItem is a bundle menu matched against the tenant's menus.
+type menuImportItem struct {
+	in     v1.MenuInput
+	parent string
+	target *menuRecord
+	fields []*v1.MenuFieldChange
+}
+
+// planMenuImport matches the menus of bundle against records by name, then
+// by path, and reports what importing it changes. Conflicts are bundle menus
+// that match two menus, share a menu with another bundle menu, would take a
+// name or path kept by another menu, or would turn a menu with children left
+// out of the bundle into a button. Malformed bundle menus are errors.
+func planMenuImport(records []*menuRecord, bundle *v1.MenuBundle) ([]*menuImportItem, *v1.MenuImportRes, error) {
+	items, err := flattenMenuBundle(bundle.Menus, "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, planMenuItems(records, items), nil
+}
+
+// planMenuItems matches items against records and fills in their targets and
+// changed fields, as described for planMenuImport. A parent may name a menu
+// of records that is not among items.
+func planMenuItems(records []*menuRecord, items []*menuImportItem) *v1.MenuImportRes {
+	res := &v1.MenuImportRes{Changes: []*v1.MenuImportChange{}, Conflicts: []*v1.MenuImportConflict{}}
+	conflicted := make(map[*menuImportItem]bool)
+	conflict := func(item *menuImportItem, format string, args ...any) {
+		conflicted[item] = true
+		res.Conflicts = append(res.Conflicts, &v1.MenuImportConflict{Name: item.in.Name, Path: item.in.Path, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	byID := make(map[string]*menuRecord, len(records))
+	byName := make(map[string]*menuRecord, len(records))
+	byPath := make(map[string]*menuRecord, len(records))
+	for _, record := range records {
+		byID[record.Id] = record
+		byName[record.Name] = record
+		if record.Path != "" {
+			byPath[record.Path] = record
+		}
+	}
+
+	claimed := make(map[string]*menuImportItem)
+	for _, item := range items {
+		named, routed := byName[item.in.Name], byPath[item.in.Path]
+		if named != nil && routed != nil && named != routed {
+			conflict(item, "name matches menu %s but path matches menu %s", named.Name, routed.Name)
+			continue
+		}
+		target := named
+		if target == nil {
+			target = routed
+		}
+		if target == nil {
+			continue
+		}
+		if other := claimed[target.Id]; other != nil {
+			conflict(item, "matches menu %s, already matched by %s", target.Name, other.in.Name)
+			continue
+		}
+		claimed[target.Id] = item
+		item.target = target
+	}
+
+	// Names and paths must stay unique once the bundle is applied; menus the
+	// bundle does not match keep theirs.
+	names := make(map[string]int)
+	paths := make(map[string]int)
+	for _, record := range records {
+		if claimed[record.Id] == nil {
+			names[record.Name]++
+			if record.Path != "" {
+				paths[record.Path]++
+			}
+		}
+	}
+	for _, item := range items {
+		names[item.in.Name]++
+		if item.in.Path != "" {
+			paths[item.in.Path]++
+		}
+	}
+	for _, item := range items {
+		if names[item.in.Name] > 1 {
+			conflict(item, "name %s is used by another menu", item.in.Name)
+		}
+		if item.in.Path != "" && paths[item.in.Path] > 1 {
+			conflict(item, "path %s is used by another menu", item.in.Path)
+		}
+		if item.target != nil && item.in.Type == MenuTypeButton {
+			for _, record := range records {
+				if record.ParentId == item.target.Id && claimed[record.Id] == nil {
+					conflict(item, "cannot become a button while menu %s is under it", record.Name)
+					break
+				}
+			}
+		}
+	}
+
+	// Conflicted items are neither created nor updated.
+	for _, item := range items {
+		if conflicted[item] {
+			continue
+		}
+		if item.target == nil {
+			res.Created++
+			res.Changes = append(res.Changes, &v1.MenuImportChange{Action: menuImportCreate, Name: item.in.Name, Path: item.in.Path})
+			continue
+		}
+		if parent := byID[item.target.ParentId]; (parent == nil && item.parent != "") || (parent != nil && parent.Name != item.parent) {
+			from := ""
+			if parent != nil {
+				from = parent.Name
+			}
+			item.fields = append(item.fields, &v1.MenuFieldChange{Field: "parent", From: from, To: item.parent})
+		}
+		before, after := menuData(recordToMenuInput(item.target)), menuData(item.in)
+		for _, field := range menuImportFields {
+			if fmt.Sprint(before[field]) != fmt.Sprint(after[field]) {
+				item.fields = append(item.fields, &v1.MenuFieldChange{Field: field, From: before[field], To: after[field]})
+			}
+		}
+		if len(item.fields) == 0 {
+			res.Unchanged++
+			continue
+		}
+		res.Updated++
+		res.Changes = append(res.Changes, &v1.MenuImportChange{Action: menuImportUpdate, Name: item.in.Name, Path: item.in.Path, Fields: item.fields})
+	}
+	return res
+}
+
+// flattenMenuBundle returns the menus of items and their descendants, parents
+// first, with their input checked as Create checks it.
+func flattenMenuBundle(items []*v1.MenuBundleItem, parent string, parentType *string) ([]*menuImportItem, error) {
+	var result []*menuImportItem
+	for _, bundled := range items {
+		if bundled == nil {
+			continue
+		}
+		in, err := normalizeMenuInput(v1.MenuInput{
+			Name:      bundled.Name,
+			Path:      bundled.Path,
+			Component: bundled.Component,
+			Type:      bundled.Type,
+			Status:    bundled.Status,
+			AuthCode:  bundled.AuthCode,
+			Meta:      bundled.Meta,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if parentType != nil && *parentType == MenuTypeButton {
+			return nil, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "button %s cannot have children", parent)
+		}
+		result = append(result, &menuImportItem{in: in, parent: parent})
+		children, err := flattenMenuBundle(bundled.Children, in.Name, &in.Type)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, children...)
+	}
+	return result, nil
+}
+
+// applyMenuImport writes the planned items, parents first, so created
+// parents have ids when their children are written. Parents outside items
+// are looked up by name in records.
+func applyMenuImport(ctx context.Context, domain string, records []*menuRecord, items []*menuImportItem) error {
+	ids := make(map[string]string, len(records)+len(items))
+	for _, record := range records {
+		ids[record.Name] = record.Id
+	}
+	now := gtime.Now()
+	for _, item := range items {
+		in := item.in
+		in.Pid = ids[item.parent]
+		data := menuData(in)
+		if item.target != nil {
+			ids[in.Name] = item.target.Id
+			if len(item.fields) == 0 {
+				continue
+			}
+			data["updated_at"] = now
+			_, err := g.DB().Ctx(ctx).Model(menuTable).Data(data).Where("id", item.target.Id).Update()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		data["tenant_id"] = domain
+		id, err := insertMenu(ctx, data)
+		if err != nil {
+			return err
+		}
+		ids[in.Name] = id
+	}
+	return nil
+}
+
+// recordToMenuInput returns the input that writes record as it is stored.
+func recordToMenuInput(record *menuRecord) v1.MenuInput {
+	item := recordToSystemMenu(record)
+	return v1.MenuInput{
+		Pid:       item.Pid,
+		Name:      item.Name,
+		Path:      item.Path,
+		Component: item.Component,
+		Type:      item.Type,
+		Status:    item.Status,
+		AuthCode:  item.AuthCode,
+		Meta:      item.Meta,
+	}
+}
+
+// menuBundleFromTree strips the ids from a menu tree.
+func menuBundleFromTree(tree v1.MenuListRes) *v1.MenuBundle {
+	var convert func(items []*v1.SystemMenuItem) []*v1.MenuBundleItem
+	convert = func(items []*v1.SystemMenuItem) []*v1.MenuBundleItem {
+		result := make([]*v1.MenuBundleItem, 0, len(items))
+		for _, item := range items {
+			result = append(result, &v1.MenuBundleItem{
+				Name:      item.Name,
+				Path:      item.Path,
+				Component: item.Component,
+				Type:      item.Type,
+				Status:    item.Status,
+				AuthCode:  item.AuthCode,
+				Meta:      item.Meta,
+				Children:  convert(item.Children),
+			})
+		}
+		return result
+	}
+	return &v1.MenuBundle{Version: menuBundleVersion, Menus: convert(tree)}
+}
+
+// encodeMenuBundle encodes bundle in format. YAML is converted from the JSON
+// encoding so both honour the json tags and keep unknown meta keys.
+func encodeMenuBundle(bundle *v1.MenuBundle, format string) ([]byte, error) {
+	content, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", MenuBundleJSON:
+		return content, nil
+	case MenuBundleYAML:
+		var value any
+		if err = json.Unmarshal(content, &value); err != nil {
+			return nil, err
+		}
+		return gyaml.Encode(value)
+	default:
+		return nil, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "bundle format %s must be json or yaml", format)
+	}
+}
+
+// decodeMenuBundle decodes content in format, detecting JSON or YAML when
+// format is empty.
+func decodeMenuBundle(content []byte, format string) (*v1.MenuBundle, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = MenuBundleYAML
+		if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '{' {
+			format = MenuBundleJSON
+		}
+	}
+	switch format {
+	case MenuBundleJSON:
+	case MenuBundleYAML:
+		converted, err := gyaml.ToJson(content)
+		if err != nil {
+			return nil, gerror.WrapCode(consts.ErrorCodeMenuInvalid, err, "malformed menu bundle")
+		}
+		content = converted
+	default:
+		return nil, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "bundle format %s must be json or yaml", format)
+	}
+	var bundle v1.MenuBundle
+	if err := json.Unmarshal(content, &bundle); err != nil {
+		return nil, gerror.WrapCode(consts.ErrorCodeMenuInvalid, err, "malformed menu bundle")
+	}
+	if bundle.Version != menuBundleVersion {
+		return nil, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "menu bundle version %d is not supported", bundle.Version)
+	}
+	return &bundle, nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"backend/api/menu/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+func testMenuBundle(t *gtest.T, format string) *v1.MenuBundle {
+	content, err := encodeMenuBundle(menuBundleFromTree(buildSystemMenuTree(testMenuRecords())), format)
+	t.AssertNil(err)
+	bundle, err := decodeMenuBundle(content, "")
+	t.AssertNil(err)
+	return bundle
+}
+
+func TestMenuBundle_RoundTrip(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		for _, format := range []string{MenuBundleJSON, MenuBundleYAML} {
+			bundle := testMenuBundle(t, format)
+			t.Assert(bundle.Version, menuBundleVersion)
+			t.Assert(len(bundle.Menus), 2)
+			t.Assert(bundle.Menus[0].Name, "System")
+			t.Assert(bundle.Menus[0].Children[0].Children[0].AuthCode, "System:Menu:Create")
+			t.Assert(bundle.Menus[0].Children[1].Meta.HideInMenu, true)
+
+			// Importing an export into the same tenant changes nothing.
+			_, res, err := planMenuImport(testMenuRecords(), bundle)
+			t.AssertNil(err)
+			t.Assert(res.Unchanged, 5)
+			t.Assert(len(res.Changes), 0)
+			t.Assert(len(res.Conflicts), 0)
+		}
+
+		content, err := encodeMenuBundle(menuBundleFromTree(buildSystemMenuTree(testMenuRecords())), MenuBundleYAML)
+		t.AssertNil(err)
+		t.Assert(strings.Contains(string(content), "authCode: System:Menu:List"), true)
+	})
+}
+
+func TestPlanMenuImport(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		bundle := testMenuBundle(t, MenuBundleYAML)
+		system := bundle.Menus[0]
+		system.Children[1].Component = "/system/dept/index"
+		system.Children[1].Meta.Extra = map[string]json.RawMessage{"x-owner": json.RawMessage(`"ops"`)}
+		system.Children = append(system.Children, &v1.MenuBundleItem{
+			Name: "SystemUser", Path: "/system/user", Component: "/system/user/list", Type: MenuTypeMenu, Status: 1,
+			Children: []*v1.MenuBundleItem{{Name: "SystemUserCreate", Type: MenuTypeButton, Status: 1, AuthCode: "System:User:Create"}},
+		})
+		// About moves under System, matched by path despite the new name.
+		about := bundle.Menus[1]
+		about.Name = "AboutUs"
+		system.Children = append(system.Children, about)
+		bundle.Menus = bundle.Menus[:1]
+
+		items, res, err := planMenuImport(testMenuRecords(), bundle)
+		t.AssertNil(err)
+		t.Assert(len(res.Conflicts), 0)
+		t.Assert(res.Created, 2)
+		t.Assert(res.Updated, 2)
+		t.Assert(res.Unchanged, 3)
+
+		changes := make(map[string]*v1.MenuImportChange)
+		for _, change := range res.Changes {
+			changes[change.Name] = change
+		}
+		t.Assert(changes["SystemUser"].Action, menuImportCreate)
+		t.Assert(changes["SystemUserCreate"].Action, menuImportCreate)
+
+		dept := changes["SystemDept"]
+		t.Assert(dept.Action, menuImportUpdate)
+		t.Assert(len(dept.Fields), 2)
+		t.Assert(dept.Fields[0].Field, "component")
+		t.Assert(dept.Fields[0].To, "/system/dept/index")
+		t.Assert(dept.Fields[1].Field, "meta")
+		t.Assert(strings.Contains(dept.Fields[1].To.(string), `"x-owner":"ops"`), true)
+
+		moved := changes["AboutUs"]
+		t.Assert(moved.Fields[0], &v1.MenuFieldChange{Field: "parent", From: "", To: "System"})
+		t.Assert(moved.Fields[1], &v1.MenuFieldChange{Field: "name", From: "About", To: "AboutUs"})
+
+		// Children follow their parents so created parents exist first.
+		order := make([]string, 0, len(items))
+		for _, item := range items {
+			order = append(order, item.in.Name)
+		}
+		t.Assert(order, []string{"System", "SystemMenu", "SystemMenuCreate", "SystemDept", "SystemUser", "SystemUserCreate", "AboutUs"})
+	})
+}
+
+func TestPlanMenuImport_Conflicts(t *testing.T) {
+	testCases := []struct {
+		name   string
+		menus  []*v1.MenuBundleItem
+		reason string
+	}{
+		{
+			name:   "name and path match different menus",
+			menus:  []*v1.MenuBundleItem{{Name: "About", Path: "/system", Type: MenuTypeCatalog, Status: 1}},
+			reason: "name matches menu About but path matches menu System",
+		},
+		{
+			name: "two bundle menus match one menu",
+			menus: []*v1.MenuBundleItem{
+				{Name: "About", Path: "/about", Component: "_core/about/index", Type: MenuTypeMenu, Status: 1},
+				{Name: "AboutUs", Path: "/about", Component: "_core/about/index", Type: MenuTypeMenu, Status: 1},
+			},
+			reason: "matches menu About, already matched by About",
+		},
+		{
+			name: "duplicate path in bundle",
+			menus: []*v1.MenuBundleItem{
+				{Name: "Docs", Path: "/docs", Type: MenuTypeCatalog, Status: 1},
+				{Name: "Guides", Path: "/docs", Type: MenuTypeCatalog, Status: 1},
+			},
+			reason: "path /docs is used by another menu",
+		},
+		{
+			name: "duplicate name in bundle",
+			menus: []*v1.MenuBundleItem{
+				{Name: "Docs", Path: "/docs", Type: MenuTypeCatalog, Status: 1},
+				{Name: "Docs", Path: "/docs2", Type: MenuTypeCatalog, Status: 1},
+			},
+			reason: "name Docs is used by another menu",
+		},
+		{
+			name:   "menu with children turned into a button",
+			menus:  []*v1.MenuBundleItem{{Name: "SystemMenu", Type: MenuTypeButton, Status: 1}},
+			reason: "cannot become a button while menu SystemMenuCreate is under it",
+		},
+	}
+	gtest.C(t, func(t *gtest.T) {
+		for _, c := range testCases {
+			_, res, err := planMenuImport(testMenuRecords(), &v1.MenuBundle{Version: menuBundleVersion, Menus: c.menus})
+			t.AssertNil(err)
+			t.AssertGT(len(res.Conflicts), 0)
+			t.Assert(res.Conflicts[0].Reason, c.reason)
+
+			// Conflicted menus are not reported as created or updated.
+			for _, conflict := range res.Conflicts {
+				for _, change := range res.Changes {
+					t.AssertNE(change.Name, conflict.Name)
+				}
+			}
+			t.Assert(res.Created, 0)
+		}
+
+		// Malformed menus are rejected outright.
+		_, _, err := planMenuImport(testMenuRecords(), &v1.MenuBundle{Version: menuBundleVersion, Menus: []*v1.MenuBundleItem{
+			{Name: "Create", Type: MenuTypeButton, Children: []*v1.MenuBundleItem{{Name: "Nested", Type: MenuTypeButton}}},
+		}})
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+		_, _, err = planMenuImport(testMenuRecords(), &v1.MenuBundle{Version: menuBundleVersion, Menus: []*v1.MenuBundleItem{
+			{Name: "Page", Path: "/page", Type: MenuTypeMenu},
+		}})
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+	})
+}
+
+func TestDecodeMenuBundle(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		bundle, err := decodeMenuBundle([]byte("version: 1\nmenus:\n  - name: Docs\n    path: /docs\n    type: catalog\n    status: 1\n    meta:\n      title: docs.title\n      domCached: true\n"), "")
+		t.AssertNil(err)
+		t.Assert(bundle.Menus[0].Meta.Title, "docs.title")
+		t.Assert(string(bundle.Menus[0].Meta.Extra["domCached"]), "true")
+
+		_, err = decodeMenuBundle([]byte(`{"version":2,"menus":[]}`), "")
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+		_, err = decodeMenuBundle([]byte(`{"version":1`), MenuBundleJSON)
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+		_, err = decodeMenuBundle([]byte(`{"version":1}`), "xml")
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+	})
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/api/menu/v1"
+
+	"github.com/gogf/gf/v2/database/gredis"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcache"
+)
+
+const (
+	// menuTreeCacheKey prefixes the cache key of a tenant's menu tree.
+	menuTreeCacheKey = "menu:tree:"
+
+	// menuTreeDefaultCacheTTL bounds how long instances that did not make a
+	// menu change serve the old tree, when menu.cacheTTL is not set.
+	menuTreeDefaultCacheTTL = time.Minute
+
+	// Backends of the menu tree cache, selected by menu.cacheAdapter.
+	menuCacheMemory = "memory"
+	menuCacheRedis  = "redis"
+)
+
+// menuTree is the cached menu tree of a tenant: its enabled routes, before
+// they are filtered by the caller's access codes. Menus is empty when the
+// tenant has none.
+type menuTree struct {
+	Menus v1.MenuAllRes `json:"menus"`
+}
+
+var (
+	menuTreeCache = gcache.New()
+
+	menuTreeTTLOnce  sync.Once
+	menuTreeTTLValue time.Duration
+
+	// loadMenuTree reads the menu tree of a tenant from sys_menu.
+	loadMenuTree = fetchMenuFromDB
+)
+
+// InitMenuCache selects the backend of the menu tree cache from
+// menu.cacheAdapter. "memory", the default, keeps trees per instance;
+// "redis" shares them between instances through the redis.default
+// configuration, which requires a gredis adapter such as
+// github.com/gogf/gf/contrib/nosql/redis/v2 to be imported by the binary.
+func InitMenuCache(ctx context.Context) error {
+	adapter := menuCacheMemory
+	if v, err := g.Cfg().Get(ctx, "menu.cacheAdapter"); err == nil && v != nil && v.String() != "" {
+		adapter = strings.ToLower(strings.TrimSpace(v.String()))
+	}
+	switch adapter {
+	case menuCacheMemory:
+		return nil
+	case menuCacheRedis:
+		v, err := g.Cfg().Get(ctx, "redis.default")
+		if err != nil {
+			return err
+		}
+		if v == nil || v.IsNil() {
+			return gerror.New("menu cache: redis.default configuration not found")
+		}
+		config, err := gredis.ConfigFromMap(v.Map())
+		if err != nil {
+			return err
+		}
+		redis, err := gredis.New(config)
+		if err != nil {
+			return err
+		}
+		menuTreeCache.SetAdapter(gcache.NewAdapterRedis(redis))
+		return nil
+	}
+	return gerror.Newf("menu cache: unsupported adapter %q", adapter)
+}
+
+// tenantMenuTree returns the menu tree of tenantID, cached for
+// menu.cacheTTL. The tree is shared between callers and must not be
+// modified.
+func tenantMenuTree(ctx context.Context, tenantID string) (v1.MenuAllRes, error) {
+	value, err := menuTreeCache.GetOrSetFuncLock(ctx, menuTreeCacheKey+tenantID, func(ctx context.Context) (interface{}, error) {
+		menus, err := loadMenuTree(ctx, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		return &menuTree{Menus: filterMenuRoutes(menus)}, nil
+	}, menuTreeCacheTTL(ctx))
+	if err != nil || value == nil || value.IsNil() {
+		return nil, err
+	}
+	if tree, ok := value.Val().(*menuTree); ok {
+		return tree.Menus, nil
+	}
+	// Trees read back from Redis arrive JSON encoded.
+	var tree menuTree
+	if err = json.Unmarshal(value.Bytes(), &tree); err != nil {
+		return nil, err
+	}
+	return tree.Menus, nil
+}
+
+// menuTreeCacheTTL reads menu.cacheTTL once, as it is consulted on every
+// request.
+func menuTreeCacheTTL(ctx context.Context) time.Duration {
+	menuTreeTTLOnce.Do(func() {
+		menuTreeTTLValue = menuTreeDefaultCacheTTL
+		if v, err := g.Cfg().Get(ctx, "menu.cacheTTL"); err == nil && v != nil && !v.IsNil() {
+			menuTreeTTLValue = v.Duration()
+		}
+	})
+	return menuTreeTTLValue
+}
+
+// invalidateMenuTree drops the cached menu tree of tenantID. With the memory
+// backend, other instances pick the change up within the cache TTL.
+func invalidateMenuTree(ctx context.Context, tenantID string) {
+	if _, err := menuTreeCache.Remove(ctx, menuTreeCacheKey+tenantID); err != nil {
+		g.Log().Warningf(ctx, "failed to invalidate menu tree of %s: %v", tenantID, err)
+	}
+}
+
+// MenuETag returns the entity tag of menus as served by /menu/all. It only
+// changes when the menus do, so clients can revalidate with If-None-Match.
+func MenuETag(menus v1.MenuAllRes) string {
+	content, err := json.Marshal(menus)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	return fmt.Sprintf(`W/"%x"`, sum[:16])
+}
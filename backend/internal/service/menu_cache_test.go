@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"backend/api/menu/v1"
+
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+// stubMenuTree serves tree for every tenant from loadMenuTree and counts the
+// loads per tenant.
+func stubMenuTree(t *testing.T, tree func() v1.MenuAllRes) map[string]int {
+	loads := make(map[string]int)
+	previous := loadMenuTree
+	loadMenuTree = func(ctx context.Context, tenantID string) (v1.MenuAllRes, error) {
+		loads[tenantID]++
+		return tree(), nil
+	}
+	t.Cleanup(func() {
+		loadMenuTree = previous
+		_ = menuTreeCache.Clear(context.Background())
+	})
+	return loads
+}
+
+func TestTenantMenuTree_Cached(t *testing.T) {
+	const tenant = "11111111-1111-1111-1111-111111111111"
+	loads := stubMenuTree(t, func() v1.MenuAllRes {
+		return v1.MenuAllRes{
+			{Id: "1", Name: "System", Path: "/system", Type: MenuTypeCatalog, Children: []*v1.MenuItem{
+				{Id: "2", Pid: "1", Name: "SystemMenu", Path: "/system/menu", Type: MenuTypeMenu, AuthCode: "System:Menu:List"},
+				{Id: "3", Pid: "1", Name: "SystemDept", Path: "/system/dept", Type: MenuTypeMenu, AuthCode: "System:Dept:List"},
+				{Id: "4", Pid: "2", Name: "SystemMenuCreate", Type: MenuTypeButton, AuthCode: "System:Menu:Create"},
+			}},
+		}
+	})
+	ctx := context.TODO()
+	gtest.C(t, func(t *gtest.T) {
+		staff := withAccessCodes(ctx, tenant, "System:Menu:List")
+		menus, err := Menu().All(staff)
+		t.AssertNil(err)
+		t.Assert(len(menus), 1)
+		t.Assert(len(menus[0].Children), 1)
+
+		admin := withAccessCodes(ctx, tenant, "System:Menu:List", "System:Dept:List")
+		menus, err = Menu().All(admin)
+		t.AssertNil(err)
+		t.Assert(len(menus[0].Children), 2)
+		t.Assert(loads[tenant], 1)
+
+		// Filtering for one caller leaves the shared tree intact.
+		tree, err := tenantMenuTree(ctx, tenant)
+		t.AssertNil(err)
+		t.Assert(len(tree[0].Children), 2)
+		t.Assert(findMenuByPath(tree, "/system/menu").Children, nil)
+
+		invalidateMenuTree(ctx, tenant)
+		_, err = Menu().All(staff)
+		t.AssertNil(err)
+		t.Assert(loads[tenant], 2)
+	})
+}
+
+func TestMenuETag(t *testing.T) {
+	tree := func() v1.MenuAllRes {
+		return v1.MenuAllRes{
+			{Id: "1", Name: "Workspace", Path: "/workspace", Type: MenuTypeMenu, Meta: &v1.MenuMeta{Title: "Workspace"}},
+			{Id: "2", Name: "Report", Path: "/report", Type: MenuTypeMenu, AuthCode: "Report:View"},
+		}
+	}
+	gtest.C(t, func(t *gtest.T) {
+		etag := MenuETag(tree())
+		t.AssertNE(etag, "")
+		t.Assert(MenuETag(tree()), etag)
+		t.AssertNE(MenuETag(filterMenusByCodes(tree(), nil)), etag)
+
+		changed := tree()
+		changed[0].Meta.Title = "Home"
+		t.AssertNE(MenuETag(changed), etag)
+	})
+}
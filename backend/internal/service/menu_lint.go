@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"backend/api/menu/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gfile"
+)
+
+// Severities of menu lint issues. Errors break navigation; warnings concern
+// disabled menus, which are not served to the frontend.
+const (
+	MenuLintError   = "error"
+	MenuLintWarning = "warning"
+)
+
+// MenuLintIssue is a problem found in a menu of a tenant.
+type MenuLintIssue struct {
+	Severity string
+	Check    string
+	MenuId   string
+	Name     string
+	Message  string
+}
+
+// MenuLintOptions tunes LintTenantMenus.
+type MenuLintOptions struct {
+	// ViewsDir is the views directory of the frontend app. Components are not
+	// checked when it is empty.
+	ViewsDir string
+	// Layouts are the component names the frontend maps to layouts rather
+	// than to files under ViewsDir, such as BasicLayout and IFrameView.
+	Layouts []string
+}
+
+// LintTenantMenus checks the live menus of tenantID for duplicate names and
+// paths, parents that are missing, are buttons or form a cycle, menus below
+// such parents, components missing from opts.ViewsDir, links and iframes
+// without a valid URL, and buttons without a permission code.
+func LintTenantMenus(ctx context.Context, tenantID string, opts MenuLintOptions) ([]*MenuLintIssue, error) {
+	if !isUUID(tenantID) {
+		return nil, gerror.NewCodef(consts.ErrorCodeTenantNotFound, "tenant %s not found", tenantID)
+	}
+	var viewExists func(file string) bool
+	if opts.ViewsDir != "" {
+		if !gfile.IsDir(opts.ViewsDir) {
+			return nil, gerror.Newf("views directory %s not found", opts.ViewsDir)
+		}
+		viewExists = func(file string) bool {
+			return gfile.IsFile(filepath.Join(opts.ViewsDir, filepath.FromSlash(file)))
+		}
+	}
+	records, err := loadTenantMenus(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return lintMenus(records, viewExists, trimNonEmpty(opts.Layouts)), nil
+}
+
+// lintMenus returns the issues of records in their order. viewExists reports
+// whether a view file, relative to the views directory, exists; components
+// are not checked when it is nil.
+func lintMenus(records []*menuRecord, viewExists func(file string) bool, layouts []string) []*MenuLintIssue {
+	var (
+		issues   []*MenuLintIssue
+		byID     = make(map[string]*menuRecord, len(records))
+		names    = make(map[string]int, len(records))
+		paths    = make(map[string]int, len(records))
+		children = make(map[string]int, len(records))
+		values   = make([]menuRecord, 0, len(records))
+	)
+	for _, record := range records {
+		byID[record.Id] = record
+		names[record.Name]++
+		if record.Path != "" {
+			paths[record.Path]++
+		}
+		children[record.ParentId]++
+		values = append(values, *record)
+	}
+	orphans, cycles := menuTreeIssues(values)
+	detached := menuDetachedAncestors(records, append(orphans, cycles...))
+
+	for _, record := range records {
+		severity := MenuLintError
+		if record.Status != 1 {
+			severity = MenuLintWarning
+		}
+		report := func(severity, check, format string, args ...any) {
+			issues = append(issues, &MenuLintIssue{
+				Severity: severity,
+				Check:    check,
+				MenuId:   record.Id,
+				Name:     record.Name,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		if names[record.Name] > 1 {
+			report(MenuLintError, "duplicate-name", "name %s is used by %d menus", record.Name, names[record.Name])
+		}
+		if record.Path != "" && paths[record.Path] > 1 {
+			report(MenuLintError, "duplicate-path", "path %s is used by %d menus", record.Path, paths[record.Path])
+		}
+		switch {
+		case slices.Contains(orphans, record.Id):
+			report(MenuLintError, "dangling-parent", "parent %s does not exist", record.ParentId)
+		case slices.Contains(cycles, record.Id):
+			report(MenuLintError, "parent-cycle", "parent %s leads back to the menu", record.ParentId)
+		case detached[record.Id] != nil:
+			report(MenuLintError, "unreachable", "ancestor %s is not attached to the menu tree", detached[record.Id].Name)
+		case record.ParentId != "" && byID[record.ParentId].Type == MenuTypeButton:
+			report(MenuLintError, "button-parent", "parent %s is a button", byID[record.ParentId].Name)
+		}
+
+		var meta v1.MenuMeta
+		if record.Meta != "" {
+			if err := json.Unmarshal([]byte(record.Meta), &meta); err != nil {
+				report(severity, "invalid-meta", "meta is not valid JSON: %v", err)
+			}
+		}
+		switch record.Type {
+		case MenuTypeButton:
+			if strings.TrimSpace(record.PermissionCode) == "" {
+				report(severity, "missing-auth-code", "button has no permission code")
+			}
+		case MenuTypeLink:
+			if !validMenuURL(meta.Link) {
+				report(severity, "invalid-url", "link %q is not an absolute http(s) URL", meta.Link)
+			}
+		case MenuTypeEmbedded:
+			if !validMenuURL(meta.IframeSrc) {
+				report(severity, "invalid-url", "iframe source %q is not an absolute http(s) URL", meta.IframeSrc)
+			}
+		case MenuTypeMenu:
+			if record.Component == "" && children[record.Id] == 0 {
+				report(severity, "missing-component", "menu has no component")
+			}
+		}
+		if viewExists != nil && record.Component != "" && !slices.Contains(layouts, record.Component) {
+			if file := menuViewFile(record.Component); !viewExists(file) {
+				report(severity, "unknown-component", "component %s has no view %s", record.Component, file)
+			}
+		}
+	}
+	return issues
+}
+
+// menuDetachedAncestors returns, for each record below one of roots, the
+// nearest such ancestor. roots are the menus whose parent is missing or on a
+// cycle; their descendants cannot be reached from the top of the tree.
+func menuDetachedAncestors(records []*menuRecord, roots []string) map[string]*menuRecord {
+	byID := make(map[string]*menuRecord, len(records))
+	for _, record := range records {
+		byID[record.Id] = record
+	}
+	detached := make(map[string]*menuRecord)
+	for _, record := range records {
+		if slices.Contains(roots, record.Id) {
+			continue
+		}
+		seen := map[string]bool{record.Id: true}
+		for parent := byID[record.ParentId]; parent != nil && !seen[parent.Id]; parent = byID[parent.ParentId] {
+			if slices.Contains(roots, parent.Id) {
+				detached[record.Id] = parent
+				break
+			}
+			seen[parent.Id] = true
+		}
+	}
+	return detached
+}
+
+// menuViewFile returns the file, relative to the views directory, that the
+// frontend loads for component, resolving it the way vben's
+// generateRoutesByBackend does.
+func menuViewFile(component string) string {
+	file := component
+	for strings.HasPrefix(file, "./") || strings.HasPrefix(file, "../") {
+		file = strings.TrimPrefix(strings.TrimPrefix(file, "./"), "../")
+	}
+	if !strings.HasPrefix(file, "/") {
+		file = "/" + file
+	}
+	file = strings.TrimPrefix(file, "/views")
+	if !strings.HasSuffix(file, ".vue") {
+		file += ".vue"
+	}
+	return file
+}
+
+// validMenuURL reports whether value is an absolute http or https URL.
+func validMenuURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+func TestMenuViewFile(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(menuViewFile("/system/menu/list"), "/system/menu/list.vue")
+		t.Assert(menuViewFile("_core/about/index"), "/_core/about/index.vue")
+		t.Assert(menuViewFile("../views/dashboard/workspace/index.vue"), "/dashboard/workspace/index.vue")
+		t.Assert(menuViewFile("./demos/form"), "/demos/form.vue")
+	})
+}
+
+func TestLintMenus(t *testing.T) {
+	views := map[string]bool{
+		"/system/menu/list.vue":  true,
+		"/_core/about/index.vue": true,
+	}
+	viewExists := func(file string) bool { return views[file] }
+	layouts := []string{"BasicLayout", "IFrameView"}
+
+	gtest.C(t, func(t *gtest.T) {
+		// The shared fixture only lacks the view of the disabled Dept menu.
+		issues := lintMenus(testMenuRecords(), viewExists, layouts)
+		t.Assert(len(issues), 1)
+		t.Assert(issues[0].Check, "unknown-component")
+		t.Assert(issues[0].Severity, MenuLintWarning)
+		t.Assert(issues[0].MenuId, testMenuDept)
+
+		// Without a views directory, components are not checked.
+		t.Assert(len(lintMenus(testMenuRecords(), nil, layouts)), 0)
+
+		records := append(testMenuRecords(),
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000020", Name: "SystemMenu", Path: "/system/menu", Component: "BasicLayout", Type: MenuTypeCatalog, Status: 1},
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000021", ParentId: "20000000-0000-0000-0000-000000000099", Name: "Lost", Path: "/lost", Component: "/system/menu/list", Type: MenuTypeMenu, Status: 1},
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000022", ParentId: testMenuButton, Name: "SystemMenuExport", Type: MenuTypeButton, Status: 1},
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000023", Name: "Docs", Path: "/docs", Type: MenuTypeLink, Status: 1, Meta: `{"link":"docs.example.com"}`},
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000024", Name: "Grafana", Path: "/grafana", Component: "IFrameView", Type: MenuTypeEmbedded, Status: 1, Meta: `{"iframeSrc":"https://grafana.example.com"}`},
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000025", ParentId: "20000000-0000-0000-0000-000000000026", Name: "LoopA", Path: "/loop-a", Component: "/system/menu/list", Type: MenuTypeMenu, Status: 1},
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000026", ParentId: "20000000-0000-0000-0000-000000000025", Name: "LoopB", Path: "/loop-b", Type: MenuTypeMenu, Status: 1},
+			// Menus below an orphan or a cycle cannot be reached either.
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000027", ParentId: "20000000-0000-0000-0000-000000000021", Name: "LostChild", Path: "/lost/child", Component: "/system/menu/list", Type: MenuTypeMenu, Status: 1},
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000028", ParentId: "20000000-0000-0000-0000-000000000027", Name: "LostChildCreate", Type: MenuTypeButton, Status: 1, PermissionCode: "Lost:Create"},
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000029", ParentId: "20000000-0000-0000-0000-000000000026", Name: "LoopChild", Path: "/loop-b/child", Component: "/system/menu/list", Type: MenuTypeMenu, Status: 1},
+		)
+		var checks []string
+		for _, issue := range lintMenus(records, viewExists, layouts) {
+			if issue.Severity == MenuLintError {
+				checks = append(checks, issue.Name+":"+issue.Check)
+			}
+		}
+		t.Assert(checks, []string{
+			"SystemMenu:duplicate-name",
+			"SystemMenu:duplicate-path",
+			"SystemMenu:duplicate-name",
+			"SystemMenu:duplicate-path",
+			"Lost:dangling-parent",
+			"SystemMenuExport:button-parent",
+			"SystemMenuExport:missing-auth-code",
+			"Docs:invalid-url",
+			"LoopA:parent-cycle",
+			"LoopB:parent-cycle",
+			"LostChild:unreachable",
+			"LostChildCreate:unreachable",
+			"LoopChild:unreachable",
+		})
+
+		issues = lintMenus(records, nil, layouts)
+		for _, issue := range issues {
+			if issue.Name == "LostChildCreate" {
+				t.Assert(issue.Message, "ancestor Lost is not attached to the menu tree")
+			}
+			if issue.Name == "LoopChild" {
+				t.Assert(issue.Message, "ancestor LoopB is not attached to the menu tree")
+			}
+		}
+	})
+}
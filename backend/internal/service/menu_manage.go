@@ -0,0 +1,521 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"backend/api/menu/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// Menu types understood by the frontend router.
+const (
+	MenuTypeCatalog  = "catalog"
+	MenuTypeMenu     = "menu"
+	MenuTypeButton   = "button"
+	MenuTypeEmbedded = "embedded"
+	MenuTypeLink     = "link"
+)
+
+var menuTypes = []string{MenuTypeCatalog, MenuTypeMenu, MenuTypeButton, MenuTypeEmbedded, MenuTypeLink}
+
+// List returns the caller's tenant menu tree, including buttons and disabled
+// menus, ordered by the order column.
+func (s *sMenu) List(ctx context.Context) (v1.MenuListRes, error) {
+	_, domain, err := menuOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records, err := loadTenantMenus(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	return buildSystemMenuTree(records), nil
+}
+
+// NameExists reports whether a live menu of the caller's tenant other than
+// excludeID is named name.
+func (s *sMenu) NameExists(ctx context.Context, name, excludeID string) (bool, error) {
+	_, domain, err := menuOperator(ctx)
+	if err != nil {
+		return false, err
+	}
+	return menuTaken(ctx, domain, "name", strings.TrimSpace(name), excludeID)
+}
+
+// PathExists reports whether a live menu of the caller's tenant other than
+// excludeID routes to path.
+func (s *sMenu) PathExists(ctx context.Context, path, excludeID string) (bool, error) {
+	_, domain, err := menuOperator(ctx)
+	if err != nil {
+		return false, err
+	}
+	return menuTaken(ctx, domain, "path", strings.TrimSpace(path), excludeID)
+}
+
+// Create adds a menu to the caller's tenant.
+func (s *sMenu) Create(ctx context.Context, in v1.MenuInput) (*v1.SystemMenuItem, error) {
+	p, domain, err := menuOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	in, err = normalizeMenuInput(in)
+	if err != nil {
+		return nil, err
+	}
+	var item *v1.SystemMenuItem
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		// Hold the tree so a concurrent create cannot take the name or path
+		// between the uniqueness check and the insert.
+		if err := lockTenantMenus(ctx, domain); err != nil {
+			return err
+		}
+		records, err := loadTenantMenus(ctx, domain)
+		if err != nil {
+			return err
+		}
+		if err = validateMenuPlacement(records, "", in); err != nil {
+			return err
+		}
+		if err = checkMenuUnique(ctx, domain, "", in); err != nil {
+			return err
+		}
+		data := menuData(in)
+		data["tenant_id"] = domain
+		id, err := insertMenu(ctx, data)
+		if err != nil {
+			return err
+		}
+		record, err := findMenuRecord(ctx, domain, "id", id)
+		if err != nil {
+			return err
+		}
+		item = recordToSystemMenu(record)
+		if err = writeMenuRevisions(ctx, domain, p.UserId, "menu.create", records); err != nil {
+			return err
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   domain,
+			OperatorId: p.UserId,
+			Action:     "menu.create",
+			Resource:   menuTable,
+			ResourceId: item.Id,
+			After:      item,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateMenuTree(ctx, domain)
+	return item, nil
+}
+
+// Update replaces the writable fields of a menu of the caller's tenant.
+func (s *sMenu) Update(ctx context.Context, id string, in v1.MenuInput) (*v1.SystemMenuItem, error) {
+	p, domain, err := menuOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	in, err = normalizeMenuInput(in)
+	if err != nil {
+		return nil, err
+	}
+	var after *v1.SystemMenuItem
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if err := lockTenantMenus(ctx, domain); err != nil {
+			return err
+		}
+		before, err := findMenuRecord(ctx, domain, "id", id)
+		if err != nil {
+			return err
+		}
+		records, err := loadTenantMenus(ctx, domain)
+		if err != nil {
+			return err
+		}
+		if err = validateMenuPlacement(records, before.Id, in); err != nil {
+			return err
+		}
+		if err = checkMenuUnique(ctx, domain, before.Id, in); err != nil {
+			return err
+		}
+		data := menuData(in)
+		data["updated_at"] = gtime.Now()
+		if _, err = g.DB().Ctx(ctx).Model(menuTable).Data(data).Where("id", before.Id).Update(); err != nil {
+			return err
+		}
+		record, err := findMenuRecord(ctx, domain, "id", before.Id)
+		if err != nil {
+			return err
+		}
+		after = recordToSystemMenu(record)
+		if err = writeMenuRevisions(ctx, domain, p.UserId, "menu.update", records); err != nil {
+			return err
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   domain,
+			OperatorId: p.UserId,
+			Action:     "menu.update",
+			Resource:   menuTable,
+			ResourceId: before.Id,
+			Before:     recordToSystemMenu(before),
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateMenuTree(ctx, domain)
+	return after, nil
+}
+
+// Delete soft deletes a menu of the caller's tenant together with its
+// descendants.
+func (s *sMenu) Delete(ctx context.Context, id string) error {
+	p, domain, err := menuOperator(ctx)
+	if err != nil {
+		return err
+	}
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		// Hold the tree so a menu moved or created under the target in the
+		// meantime is not left behind without its parent.
+		if err := lockTenantMenus(ctx, domain); err != nil {
+			return err
+		}
+		target, err := findMenuRecord(ctx, domain, "id", id)
+		if err != nil {
+			return err
+		}
+		records, err := loadTenantMenus(ctx, domain)
+		if err != nil {
+			return err
+		}
+		ids := menuSubtree(records, target.Id)
+		_, err = g.DB().Ctx(ctx).Model(menuTable).
+			Data(g.Map{"deleted_at": gtime.Now()}).
+			Where("tenant_id", domain).
+			WhereIn("id", ids).
+			Update()
+		if err != nil {
+			return err
+		}
+		if err = writeMenuRevisions(ctx, domain, p.UserId, "menu.delete", records); err != nil {
+			return err
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   domain,
+			OperatorId: p.UserId,
+			Action:     "menu.delete",
+			Resource:   menuTable,
+			ResourceId: target.Id,
+			Before:     g.Map{"menu": recordToSystemMenu(target), "deletedIds": ids},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	invalidateMenuTree(ctx, domain)
+	return nil
+}
+
+// menuOperator returns the caller and the tenant whose menus it manages.
+func menuOperator(ctx context.Context) (*Principal, string, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	domain := NormalizeDomain(p.TenantId)
+	if !isUUID(domain) {
+		return nil, "", gerror.NewCodef(consts.ErrorCodeForbidden, "domain %s has no menus", domain)
+	}
+	return p, domain, nil
+}
+
+// loadTenantMenus returns the live menus of domain in display order.
+func loadTenantMenus(ctx context.Context, domain string) ([]*menuRecord, error) {
+	var records []*menuRecord
+	err := g.DB().Ctx(ctx).Model(menuTable).
+		Where("tenant_id", domain).
+		Where("deleted_at is null").
+		Order("\"order\" asc, name asc").
+		Scan(&records)
+	return records, err
+}
+
+// findMenuRecord returns the live menu of domain whose column equals value.
+func findMenuRecord(ctx context.Context, domain, column, value string) (*menuRecord, error) {
+	value = strings.TrimSpace(value)
+	if column == "id" && !isUUID(value) {
+		return nil, gerror.NewCodef(consts.ErrorCodeMenuNotFound, "menu %s not found", value)
+	}
+	var record *menuRecord
+	err := g.DB().Ctx(ctx).Model(menuTable).
+		Where("tenant_id", domain).
+		Where(column, value).
+		Where("deleted_at is null").
+		Scan(&record)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, gerror.NewCodef(consts.ErrorCodeMenuNotFound, "menu %s not found", value)
+	}
+	return record, nil
+}
+
+// menuTaken reports whether a live menu of domain other than excludeID has
+// column equal to value.
+func menuTaken(ctx context.Context, domain, column, value, excludeID string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	model := g.DB().Ctx(ctx).Model(menuTable).
+		Where("tenant_id", domain).
+		Where(column, value).
+		Where("deleted_at is null")
+	if excludeID = strings.TrimSpace(excludeID); isUUID(excludeID) {
+		model = model.WhereNot("id", excludeID)
+	}
+	count, err := model.Count()
+	return count > 0, err
+}
+
+// checkMenuUnique requires the name, and the path of routable menus, to be
+// unused by the other live menus of domain.
+func checkMenuUnique(ctx context.Context, domain, excludeID string, in v1.MenuInput) error {
+	taken, err := menuTaken(ctx, domain, "name", in.Name, excludeID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return gerror.NewCodef(consts.ErrorCodeMenuExists, "menu name %s already exists", in.Name)
+	}
+	if taken, err = menuTaken(ctx, domain, "path", in.Path, excludeID); err != nil {
+		return err
+	}
+	if taken {
+		return gerror.NewCodef(consts.ErrorCodeMenuExists, "menu path %s already exists", in.Path)
+	}
+	return nil
+}
+
+// normalizeMenuInput trims in and checks the fields each menu type needs.
+func normalizeMenuInput(in v1.MenuInput) (v1.MenuInput, error) {
+	in.Pid = strings.TrimSpace(in.Pid)
+	in.Name = strings.TrimSpace(in.Name)
+	in.Path = strings.TrimSpace(in.Path)
+	in.Component = strings.TrimSpace(in.Component)
+	in.Type = strings.TrimSpace(in.Type)
+	in.AuthCode = strings.TrimSpace(in.AuthCode)
+	if in.Name == "" {
+		return in, gerror.NewCode(consts.ErrorCodeMenuInvalid, "name is required")
+	}
+	valid := false
+	for _, menuType := range menuTypes {
+		valid = valid || in.Type == menuType
+	}
+	if !valid {
+		return in, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "type %s must be one of %s", in.Type, strings.Join(menuTypes, ", "))
+	}
+	if in.Pid != "" && !isUUID(in.Pid) {
+		return in, gerror.NewCodef(consts.ErrorCodeMenuNotFound, "parent menu %s not found", in.Pid)
+	}
+	if in.Type == MenuTypeButton {
+		// Buttons are permission entries, not routes.
+		in.Path, in.Component = "", ""
+		return in, nil
+	}
+	if !strings.HasPrefix(in.Path, "/") {
+		return in, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "path of %s must start with /", in.Name)
+	}
+	switch in.Type {
+	case MenuTypeMenu:
+		if in.Component == "" {
+			return in, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "menu %s requires a component", in.Name)
+		}
+	case MenuTypeEmbedded:
+		if in.Meta == nil || strings.TrimSpace(in.Meta.IframeSrc) == "" {
+			return in, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "embedded menu %s requires meta.iframeSrc", in.Name)
+		}
+	case MenuTypeLink:
+		if in.Meta == nil || strings.TrimSpace(in.Meta.Link) == "" {
+			return in, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "link menu %s requires meta.link", in.Name)
+		}
+	}
+	return in, nil
+}
+
+// validateMenuPlacement checks the parent of in against the live menus of the
+// tenant: it must exist, must not be a button and, when id is being updated,
+// must not be id itself or one of its descendants. Buttons cannot have
+// children.
+func validateMenuPlacement(records []*menuRecord, id string, in v1.MenuInput) error {
+	byID := make(map[string]*menuRecord, len(records))
+	for _, record := range records {
+		byID[record.Id] = record
+	}
+	if in.Pid != "" {
+		parent := byID[in.Pid]
+		if parent == nil {
+			return gerror.NewCodef(consts.ErrorCodeMenuNotFound, "parent menu %s not found", in.Pid)
+		}
+		if parent.Type == MenuTypeButton {
+			return gerror.NewCodef(consts.ErrorCodeMenuInvalid, "button %s cannot have children", parent.Name)
+		}
+	}
+	if id == "" {
+		return nil
+	}
+	subtree := menuSubtree(records, id)
+	for _, descendant := range subtree {
+		if descendant == in.Pid {
+			return gerror.NewCodef(consts.ErrorCodeMenuInvalid, "menu %s cannot be moved under itself", in.Name)
+		}
+	}
+	if in.Type == MenuTypeButton && len(subtree) > 1 {
+		return gerror.NewCodef(consts.ErrorCodeMenuInvalid, "menu %s has children and cannot become a button", in.Name)
+	}
+	return nil
+}
+
+// menuSubtree returns id followed by the ids of all its descendants.
+func menuSubtree(records []*menuRecord, id string) []string {
+	children := make(map[string][]string, len(records))
+	for _, record := range records {
+		if record.ParentId != "" {
+			children[record.ParentId] = append(children[record.ParentId], record.Id)
+		}
+	}
+	ids := []string{id}
+	seen := map[string]struct{}{id: {}}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if _, ok := seen[child]; ok {
+				continue
+			}
+			seen[child] = struct{}{}
+			ids = append(ids, child)
+		}
+	}
+	return ids
+}
+
+// insertMenu inserts a sys_menu row with the columns of data and returns the
+// id the database assigned to it.
+func insertMenu(ctx context.Context, data g.Map) (string, error) {
+	columns := make([]string, 0, len(data))
+	for column := range data {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	var (
+		quoted = make([]string, 0, len(columns))
+		marks  = make([]string, 0, len(columns))
+		args   = make([]interface{}, 0, len(columns))
+	)
+	for _, column := range columns {
+		quoted = append(quoted, `"`+column+`"`)
+		marks = append(marks, "?")
+		args = append(args, data[column])
+	}
+	id, err := g.DB().GetValue(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING id",
+		menuTable, strings.Join(quoted, ", "), strings.Join(marks, ", ")), args...)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// menuData returns the sys_menu columns written for in. The order and icon
+// columns mirror meta.order and meta.icon, and visible mirrors the inverse
+// of meta.hideInMenu.
+func menuData(in v1.MenuInput) g.Map {
+	data := g.Map{
+		"parent_id":       nil,
+		"name":            in.Name,
+		"path":            in.Path,
+		"component":       in.Component,
+		"icon":            "",
+		"order":           0,
+		"type":            in.Type,
+		"visible":         1,
+		"status":          in.Status,
+		"permission_code": in.AuthCode,
+		"meta":            nil,
+	}
+	if in.Pid != "" {
+		data["parent_id"] = in.Pid
+	}
+	if in.Meta != nil {
+		data["icon"] = in.Meta.Icon
+		data["order"] = in.Meta.Order
+		if in.Meta.HideInMenu {
+			data["visible"] = 0
+		}
+		meta := *in.Meta
+		meta.Order = 0
+		meta.HideInMenu = false
+		if encoded, err := json.Marshal(meta); err == nil && string(encoded) != "{}" {
+			data["meta"] = string(encoded)
+		}
+	}
+	return data
+}
+
+// recordToSystemMenu converts a sys_menu row for the menu management page.
+func recordToSystemMenu(record *menuRecord) *v1.SystemMenuItem {
+	item := &v1.SystemMenuItem{
+		Id:        record.Id,
+		Pid:       record.ParentId,
+		Name:      record.Name,
+		Path:      record.Path,
+		Component: record.Component,
+		Type:      record.Type,
+		Status:    record.Status,
+		AuthCode:  record.PermissionCode,
+		Meta:      &v1.MenuMeta{},
+	}
+	if record.Meta != "" {
+		_ = json.Unmarshal([]byte(record.Meta), item.Meta)
+	}
+	if item.Meta.Icon == "" {
+		item.Meta.Icon = record.Icon
+	}
+	item.Meta.Order = record.Order
+	if record.Visible == 0 {
+		item.Meta.HideInMenu = true
+	}
+	return item
+}
+
+// buildSystemMenuTree nests records under their parents, keeping their order.
+// Menus whose parent is missing and menus on a parent cycle are listed as
+// roots, so every menu can still be reached and repaired.
+func buildSystemMenuTree(records []*menuRecord) v1.MenuListRes {
+	items := make(map[string]*v1.SystemMenuItem, len(records))
+	values := make([]menuRecord, 0, len(records))
+	for _, record := range records {
+		items[record.Id] = recordToSystemMenu(record)
+		values = append(values, *record)
+	}
+	orphans, cycles := menuTreeIssues(values)
+	roots := make(v1.MenuListRes, 0)
+	for _, record := range records {
+		item := items[record.Id]
+		if record.ParentId != "" && !slices.Contains(orphans, record.Id) && !slices.Contains(cycles, record.Id) {
+			items[record.ParentId].Children = append(items[record.ParentId].Children, item)
+			continue
+		}
+		roots = append(roots, item)
+	}
+	return roots
+}
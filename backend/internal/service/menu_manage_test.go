@@ -0,0 +1,221 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"backend/api/menu/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+const (
+	testMenuSystem = "20000000-0000-0000-0000-000000000010"
+	testMenuMenu   = "20000000-0000-0000-0000-000000000011"
+	testMenuButton = "20000000-0000-0000-0000-000000000012"
+	testMenuDept   = "20000000-0000-0000-0000-000000000013"
+	testMenuAbout  = "20000000-0000-0000-0000-000000000014"
+)
+
+func testMenuRecords() []*menuRecord {
+	return []*menuRecord{
+		{Id: testMenuSystem, Name: "System", Path: "/system", Type: MenuTypeCatalog, Visible: 1, Status: 1, Order: 1, Meta: `{"title":"system.title","icon":"carbon:settings"}`},
+		{Id: testMenuMenu, ParentId: testMenuSystem, Name: "SystemMenu", Path: "/system/menu", Component: "/system/menu/list", Type: MenuTypeMenu, Visible: 1, Status: 1, PermissionCode: "System:Menu:List", Icon: "carbon:menu"},
+		{Id: testMenuButton, ParentId: testMenuMenu, Name: "SystemMenuCreate", Type: MenuTypeButton, Visible: 1, Status: 1, PermissionCode: "System:Menu:Create"},
+		{Id: testMenuDept, ParentId: testMenuSystem, Name: "SystemDept", Path: "/system/dept", Component: "/system/dept/list", Type: MenuTypeMenu, Status: 0, Order: 2},
+		{Id: testMenuAbout, Name: "About", Path: "/about", Component: "_core/about/index", Type: MenuTypeMenu, Visible: 1, Status: 1, Order: 9},
+	}
+}
+
+func TestNormalizeMenuInput(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   v1.MenuInput
+		code int
+	}{
+		{name: "menu", in: v1.MenuInput{Name: " Users ", Path: "/system/user", Component: "/system/user/list", Type: MenuTypeMenu}},
+		{name: "catalog", in: v1.MenuInput{Name: "System", Path: "/system", Type: MenuTypeCatalog}},
+		{name: "button without path", in: v1.MenuInput{Name: "UserCreate", Type: MenuTypeButton, AuthCode: "System:User:Create"}},
+		{name: "embedded", in: v1.MenuInput{Name: "Doc", Path: "/doc", Type: MenuTypeEmbedded, Meta: &v1.MenuMeta{IframeSrc: "https://doc.vben.pro"}}},
+		{name: "link", in: v1.MenuInput{Name: "Github", Path: "/github", Type: MenuTypeLink, Meta: &v1.MenuMeta{Link: "https://github.com"}}},
+		{name: "missing name", in: v1.MenuInput{Name: " ", Path: "/x", Type: MenuTypeCatalog}, code: consts.ErrorCodeMenuInvalid.Code()},
+		{name: "unknown type", in: v1.MenuInput{Name: "X", Path: "/x", Type: "page"}, code: consts.ErrorCodeMenuInvalid.Code()},
+		{name: "relative path", in: v1.MenuInput{Name: "X", Path: "x", Type: MenuTypeCatalog}, code: consts.ErrorCodeMenuInvalid.Code()},
+		{name: "menu without component", in: v1.MenuInput{Name: "X", Path: "/x", Type: MenuTypeMenu}, code: consts.ErrorCodeMenuInvalid.Code()},
+		{name: "embedded without iframe", in: v1.MenuInput{Name: "X", Path: "/x", Type: MenuTypeEmbedded}, code: consts.ErrorCodeMenuInvalid.Code()},
+		{name: "link without link", in: v1.MenuInput{Name: "X", Path: "/x", Type: MenuTypeLink, Meta: &v1.MenuMeta{}}, code: consts.ErrorCodeMenuInvalid.Code()},
+		{name: "malformed parent", in: v1.MenuInput{Name: "X", Pid: "2", Path: "/x", Type: MenuTypeCatalog}, code: consts.ErrorCodeMenuNotFound.Code()},
+	}
+	gtest.C(t, func(t *gtest.T) {
+		for _, c := range testCases {
+			_, err := normalizeMenuInput(c.in)
+			if c.code == 0 {
+				t.AssertNil(err)
+				continue
+			}
+			t.Assert(gerror.Code(err).Code(), c.code)
+		}
+
+		out, err := normalizeMenuInput(v1.MenuInput{Name: " Users ", Path: " /u ", Component: "/system/user/list", Type: " menu "})
+		t.AssertNil(err)
+		t.Assert(out.Name, "Users")
+		t.Assert(out.Path, "/u")
+		t.Assert(out.Type, MenuTypeMenu)
+
+		out, err = normalizeMenuInput(v1.MenuInput{Name: "B", Path: "/b", Component: "x", Type: MenuTypeButton})
+		t.AssertNil(err)
+		t.Assert(out.Path, "")
+		t.Assert(out.Component, "")
+	})
+}
+
+func TestValidateMenuPlacement(t *testing.T) {
+	records := testMenuRecords()
+	gtest.C(t, func(t *gtest.T) {
+		t.AssertNil(validateMenuPlacement(records, "", v1.MenuInput{Name: "New", Pid: testMenuSystem, Type: MenuTypeMenu}))
+		t.AssertNil(validateMenuPlacement(records, "", v1.MenuInput{Name: "Root", Type: MenuTypeCatalog}))
+		t.AssertNil(validateMenuPlacement(records, testMenuDept, v1.MenuInput{Name: "SystemDept", Pid: testMenuMenu, Type: MenuTypeMenu}))
+
+		err := validateMenuPlacement(records, "", v1.MenuInput{Name: "New", Pid: "20000000-0000-0000-0000-0000000000ff", Type: MenuTypeMenu})
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuNotFound)
+		err = validateMenuPlacement(records, "", v1.MenuInput{Name: "New", Pid: testMenuButton, Type: MenuTypeButton})
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+		// A menu cannot become its own ancestor.
+		err = validateMenuPlacement(records, testMenuSystem, v1.MenuInput{Name: "System", Pid: testMenuMenu, Type: MenuTypeCatalog})
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+		err = validateMenuPlacement(records, testMenuSystem, v1.MenuInput{Name: "System", Pid: testMenuSystem, Type: MenuTypeCatalog})
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+		// Menus with children cannot turn into buttons.
+		err = validateMenuPlacement(records, testMenuMenu, v1.MenuInput{Name: "SystemMenu", Pid: testMenuSystem, Type: MenuTypeButton})
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+	})
+}
+
+func TestMenuSubtree(t *testing.T) {
+	records := testMenuRecords()
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(menuSubtree(records, testMenuSystem), []string{testMenuSystem, testMenuMenu, testMenuDept, testMenuButton})
+		t.Assert(menuSubtree(records, testMenuButton), []string{testMenuButton})
+
+		// Cycles in stored data do not loop forever.
+		cyclic := append(testMenuRecords(), &menuRecord{Id: "c1", ParentId: "c2"}, &menuRecord{Id: "c2", ParentId: "c1"})
+		t.Assert(menuSubtree(cyclic, "c1"), []string{"c1", "c2"})
+	})
+}
+
+func TestBuildSystemMenuTree(t *testing.T) {
+	records := append(testMenuRecords(), &menuRecord{
+		Id: "20000000-0000-0000-0000-000000000099", ParentId: "20000000-0000-0000-0000-0000000000ff", Name: "Orphan", Path: "/orphan", Type: MenuTypeCatalog,
+	})
+	gtest.C(t, func(t *gtest.T) {
+		tree := buildSystemMenuTree(records)
+		t.Assert(len(tree), 3)
+		t.Assert(tree[0].Name, "System")
+		t.Assert(tree[0].Meta.Title, "system.title")
+		t.Assert(tree[0].Meta.Icon, "carbon:settings")
+		t.Assert(tree[0].Meta.Order, 1)
+		t.Assert(len(tree[0].Children), 2)
+
+		menu := tree[0].Children[0]
+		t.Assert(menu.Id, testMenuMenu)
+		t.Assert(menu.Pid, testMenuSystem)
+		t.Assert(menu.AuthCode, "System:Menu:List")
+		t.Assert(menu.Meta.Icon, "carbon:menu")
+		t.Assert(len(menu.Children), 1)
+		t.Assert(menu.Children[0].Type, MenuTypeButton)
+
+		// Disabled menus are listed for management.
+		t.Assert(tree[0].Children[1].Status, 0)
+		t.Assert(tree[2].Name, "Orphan")
+	})
+	gtest.C(t, func(t *gtest.T) {
+		// Menus on a parent cycle are listed as roots, with the menus below
+		// them nested as usual.
+		cyclic := append(testMenuRecords(),
+			&menuRecord{Id: "c1", ParentId: "c2", Name: "CycleA", Type: MenuTypeCatalog},
+			&menuRecord{Id: "c2", ParentId: "c1", Name: "CycleB", Type: MenuTypeCatalog},
+			&menuRecord{Id: "c3", ParentId: "c1", Name: "CycleChild", Type: MenuTypeMenu},
+			&menuRecord{Id: "c4", ParentId: "c4", Name: "Self", Type: MenuTypeMenu},
+		)
+		tree := buildSystemMenuTree(cyclic)
+		t.Assert(len(tree), 5)
+		t.Assert(tree[2].Id, "c1")
+		t.Assert(len(tree[2].Children), 1)
+		t.Assert(tree[2].Children[0].Id, "c3")
+		t.Assert(tree[3].Id, "c2")
+		t.Assert(len(tree[3].Children), 0)
+		t.Assert(tree[4].Id, "c4")
+		t.Assert(len(tree[4].Children), 0)
+	})
+}
+
+func TestMenuData(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		data := menuData(v1.MenuInput{
+			Pid: testMenuSystem, Name: "SystemUser", Path: "/system/user", Component: "/system/user/list",
+			Type: MenuTypeMenu, Status: 1, AuthCode: "System:User:List",
+			Meta: &v1.MenuMeta{Title: "system.user.title", Icon: "carbon:user", Order: 3},
+		})
+		t.Assert(data["parent_id"], testMenuSystem)
+		t.Assert(data["icon"], "carbon:user")
+		t.Assert(data["order"], 3)
+		t.Assert(data["permission_code"], "System:User:List")
+		t.Assert(data["meta"], `{"icon":"carbon:user","title":"system.user.title"}`)
+		t.Assert(data["visible"], 1)
+
+		data = menuData(v1.MenuInput{Name: "Root", Path: "/root", Type: MenuTypeCatalog, Meta: &v1.MenuMeta{Order: 5}})
+		t.Assert(data["parent_id"], nil)
+		t.Assert(data["meta"], nil)
+		t.Assert(data["order"], 5)
+
+		// hideInMenu is stored in the visible column, unknown keys in meta.
+		var meta v1.MenuMeta
+		t.AssertNil(json.Unmarshal([]byte(`{"title":"t","hideInMenu":true,"keepAlive":true,"x-owner":{"team":"ops"}}`), &meta))
+		data = menuData(v1.MenuInput{Name: "Hidden", Path: "/hidden", Component: "/hidden/index", Type: MenuTypeMenu, Meta: &meta})
+		t.Assert(data["visible"], 0)
+		t.Assert(data["meta"], `{"keepAlive":true,"title":"t","x-owner":{"team":"ops"}}`)
+	})
+}
+
+func TestRecordToSystemMenu_Meta(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		item := recordToSystemMenu(&menuRecord{
+			Id: testMenuDept, Name: "SystemDept", Type: MenuTypeMenu, Visible: 0, Order: 2,
+			Meta: `{"title":"system.dept.title","keepAlive":true,"activePath":"/system","query":{"tab":"all"},"authority":["admin"],"fullPathKey":false,"x-owner":"ops"}`,
+		})
+		t.Assert(item.Meta.HideInMenu, true)
+		t.Assert(item.Meta.KeepAlive, true)
+		t.Assert(item.Meta.ActivePath, "/system")
+		t.Assert(item.Meta.Query["tab"], "all")
+		t.Assert(item.Meta.Authority, []string{"admin"})
+		t.Assert(*item.Meta.FullPathKey, false)
+
+		// Saving the listed menu back writes the same meta.
+		in := v1.MenuInput{Name: item.Name, Path: "/system/dept", Component: "/system/dept/list", Type: item.Type, Meta: item.Meta}
+		data := menuData(in)
+		t.Assert(data["visible"], 0)
+		t.Assert(data["order"], 2)
+		t.Assert(data["meta"], `{"activePath":"/system","authority":["admin"],"fullPathKey":false,"keepAlive":true,"query":{"tab":"all"},"title":"system.dept.title","x-owner":"ops"}`)
+	})
+}
+
+func TestMenuInput_BindMeta(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		var in v1.MenuInput
+		err := gconv.Struct(g.Map{
+			"name": "Doc",
+			"type": MenuTypeEmbedded,
+			"meta": g.Map{"iframeSrc": "https://doc.vben.pro", "hideInTab": true, "maxNumOfOpenTab": 3, "x-owner": "ops"},
+		}, &in)
+		t.AssertNil(err)
+		t.AssertNE(in.Meta, nil)
+		t.Assert(in.Meta.IframeSrc, "https://doc.vben.pro")
+		t.Assert(in.Meta.HideInTab, true)
+		t.Assert(in.Meta.MaxNumOfOpenTab, 3)
+		t.Assert(string(in.Meta.Extra["x-owner"]), `"ops"`)
+	})
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"backend/api/menu/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// menuOrphanGroup prefixes the sibling group of menus whose parent is missing.
+const menuOrphanGroup = "orphan:"
+
+// menuPosition is the parent and order of a menu.
+type menuPosition struct {
+	Id       string `json:"id"`
+	ParentId string `json:"pid"`
+	Order    int    `json:"order"`
+}
+
+// Move applies moves to the menus of the caller's tenant in one transaction
+// and returns the resulting menu tree. The siblings of every parent a menu
+// leaves or joins are renumbered from 0.
+func (s *sMenu) Move(ctx context.Context, moves []v1.MenuMoveItem) (v1.MenuListRes, error) {
+	p, domain, err := menuOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(moves) == 0 {
+		return nil, gerror.NewCode(consts.ErrorCodeMenuInvalid, "moves are required")
+	}
+	var tree v1.MenuListRes
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		// Lock the tenant's menus so concurrent moves cannot combine into a cycle.
+		if err := lockTenantMenus(ctx, domain); err != nil {
+			return err
+		}
+		records, err := loadTenantMenus(ctx, domain)
+		if err != nil {
+			return err
+		}
+		before, after, err := planMenuMoves(records, moves)
+		if err != nil {
+			return err
+		}
+		now := gtime.Now()
+		for _, position := range after {
+			var parentID any
+			if position.ParentId != "" {
+				parentID = position.ParentId
+			}
+			_, err = g.DB().Ctx(ctx).Model(menuTable).
+				Data(g.Map{"parent_id": parentID, "order": position.Order, "updated_at": now}).
+				Where("tenant_id", domain).
+				Where("id", position.Id).
+				Update()
+			if err != nil {
+				return err
+			}
+		}
+		if len(after) > 0 {
+			err = writeAuditLog(ctx, auditEntry{
+				TenantId:   domain,
+				OperatorId: p.UserId,
+				Action:     "menu.move",
+				Resource:   menuTable,
+				Before:     before,
+				After:      after,
+			})
+			if err != nil {
+				return err
+			}
+			if err = writeMenuRevisions(ctx, domain, p.UserId, "menu.move", records); err != nil {
+				return err
+			}
+		}
+		if records, err = loadTenantMenus(ctx, domain); err != nil {
+			return err
+		}
+		tree = buildSystemMenuTree(records)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateMenuTree(ctx, domain)
+	return tree, nil
+}
+
+// planMenuMoves applies moves to records in memory. It returns the previous
+// and new positions of the menus whose parent or order changes. A move fails
+// if the menu or its new parent is not among records, the parent is a button,
+// or the parent is the menu itself or one of its descendants. Menus whose
+// parent is missing keep their order until they are moved.
+func planMenuMoves(records []*menuRecord, moves []v1.MenuMoveItem) (before, after []menuPosition, err error) {
+	byID := make(map[string]*menuRecord, len(records))
+	parents := make(map[string]string, len(records))
+	siblings := make(map[string][]string)
+	for _, record := range records {
+		byID[record.Id] = record
+	}
+	for _, record := range records {
+		parentID := record.ParentId
+		if parentID != "" && byID[parentID] == nil {
+			// Orphans are kept apart from the roots until moved.
+			parentID = menuOrphanGroup + parentID
+		}
+		parents[record.Id] = parentID
+		siblings[parentID] = append(siblings[parentID], record.Id)
+	}
+
+	touched := make(map[string]bool)
+	for _, move := range moves {
+		id, parentID := strings.TrimSpace(move.Id), strings.TrimSpace(move.Pid)
+		if byID[id] == nil {
+			return nil, nil, gerror.NewCodef(consts.ErrorCodeMenuNotFound, "menu %s not found", id)
+		}
+		if parentID != "" {
+			parent := byID[parentID]
+			if parent == nil {
+				return nil, nil, gerror.NewCodef(consts.ErrorCodeMenuNotFound, "parent menu %s not found", parentID)
+			}
+			if parent.Type == MenuTypeButton {
+				return nil, nil, gerror.NewCode(consts.ErrorCodeMenuInvalid, "buttons cannot have children")
+			}
+			// Walk up from the new parent; meeting id means a cycle.
+			for ancestor, seen := parentID, map[string]bool{}; ancestor != "" && !seen[ancestor]; ancestor = parents[ancestor] {
+				if ancestor == id {
+					return nil, nil, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "moving menu %s under %s would create a cycle", id, parentID)
+				}
+				seen[ancestor] = true
+			}
+		}
+
+		from := parents[id]
+		siblings[from] = slices.DeleteFunc(siblings[from], func(sibling string) bool { return sibling == id })
+		position := min(max(move.Position, 0), len(siblings[parentID]))
+		siblings[parentID] = slices.Insert(siblings[parentID], position, id)
+		parents[id] = parentID
+		touched[from], touched[parentID] = !strings.HasPrefix(from, menuOrphanGroup), true
+	}
+
+	for _, record := range records {
+		parentID := parents[record.Id]
+		if !touched[parentID] {
+			continue
+		}
+		order := slices.Index(siblings[parentID], record.Id)
+		if parentID == record.ParentId && order == record.Order {
+			continue
+		}
+		before = append(before, menuPosition{Id: record.Id, ParentId: record.ParentId, Order: record.Order})
+		after = append(after, menuPosition{Id: record.Id, ParentId: parentID, Order: order})
+	}
+	return before, after, nil
+}
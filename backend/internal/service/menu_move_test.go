@@ -0,0 +1,123 @@
+package service
+
+import (
+	"testing"
+
+	"backend/api/menu/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+func TestPlanMenuMoves(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		// Reordering within a parent renumbers its children only.
+		before, after, err := planMenuMoves(testMenuRecords(), []v1.MenuMoveItem{{Id: testMenuDept, Pid: testMenuSystem, Position: 0}})
+		t.AssertNil(err)
+		t.Assert(before, []menuPosition{
+			{Id: testMenuMenu, ParentId: testMenuSystem, Order: 0},
+			{Id: testMenuDept, ParentId: testMenuSystem, Order: 2},
+		})
+		t.Assert(after, []menuPosition{
+			{Id: testMenuMenu, ParentId: testMenuSystem, Order: 1},
+			{Id: testMenuDept, ParentId: testMenuSystem, Order: 0},
+		})
+
+		// Moving renumbers the parent left and the parent joined.
+		_, after, err = planMenuMoves(testMenuRecords(), []v1.MenuMoveItem{{Id: testMenuAbout, Pid: testMenuSystem, Position: 1}})
+		t.AssertNil(err)
+		t.Assert(after, []menuPosition{
+			{Id: testMenuSystem, ParentId: "", Order: 0},
+			{Id: testMenuAbout, ParentId: testMenuSystem, Order: 1},
+		})
+
+		// Positions past the end append.
+		_, after, err = planMenuMoves(testMenuRecords(), []v1.MenuMoveItem{{Id: testMenuMenu, Position: 99}})
+		t.AssertNil(err)
+		t.Assert(after, []menuPosition{
+			{Id: testMenuSystem, ParentId: "", Order: 0},
+			{Id: testMenuMenu, ParentId: "", Order: 2},
+			{Id: testMenuDept, ParentId: testMenuSystem, Order: 0},
+			{Id: testMenuAbout, ParentId: "", Order: 1},
+		})
+
+		// Moves apply in order: once SystemMenu leaves System, System may
+		// move under it.
+		_, after, err = planMenuMoves(testMenuRecords(), []v1.MenuMoveItem{
+			{Id: testMenuMenu, Position: 0},
+			{Id: testMenuSystem, Pid: testMenuMenu, Position: 0},
+		})
+		t.AssertNil(err)
+		t.Assert(after, []menuPosition{
+			{Id: testMenuSystem, ParentId: testMenuMenu, Order: 0},
+			{Id: testMenuMenu, ParentId: "", Order: 0},
+			{Id: testMenuButton, ParentId: testMenuMenu, Order: 1},
+			{Id: testMenuDept, ParentId: testMenuSystem, Order: 0},
+			{Id: testMenuAbout, ParentId: "", Order: 1},
+		})
+
+		// Nothing changes when the menu stays where it is.
+		before, after, err = planMenuMoves(testMenuRecords(), []v1.MenuMoveItem{{Id: testMenuButton, Pid: testMenuMenu, Position: 0}})
+		t.AssertNil(err)
+		t.Assert(len(before), 0)
+		t.Assert(len(after), 0)
+	})
+}
+
+func TestPlanMenuMoves_Invalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		moves []v1.MenuMoveItem
+		code  int
+	}{
+		{name: "unknown menu", moves: []v1.MenuMoveItem{{Id: "20000000-0000-0000-0000-0000000000ff"}}, code: consts.ErrorCodeMenuNotFound.Code()},
+		{name: "unknown parent", moves: []v1.MenuMoveItem{{Id: testMenuAbout, Pid: "20000000-0000-0000-0000-0000000000ff"}}, code: consts.ErrorCodeMenuNotFound.Code()},
+		{name: "button parent", moves: []v1.MenuMoveItem{{Id: testMenuAbout, Pid: testMenuButton}}, code: consts.ErrorCodeMenuInvalid.Code()},
+		{name: "own parent", moves: []v1.MenuMoveItem{{Id: testMenuSystem, Pid: testMenuSystem}}, code: consts.ErrorCodeMenuInvalid.Code()},
+		{name: "descendant parent", moves: []v1.MenuMoveItem{{Id: testMenuSystem, Pid: testMenuDept}}, code: consts.ErrorCodeMenuInvalid.Code()},
+		{
+			name: "cycle through earlier move",
+			moves: []v1.MenuMoveItem{
+				{Id: testMenuAbout, Pid: testMenuDept},
+				{Id: testMenuSystem, Pid: testMenuAbout},
+			},
+			code: consts.ErrorCodeMenuInvalid.Code(),
+		},
+	}
+	gtest.C(t, func(t *gtest.T) {
+		for _, c := range testCases {
+			_, _, err := planMenuMoves(testMenuRecords(), c.moves)
+			t.Assert(gerror.Code(err).Code(), c.code)
+		}
+	})
+}
+
+func TestPlanMenuMoves_RepairsTree(t *testing.T) {
+	records := append(testMenuRecords(),
+		&menuRecord{Id: "c1", ParentId: "c2", Type: MenuTypeCatalog},
+		&menuRecord{Id: "c2", ParentId: "c1", Type: MenuTypeCatalog},
+		&menuRecord{Id: "o1", ParentId: "gone", Type: MenuTypeMenu, Order: 4},
+		&menuRecord{Id: "o2", ParentId: "gone", Type: MenuTypeMenu, Order: 5},
+	)
+	gtest.C(t, func(t *gtest.T) {
+		// A menu on a cycle can be moved to the root to break it.
+		_, after, err := planMenuMoves(records, []v1.MenuMoveItem{{Id: "c1", Position: 2}})
+		t.AssertNil(err)
+		t.Assert(after, []menuPosition{
+			{Id: testMenuSystem, ParentId: "", Order: 0},
+			{Id: testMenuAbout, ParentId: "", Order: 1},
+			{Id: "c1", ParentId: "", Order: 2},
+		})
+		_, _, err = planMenuMoves(records, []v1.MenuMoveItem{{Id: "c1", Pid: "c2"}})
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+
+		// Rehoming an orphan leaves the other orphans alone.
+		_, after, err = planMenuMoves(records, []v1.MenuMoveItem{{Id: "o1", Pid: testMenuSystem, Position: 2}})
+		t.AssertNil(err)
+		t.Assert(after, []menuPosition{
+			{Id: testMenuDept, ParentId: testMenuSystem, Order: 1},
+			{Id: "o1", ParentId: testMenuSystem, Order: 2},
+		})
+	})
+}
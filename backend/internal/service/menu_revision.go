@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"backend/api/menu/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+const menuRevisionTable = "sys_menu_revision"
+
+// menuRevisionRecord is a sys_menu_revision row. Before and After hold the
+// menu as a JSON encoded v1.SystemMenuItem, empty when it did not exist.
+type menuRevisionRecord struct {
+	Id         int64       `json:"id" orm:"id"`
+	MenuId     string      `json:"menuId" orm:"menu_id"`
+	OperatorId string      `json:"operatorId" orm:"operator_id"`
+	Action     string      `json:"action" orm:"action"`
+	Before     string      `json:"before" orm:"before"`
+	After      string      `json:"after" orm:"after"`
+	CreatedAt  *gtime.Time `json:"createdAt" orm:"created_at"`
+}
+
+// menuRestoreChange is a menu a restore writes back; Menu is nil when the
+// menu is deleted.
+type menuRestoreChange struct {
+	Id   string
+	Menu *v1.SystemMenuItem
+}
+
+// Revisions returns the revisions of the caller's tenant, or of the menu
+// menuID when it is set, newest first.
+func (s *sMenu) Revisions(ctx context.Context, menuID string, page, pageSize int) (*v1.MenuRevisionListRes, error) {
+	_, domain, err := menuOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	model := g.DB().Ctx(ctx).Model(menuRevisionTable).Where("tenant_id", domain)
+	if menuID = strings.TrimSpace(menuID); menuID != "" {
+		if !isUUID(menuID) {
+			return nil, gerror.NewCodef(consts.ErrorCodeMenuNotFound, "menu %s not found", menuID)
+		}
+		model = model.Where("menu_id", menuID)
+	}
+	var (
+		records []*menuRevisionRecord
+		total   int
+	)
+	if err = model.OrderDesc("id").Page(page, pageSize).ScanAndCount(&records, &total, false); err != nil {
+		return nil, err
+	}
+	res := &v1.MenuRevisionListRes{Items: make([]*v1.MenuRevisionItem, 0, len(records)), Total: total}
+	for _, record := range records {
+		item, err := recordToMenuRevision(record)
+		if err != nil {
+			return nil, err
+		}
+		res.Items = append(res.Items, item)
+	}
+	return res, nil
+}
+
+// Restore returns the menus of the caller's tenant, or only the menu menuID
+// when it is set, to their state at point and returns the resulting menu
+// tree. Menus that did not exist at point are deleted. The restore is
+// recorded as revisions of its own, so it can be undone the same way.
+func (s *sMenu) Restore(ctx context.Context, menuID string, point v1.MenuRestorePoint) (v1.MenuListRes, error) {
+	p, domain, err := menuOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if point.RevisionId <= 0 && point.At == nil {
+		return nil, gerror.NewCode(consts.ErrorCodeMenuInvalid, "a revision id or a point in time is required")
+	}
+	if menuID = strings.TrimSpace(menuID); menuID != "" && !isUUID(menuID) {
+		return nil, gerror.NewCodef(consts.ErrorCodeMenuNotFound, "menu %s not found", menuID)
+	}
+	var tree v1.MenuListRes
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if err := lockTenantMenus(ctx, domain); err != nil {
+			return err
+		}
+		records, err := loadTenantMenus(ctx, domain)
+		if err != nil {
+			return err
+		}
+		revisions, err := loadMenuRevisionsAfter(ctx, domain, menuID, point)
+		if err != nil {
+			return err
+		}
+		changes, err := planMenuRestore(records, revisions)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err = applyMenuRestore(ctx, domain, changes); err != nil {
+				return err
+			}
+			if err = writeMenuRevisions(ctx, domain, p.UserId, "menu.restore", records); err != nil {
+				return err
+			}
+			ids := make([]string, 0, len(changes))
+			for _, change := range changes {
+				ids = append(ids, change.Id)
+			}
+			err = writeAuditLog(ctx, auditEntry{
+				TenantId:   domain,
+				OperatorId: p.UserId,
+				Action:     "menu.restore",
+				Resource:   menuTable,
+				ResourceId: menuID,
+				After:      g.Map{"revisionId": point.RevisionId, "at": point.At, "menuIds": ids},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if records, err = loadTenantMenus(ctx, domain); err != nil {
+			return err
+		}
+		tree = buildSystemMenuTree(records)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateMenuTree(ctx, domain)
+	return tree, nil
+}
+
+// lockTenantMenus locks the live menus of domain until the transaction of ctx
+// ends, so concurrent changes of the tree cannot interleave. The tenant row
+// is locked too, which serializes creates into a tree without menus; NO KEY
+// UPDATE leaves rows referencing the tenant free to be written.
+func lockTenantMenus(ctx context.Context, domain string) error {
+	if _, err := g.DB().GetAll(ctx,
+		"SELECT id FROM "+tenantTable+" WHERE id = ? FOR NO KEY UPDATE", domain); err != nil {
+		return err
+	}
+	_, err := g.DB().Ctx(ctx).Model(menuTable).
+		Fields("id").
+		Where("tenant_id", domain).
+		Where("deleted_at is null").
+		LockUpdate().
+		All()
+	return err
+}
+
+// loadMenuRevisionsAfter returns the revisions of domain, of menuID only when
+// it is set, recorded after point, oldest first.
+func loadMenuRevisionsAfter(ctx context.Context, domain, menuID string, point v1.MenuRestorePoint) ([]*menuRevisionRecord, error) {
+	model := g.DB().Ctx(ctx).Model(menuRevisionTable).Where("tenant_id", domain)
+	if point.RevisionId > 0 {
+		count, err := g.DB().Ctx(ctx).Model(menuRevisionTable).
+			Where("tenant_id", domain).
+			Where("id", point.RevisionId).
+			Count()
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, gerror.NewCodef(consts.ErrorCodeMenuNotFound, "menu revision %d not found", point.RevisionId)
+		}
+		model = model.WhereGT("id", point.RevisionId)
+	} else {
+		model = model.WhereGT("created_at", point.At)
+	}
+	if menuID != "" {
+		model = model.Where("menu_id", menuID)
+	}
+	var revisions []*menuRevisionRecord
+	err := model.OrderAsc("id").Scan(&revisions)
+	return revisions, err
+}
+
+// planMenuRestore returns the changes that bring records back to the point
+// revisions were recorded after: every menu changed since then takes the
+// Before of its first revision. A restored menu must keep a live parent that
+// is not a button, stay out of its own subtree and keep its name and path
+// unique; a deleted menu must not leave live children behind.
+func planMenuRestore(records []*menuRecord, revisions []*menuRevisionRecord) ([]*menuRestoreChange, error) {
+	menus := make(map[string]*v1.SystemMenuItem, len(records))
+	for _, record := range records {
+		menus[record.Id] = recordToSystemMenu(record)
+	}
+	var changes []*menuRestoreChange
+	seen := make(map[string]bool)
+	for _, revision := range revisions {
+		if seen[revision.MenuId] {
+			continue
+		}
+		seen[revision.MenuId] = true
+		target, err := decodeMenuSnapshot(revision.Before)
+		if err != nil {
+			return nil, err
+		}
+		if target != nil {
+			target.Id, target.Children = revision.MenuId, nil
+		}
+		current := menus[revision.MenuId]
+		if sameMenuSnapshot(current, target) {
+			continue
+		}
+		changes = append(changes, &menuRestoreChange{Id: revision.MenuId, Menu: target})
+		if target == nil {
+			delete(menus, revision.MenuId)
+		} else {
+			menus[revision.MenuId] = target
+		}
+	}
+
+	for _, change := range changes {
+		if change.Menu == nil {
+			for _, menu := range menus {
+				if menu.Pid == change.Id {
+					return nil, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "menu %s cannot be removed while %s is under it", change.Id, menu.Name)
+				}
+			}
+			continue
+		}
+		menu := change.Menu
+		if menu.Pid != "" {
+			parent := menus[menu.Pid]
+			if parent == nil {
+				return nil, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "menu %s needs its parent %s, which does not exist", menu.Name, menu.Pid)
+			}
+			if parent.Type == MenuTypeButton {
+				return nil, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "button %s cannot have children", parent.Name)
+			}
+		}
+		visited := map[string]bool{}
+		for ancestor := menu.Pid; ancestor != "" && !visited[ancestor]; {
+			if ancestor == change.Id {
+				return nil, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "menu %s cannot be moved under itself", menu.Name)
+			}
+			visited[ancestor] = true
+			parent := menus[ancestor]
+			if parent == nil {
+				break
+			}
+			ancestor = parent.Pid
+		}
+		for id, other := range menus {
+			if id == change.Id {
+				continue
+			}
+			if other.Name == menu.Name {
+				return nil, gerror.NewCodef(consts.ErrorCodeMenuExists, "menu name %s already exists", menu.Name)
+			}
+			if menu.Path != "" && other.Path == menu.Path {
+				return nil, gerror.NewCodef(consts.ErrorCodeMenuExists, "menu path %s already exists", menu.Path)
+			}
+			if menu.Type == MenuTypeButton && other.Pid == change.Id {
+				return nil, gerror.NewCodef(consts.ErrorCodeMenuInvalid, "menu %s has children and cannot become a button", menu.Name)
+			}
+		}
+	}
+	return changes, nil
+}
+
+// applyMenuRestore writes changes: deleted menus are soft deleted and the
+// others are written back, undeleting them when needed.
+func applyMenuRestore(ctx context.Context, domain string, changes []*menuRestoreChange) error {
+	now := gtime.Now()
+	for _, change := range changes {
+		if change.Menu == nil {
+			_, err := g.DB().Ctx(ctx).Model(menuTable).
+				Data(g.Map{"deleted_at": now}).
+				Where("tenant_id", domain).
+				Where("id", change.Id).
+				Update()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		menu := change.Menu
+		data := menuData(v1.MenuInput{
+			Pid:       menu.Pid,
+			Name:      menu.Name,
+			Path:      menu.Path,
+			Component: menu.Component,
+			Type:      menu.Type,
+			Status:    menu.Status,
+			AuthCode:  menu.AuthCode,
+			Meta:      menu.Meta,
+		})
+		data["id"] = change.Id
+		data["tenant_id"] = domain
+		data["updated_at"] = now
+		data["deleted_at"] = nil
+		if _, err := g.DB().Ctx(ctx).Model(menuTable).Data(data).OnConflict("id").Save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMenuRevisions records a revision, attributed to operatorID, for every
+// menu of domain created, changed or deleted since before was loaded in the
+// same transaction.
+func writeMenuRevisions(ctx context.Context, domain, operatorID, action string, before []*menuRecord) error {
+	after, err := loadTenantMenus(ctx, domain)
+	if err != nil {
+		return err
+	}
+	rows := menuRevisionRows(domain, operatorID, action, before, after)
+	if len(rows) == 0 {
+		return nil
+	}
+	_, err = g.DB().Ctx(ctx).Model(menuRevisionTable).Data(rows).Insert()
+	return err
+}
+
+// menuRevisionRows returns the sys_menu_revision rows for the menus that
+// differ between before and after: changed and created menus in the order of
+// after, then deleted menus in the order of before.
+func menuRevisionRows(domain, operatorID, action string, before, after []*menuRecord) g.List {
+	previous := make(map[string]*menuRecord, len(before))
+	for _, record := range before {
+		previous[record.Id] = record
+	}
+	current := make(map[string]bool, len(after))
+	var rows g.List
+	row := func(id string, from, to *menuRecord) {
+		data := g.Map{
+			"tenant_id": domain,
+			"menu_id":   id,
+			"action":    action,
+			"before":    nil,
+			"after":     nil,
+		}
+		if from != nil {
+			data["before"] = auditJSON(recordToSystemMenu(from))
+		}
+		if to != nil {
+			data["after"] = auditJSON(recordToSystemMenu(to))
+		}
+		if isUUID(operatorID) {
+			data["operator_id"] = operatorID
+		}
+		rows = append(rows, data)
+	}
+	for _, record := range after {
+		current[record.Id] = true
+		if from := previous[record.Id]; from == nil || *from != *record {
+			row(record.Id, from, record)
+		}
+	}
+	for _, record := range before {
+		if !current[record.Id] {
+			row(record.Id, record, nil)
+		}
+	}
+	return rows
+}
+
+func recordToMenuRevision(record *menuRevisionRecord) (*v1.MenuRevisionItem, error) {
+	item := &v1.MenuRevisionItem{
+		Id:         record.Id,
+		MenuId:     record.MenuId,
+		Action:     record.Action,
+		OperatorId: record.OperatorId,
+		CreatedAt:  record.CreatedAt,
+	}
+	var err error
+	if item.Before, err = decodeMenuSnapshot(record.Before); err != nil {
+		return nil, err
+	}
+	if item.After, err = decodeMenuSnapshot(record.After); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// decodeMenuSnapshot decodes the before or after column of a revision, nil
+// when the menu did not exist.
+func decodeMenuSnapshot(value string) (*v1.SystemMenuItem, error) {
+	if value == "" || value == "null" {
+		return nil, nil
+	}
+	var item v1.SystemMenuItem
+	if err := json.Unmarshal([]byte(value), &item); err != nil {
+		return nil, gerror.WrapCode(consts.ErrorCodeMenuInvalid, err, "malformed menu revision")
+	}
+	return &item, nil
+}
+
+// sameMenuSnapshot reports whether a and b describe the same menu state.
+func sameMenuSnapshot(a, b *v1.SystemMenuItem) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"testing"
+
+	"backend/api/menu/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+const testMenuReport = "20000000-0000-0000-0000-000000000015"
+
+// testMenuRevisions returns the revisions recorded for the change from
+// before to after, numbered from id.
+func testMenuRevisions(id int64, before, after []*menuRecord) []*menuRevisionRecord {
+	var revisions []*menuRevisionRecord
+	for _, row := range menuRevisionRows(consts.DefaultTenantId, "", "test", before, after) {
+		revisions = append(revisions, &menuRevisionRecord{
+			Id:     id,
+			MenuId: gconv.String(row["menu_id"]),
+			Action: gconv.String(row["action"]),
+			Before: gconv.String(row["before"]),
+			After:  gconv.String(row["after"]),
+		})
+		id++
+	}
+	return revisions
+}
+
+// applyTestMenuRestore returns records with changes applied, as menu items by id.
+func applyTestMenuRestore(records []*menuRecord, changes []*menuRestoreChange) map[string]*v1.SystemMenuItem {
+	menus := make(map[string]*v1.SystemMenuItem, len(records))
+	for _, record := range records {
+		menus[record.Id] = recordToSystemMenu(record)
+	}
+	for _, change := range changes {
+		if change.Menu == nil {
+			delete(menus, change.Id)
+			continue
+		}
+		menus[change.Id] = change.Menu
+	}
+	return menus
+}
+
+// testMenuChange returns a copy of records in which change has updated the
+// menu id, or removed it by returning nil.
+func testMenuChange(records []*menuRecord, id string, change func(*menuRecord) *menuRecord) []*menuRecord {
+	result := make([]*menuRecord, 0, len(records))
+	for _, record := range records {
+		copied := *record
+		if record.Id != id {
+			result = append(result, &copied)
+			continue
+		}
+		if changed := change(&copied); changed != nil {
+			result = append(result, changed)
+		}
+	}
+	return result
+}
+
+func TestMenuRevisionRows(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		before := testMenuRecords()
+		after := testMenuChange(before, testMenuDept, func(record *menuRecord) *menuRecord {
+			record.Status = 1
+			return record
+		})
+		after = testMenuChange(after, testMenuAbout, func(*menuRecord) *menuRecord { return nil })
+		after = append(after, &menuRecord{Id: testMenuReport, Name: "Report", Path: "/report", Type: MenuTypeMenu, Visible: 1, Status: 1})
+
+		rows := menuRevisionRows(consts.DefaultTenantId, "10000000-0000-0000-0000-000000000001", "menu.test", before, after)
+		t.Assert(len(rows), 3)
+		t.Assert(rows[0]["menu_id"], testMenuDept)
+		t.Assert(rows[1]["menu_id"], testMenuReport)
+		t.Assert(rows[2]["menu_id"], testMenuAbout)
+		for _, row := range rows {
+			t.Assert(row["action"], "menu.test")
+			t.Assert(row["tenant_id"], consts.DefaultTenantId)
+			t.Assert(row["operator_id"], "10000000-0000-0000-0000-000000000001")
+		}
+
+		from, err := decodeMenuSnapshot(gconv.String(rows[0]["before"]))
+		t.AssertNil(err)
+		to, err := decodeMenuSnapshot(gconv.String(rows[0]["after"]))
+		t.AssertNil(err)
+		t.Assert(from.Status, 0)
+		t.Assert(to.Status, 1)
+		t.Assert(to.Pid, testMenuSystem)
+		t.Assert(rows[1]["before"], nil)
+		t.Assert(rows[2]["after"], nil)
+
+		// Operators outside the user table, such as the CLI, are not recorded.
+		t.Assert(menuRevisionRows(consts.DefaultTenantId, "", "menu.test", before, after)[0]["operator_id"], nil)
+		t.Assert(len(menuRevisionRows(consts.DefaultTenantId, "", "menu.test", before, testMenuRecords())), 0)
+	})
+}
+
+func TestPlanMenuRestore(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		// Move Dept to the root, rename it, delete About and add Report,
+		// then restore the tree as it was before.
+		original := testMenuRecords()
+		moved := testMenuChange(original, testMenuDept, func(record *menuRecord) *menuRecord {
+			record.ParentId, record.Order = "", 3
+			return record
+		})
+		revisions := testMenuRevisions(1, original, moved)
+		renamed := testMenuChange(moved, testMenuDept, func(record *menuRecord) *menuRecord {
+			record.Name, record.Path = "Department", "/department"
+			return record
+		})
+		renamed = testMenuChange(renamed, testMenuAbout, func(*menuRecord) *menuRecord { return nil })
+		renamed = append(renamed, &menuRecord{Id: testMenuReport, Name: "Report", Path: "/report", Type: MenuTypeMenu, Visible: 1, Status: 1})
+		revisions = append(revisions, testMenuRevisions(10, moved, renamed)...)
+
+		changes, err := planMenuRestore(renamed, revisions)
+		t.AssertNil(err)
+		t.Assert(len(changes), 3)
+		restored := applyTestMenuRestore(renamed, changes)
+		t.Assert(len(restored), len(original))
+		for _, record := range original {
+			t.Assert(sameMenuSnapshot(restored[record.Id], recordToSystemMenu(record)), true)
+		}
+
+		// Restoring to revision 1 only undoes the later revisions.
+		changes, err = planMenuRestore(renamed, revisions[1:])
+		t.AssertNil(err)
+		restored = applyTestMenuRestore(renamed, changes)
+		t.Assert(restored[testMenuDept].Name, "SystemDept")
+		t.Assert(restored[testMenuDept].Pid, "")
+		t.AssertNE(restored[testMenuAbout], nil)
+		t.Assert(restored[testMenuReport], nil)
+
+		// Dept was moved and moved back since the point: nothing to write.
+		revisions = append(testMenuRevisions(1, original, moved), testMenuRevisions(2, moved, original)...)
+		changes, err = planMenuRestore(original, revisions)
+		t.AssertNil(err)
+		t.Assert(len(changes), 0)
+	})
+}
+
+func TestPlanMenuRestore_Invalid(t *testing.T) {
+	original := testMenuRecords()
+	revisionsOf := func(menuID string, revisions []*menuRevisionRecord) []*menuRevisionRecord {
+		var result []*menuRevisionRecord
+		for _, revision := range revisions {
+			if revision.MenuId == menuID {
+				result = append(result, revision)
+			}
+		}
+		return result
+	}
+	gtest.C(t, func(t *gtest.T) {
+		// System and its subtree were deleted; SystemMenu alone has no parent.
+		deleted := []*menuRecord{original[4]}
+		_, err := planMenuRestore(deleted, revisionsOf(testMenuMenu, testMenuRevisions(1, original, deleted)))
+		t.AssertNE(err, nil)
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+
+		// Report was created with a child; removing Report alone orphans it.
+		created := append(testMenuRecords(),
+			&menuRecord{Id: testMenuReport, Name: "Report", Path: "/report", Type: MenuTypeCatalog, Visible: 1, Status: 1},
+			&menuRecord{Id: "20000000-0000-0000-0000-000000000016", ParentId: testMenuReport, Name: "ReportDaily", Path: "/report/daily", Type: MenuTypeMenu, Visible: 1, Status: 1},
+		)
+		_, err = planMenuRestore(created, revisionsOf(testMenuReport, testMenuRevisions(1, original, created)))
+		t.AssertNE(err, nil)
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuInvalid)
+
+		// Dept was renamed and another menu took its old name since.
+		renamed := testMenuChange(original, testMenuDept, func(record *menuRecord) *menuRecord {
+			record.Name = "Department"
+			return record
+		})
+		revisions := testMenuRevisions(1, original, renamed)
+		taken := append(append([]*menuRecord{}, renamed...), &menuRecord{Id: testMenuReport, Name: "SystemDept", Type: MenuTypeMenu, Path: "/report", Visible: 1, Status: 1})
+		revisions = append(revisions, testMenuRevisions(2, renamed, taken)...)
+		_, err = planMenuRestore(taken, revisionsOf(testMenuDept, revisions))
+		t.AssertNE(err, nil)
+		t.Assert(gerror.Code(err), consts.ErrorCodeMenuExists)
+
+		// A malformed revision is reported rather than written back.
+		_, err = planMenuRestore(original, []*menuRevisionRecord{{Id: 1, MenuId: testMenuDept, Before: "{"}})
+		t.AssertNE(err, nil)
+	})
+}
+
+func TestRecordToMenuRevision(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		rows := menuRevisionRows(consts.DefaultTenantId, "", "menu.create", nil, testMenuRecords()[:1])
+		var record *menuRevisionRecord
+		t.AssertNil(gconv.Struct(g.Map{
+			"id": 7, "menuId": rows[0]["menu_id"], "action": rows[0]["action"], "after": rows[0]["after"],
+		}, &record))
+		item, err := recordToMenuRevision(record)
+		t.AssertNil(err)
+		t.Assert(item.Id, 7)
+		t.Assert(item.Before, nil)
+		t.Assert(item.After.Name, "System")
+		t.Assert(item.After.Meta.Title, "system.title")
+	})
+}
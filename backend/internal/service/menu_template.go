@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	menuv1 "backend/api/menu/v1"
+	"backend/api/platform/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+const (
+	menuTemplateSyncTable = "sys_menu_template_sync"
+
+	// menuTemplateSetting is the sys_setting key naming the tenant whose
+	// menus make up the menu template, the default tenant when unset.
+	menuTemplateSetting = "menu.template_tenant"
+)
+
+// Template sync actions reported in MenuImportRes.Kept.
+const (
+	// menuTemplateKeep marks a menu whose customized fields are kept.
+	menuTemplateKeep = "keep"
+	// menuTemplateSkip marks a template menu the tenant removed, or whose
+	// parent it removed, which is not added back.
+	menuTemplateSkip = "skip"
+)
+
+// SyncTenantMenus pushes the menu template to a tenant. Template menus are
+// tracked by name. A field or meta key the tenant left as it was at the last
+// sync takes the template's value; one it changed is kept and reported.
+// Template menus the tenant removed are not added back, and menus of the
+// tenant outside the template are left alone. A tenant that was never synced
+// keeps every value that differs from the template.
+func (s *sPlatform) SyncTenantMenus(ctx context.Context, in v1.TenantMenuSyncReq) (*menuv1.MenuImportRes, error) {
+	p, err := requirePlatformOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := findTenant(ctx, in.Id)
+	if err != nil {
+		return nil, err
+	}
+	var res *menuv1.MenuImportRes
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		// Hold the tenant's tree so the merge still matches its menus, and its
+		// sync base, when the changes are applied.
+		if err := lockTenantMenus(ctx, tenant.Id); err != nil {
+			return err
+		}
+		templateID, err := menuTemplateTenant(ctx)
+		if err != nil {
+			return err
+		}
+		if templateID == tenant.Id {
+			return gerror.NewCodef(consts.ErrorCodeMenuInvalid, "tenant %s is the menu template", tenant.Id)
+		}
+		template, err := tenantMenuBundle(ctx, templateID)
+		if err != nil {
+			return err
+		}
+		base, err := loadMenuTemplateBase(ctx, tenant.Id)
+		if err != nil {
+			return err
+		}
+		records, err := loadTenantMenus(ctx, tenant.Id)
+		if err != nil {
+			return err
+		}
+		items, kept, err := mergeMenuTemplate(records, base, template)
+		if err != nil {
+			return err
+		}
+		res = planMenuItems(records, items)
+		res.DryRun = in.DryRun
+		res.Kept = kept
+		if in.DryRun || len(res.Conflicts) > 0 {
+			return nil
+		}
+		if len(res.Changes) > 0 {
+			if err = applyMenuImport(ctx, tenant.Id, records, items); err != nil {
+				return err
+			}
+			if err = writeMenuRevisions(ctx, tenant.Id, p.UserId, "menu.sync", records); err != nil {
+				return err
+			}
+			res.Applied = true
+		}
+		if err = saveMenuTemplateBase(ctx, tenant.Id, templateID, template); err != nil {
+			return err
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   consts.PlatformDomain,
+			OperatorId: p.UserId,
+			Action:     "tenant.menu_sync",
+			Resource:   menuTable,
+			ResourceId: tenant.Id,
+			After:      g.Map{"changes": res.Changes, "kept": res.Kept},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Applied {
+		invalidateMenuTree(ctx, tenant.Id)
+	}
+	return res, nil
+}
+
+// provisionTenantMenus clones the menu template into the new tenant tenantID
+// on behalf of operatorID and records it as the tenant's last sync.
+func provisionTenantMenus(ctx context.Context, tenantID, operatorID string) error {
+	templateID, err := menuTemplateTenant(ctx)
+	if err != nil || templateID == tenantID {
+		return err
+	}
+	template, err := tenantMenuBundle(ctx, templateID)
+	if err != nil || len(template.Menus) == 0 {
+		return err
+	}
+	items, err := flattenMenuBundle(template.Menus, "", nil)
+	if err != nil {
+		return err
+	}
+	if res := planMenuItems(nil, items); len(res.Conflicts) > 0 {
+		return gerror.NewCodef(consts.ErrorCodeMenuInvalid, "menu template %s: %s %s", templateID, res.Conflicts[0].Name, res.Conflicts[0].Reason)
+	}
+	if err = applyMenuImport(ctx, tenantID, nil, items); err != nil {
+		return err
+	}
+	if err = writeMenuRevisions(ctx, tenantID, operatorID, "menu.provision", nil); err != nil {
+		return err
+	}
+	return saveMenuTemplateBase(ctx, tenantID, templateID, template)
+}
+
+// menuTemplateTenant returns the tenant the menu template is read from.
+func menuTemplateTenant(ctx context.Context) (string, error) {
+	value, err := g.DB().Ctx(ctx).Model(settingTable).Where("key", menuTemplateSetting).Value("value")
+	if err != nil {
+		return "", err
+	}
+	if value.IsEmpty() {
+		return consts.DefaultTenantId, nil
+	}
+	var tenantID string
+	if err = json.Unmarshal(value.Bytes(), &tenantID); err != nil || !isUUID(tenantID) {
+		return "", gerror.NewCodef(consts.ErrorCodeSettingInvalid, "setting %s must be a tenant id", menuTemplateSetting)
+	}
+	return tenantID, nil
+}
+
+// tenantMenuBundle returns the live menus of tenantID as a bundle.
+func tenantMenuBundle(ctx context.Context, tenantID string) (*menuv1.MenuBundle, error) {
+	records, err := loadTenantMenus(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return menuBundleFromTree(buildSystemMenuTree(records)), nil
+}
+
+// loadMenuTemplateBase returns the template as last synced to tenantID, or
+// nil if it never was.
+func loadMenuTemplateBase(ctx context.Context, tenantID string) (*menuv1.MenuBundle, error) {
+	value, err := g.DB().Ctx(ctx).Model(menuTemplateSyncTable).Where("tenant_id", tenantID).Value("bundle")
+	if err != nil || value.IsEmpty() {
+		return nil, err
+	}
+	var bundle menuv1.MenuBundle
+	if err = json.Unmarshal(value.Bytes(), &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// saveMenuTemplateBase records template as synced to tenantID.
+func saveMenuTemplateBase(ctx context.Context, tenantID, templateID string, template *menuv1.MenuBundle) error {
+	encoded, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	_, err = g.DB().Ctx(ctx).Model(menuTemplateSyncTable).Data(g.Map{
+		"tenant_id":          tenantID,
+		"template_tenant_id": templateID,
+		"bundle":             string(encoded),
+		"synced_at":          gtime.Now(),
+	}).OnConflict("tenant_id").Save()
+	return err
+}
+
+// mergeMenuTemplate returns the import items that bring records in line with
+// template, keeping what the tenant changed since base, and the changes it
+// keeps. base is nil for a tenant that was never synced.
+func mergeMenuTemplate(records []*menuRecord, base, template *menuv1.MenuBundle) ([]*menuImportItem, []*menuv1.MenuImportChange, error) {
+	next, err := flattenMenuBundle(template.Menus, "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous := make(map[string]*menuImportItem)
+	if base != nil {
+		items, err := flattenMenuBundle(base.Menus, "", nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, item := range items {
+			previous[item.in.Name] = item
+		}
+	}
+	byID := make(map[string]*menuRecord, len(records))
+	byName := make(map[string]*menuRecord, len(records))
+	byPath := make(map[string]*menuRecord, len(records))
+	// present holds the menu names the tenant has once the items are applied,
+	// so children are only placed under parents that exist.
+	present := make(map[string]bool, len(records))
+	for _, record := range records {
+		byID[record.Id] = record
+		byName[record.Name] = record
+		if record.Path != "" {
+			byPath[record.Path] = record
+		}
+		present[record.Name] = true
+	}
+
+	var (
+		items []*menuImportItem
+		kept  []*menuv1.MenuImportChange
+	)
+	for _, item := range next {
+		current := byName[item.in.Name]
+		if current == nil && item.in.Path != "" {
+			current = byPath[item.in.Path]
+		}
+		if current == nil {
+			if previous[item.in.Name] != nil || (item.parent != "" && !present[item.parent]) {
+				kept = append(kept, &menuv1.MenuImportChange{Action: menuTemplateSkip, Name: item.in.Name, Path: item.in.Path})
+				continue
+			}
+			items = append(items, item)
+			present[item.in.Name] = true
+			continue
+		}
+
+		mine := &menuImportItem{in: recordToMenuInput(current)}
+		mine.in.Pid = ""
+		if parent := byID[current.ParentId]; parent != nil {
+			mine.parent = parent.Name
+		}
+		merged, fields := mergeMenuItem(mine, previous[item.in.Name], item)
+		if merged.parent != "" && !present[merged.parent] {
+			merged.parent = mine.parent
+		}
+		if len(fields) > 0 {
+			kept = append(kept, &menuv1.MenuImportChange{Action: menuTemplateKeep, Name: merged.in.Name, Path: merged.in.Path, Fields: fields})
+		}
+		items = append(items, merged)
+		present[merged.in.Name] = true
+	}
+	return items, kept, nil
+}
+
+// mergeMenuItem merges the template menu next into the tenant's menu mine.
+// previous is next as last synced, nil if unknown. It returns the merged
+// menu and the fields where mine is kept over a different next.
+func mergeMenuItem(mine, previous, next *menuImportItem) (*menuImportItem, []*menuv1.MenuFieldChange) {
+	var kept []*menuv1.MenuFieldChange
+	synced := previous != nil
+	if !synced {
+		previous = &menuImportItem{}
+	}
+	merged := &menuImportItem{in: mine.in}
+	merged.parent = mergeMenuField(&kept, "parent", synced, mine.parent, previous.parent, next.parent)
+	merged.in.Name = mergeMenuField(&kept, "name", synced, mine.in.Name, previous.in.Name, next.in.Name)
+	merged.in.Path = mergeMenuField(&kept, "path", synced, mine.in.Path, previous.in.Path, next.in.Path)
+	merged.in.Component = mergeMenuField(&kept, "component", synced, mine.in.Component, previous.in.Component, next.in.Component)
+	merged.in.Type = mergeMenuField(&kept, "type", synced, mine.in.Type, previous.in.Type, next.in.Type)
+	merged.in.Status = mergeMenuField(&kept, "status", synced, mine.in.Status, previous.in.Status, next.in.Status)
+	merged.in.AuthCode = mergeMenuField(&kept, "authCode", synced, mine.in.AuthCode, previous.in.AuthCode, next.in.AuthCode)
+
+	// Meta is merged key by key, so a tenant's own title survives a new
+	// template icon.
+	mineMeta, previousMeta, nextMeta := menuMetaFields(mine.in.Meta), menuMetaFields(previous.in.Meta), menuMetaFields(next.in.Meta)
+	keys := make([]string, 0, len(mineMeta)+len(nextMeta))
+	for _, fields := range []map[string]json.RawMessage{mineMeta, previousMeta, nextMeta} {
+		for key := range fields {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	meta := make(map[string]json.RawMessage)
+	var keptMeta []*menuv1.MenuFieldChange
+	for _, key := range slices.Compact(keys) {
+		value := mergeMenuField(&keptMeta, "meta."+key, synced, string(mineMeta[key]), string(previousMeta[key]), string(nextMeta[key]))
+		if value != "" {
+			meta[key] = json.RawMessage(value)
+		}
+	}
+	for _, change := range keptMeta {
+		change.From, change.To = rawMenuMetaValue(change.From.(string)), rawMenuMetaValue(change.To.(string))
+	}
+	kept = append(kept, keptMeta...)
+	merged.in.Meta = nil
+	if len(meta) > 0 {
+		encoded, _ := json.Marshal(meta)
+		merged.in.Meta = &menuv1.MenuMeta{}
+		_ = json.Unmarshal(encoded, merged.in.Meta)
+	}
+	return merged, kept
+}
+
+// mergeMenuField returns next if mine is unchanged since previous, else mine,
+// recording it in kept when it differs from next.
+func mergeMenuField[T comparable](kept *[]*menuv1.MenuFieldChange, field string, synced bool, mine, previous, next T) T {
+	if synced && mine == previous {
+		return next
+	}
+	if mine != next {
+		*kept = append(*kept, &menuv1.MenuFieldChange{Field: field, From: mine, To: next})
+	}
+	return mine
+}
+
+// rawMenuMetaValue reports an encoded meta value as JSON, null when unset.
+func rawMenuMetaValue(value string) json.RawMessage {
+	if value == "" {
+		return nil
+	}
+	return json.RawMessage(value)
+}
+
+// menuMetaFields returns the encoded meta keys of meta.
+func menuMetaFields(meta *menuv1.MenuMeta) map[string]json.RawMessage {
+	fields := make(map[string]json.RawMessage)
+	if meta == nil {
+		return fields
+	}
+	if encoded, err := json.Marshal(meta); err == nil {
+		_ = json.Unmarshal(encoded, &fields)
+	}
+	return fields
+}
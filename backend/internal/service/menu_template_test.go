@@ -0,0 +1,121 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	menuv1 "backend/api/menu/v1"
+
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+const testMenuLog = "20000000-0000-0000-0000-000000000015"
+
+func findBundleItem(items []*menuv1.MenuBundleItem, name string) *menuv1.MenuBundleItem {
+	for _, item := range items {
+		if item.Name == name {
+			return item
+		}
+		if found := findBundleItem(item.Children, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func cloneMenuBundle(t *gtest.T, bundle *menuv1.MenuBundle) *menuv1.MenuBundle {
+	encoded, err := json.Marshal(bundle)
+	t.AssertNil(err)
+	var clone menuv1.MenuBundle
+	t.AssertNil(json.Unmarshal(encoded, &clone))
+	return &clone
+}
+
+func TestMergeMenuTemplate(t *testing.T) {
+	logRecord := &menuRecord{Id: testMenuLog, ParentId: testMenuSystem, Name: "SystemLog", Path: "/system/log", Component: "/system/log/list", Type: MenuTypeMenu, Visible: 1, Status: 1, Order: 3}
+	gtest.C(t, func(t *gtest.T) {
+		base := menuBundleFromTree(buildSystemMenuTree(append(testMenuRecords(), logRecord)))
+
+		// The template renames SystemMenu's title, swaps its icon, moves
+		// About to v2, enables SystemDept and adds SystemUser.
+		template := cloneMenuBundle(t, base)
+		systemMenu := findBundleItem(template.Menus, "SystemMenu")
+		systemMenu.Meta.Title = "system.menu.v2"
+		systemMenu.Meta.Icon = "carbon:list"
+		findBundleItem(template.Menus, "About").Component = "/about/v2"
+		findBundleItem(template.Menus, "SystemDept").Status = 1
+		system := findBundleItem(template.Menus, "System")
+		system.Children = append(system.Children, &menuv1.MenuBundleItem{
+			Name: "SystemUser", Path: "/system/user", Component: "/system/user/list", Type: MenuTypeMenu, Status: 1,
+		})
+
+		// The tenant retitled SystemMenu, points About at its own page and
+		// deleted SystemLog.
+		records := testMenuRecords()
+		records[1].Meta = `{"title":"my.menu"}`
+		records[4].Component = "/custom/about"
+
+		items, kept, err := mergeMenuTemplate(records, base, template)
+		t.AssertNil(err)
+		res := planMenuItems(records, items)
+		t.Assert(len(res.Conflicts), 0)
+
+		changes := make(map[string]*menuv1.MenuImportChange)
+		for _, change := range res.Changes {
+			changes[change.Name] = change
+		}
+		t.Assert(len(changes), 3)
+		t.Assert(changes["SystemUser"].Action, menuImportCreate)
+		t.Assert(changes["SystemDept"].Fields, []*menuv1.MenuFieldChange{{Field: "status", From: 0, To: 1}})
+		t.Assert(changes["SystemMenu"].Fields[0].Field, "icon")
+		t.Assert(changes["SystemMenu"].Fields[0].To, "carbon:list")
+		for _, item := range items {
+			if item.in.Name == "SystemMenu" {
+				t.Assert(item.in.Meta.Title, "my.menu")
+				t.Assert(item.in.Meta.Icon, "carbon:list")
+			}
+			if item.in.Name == "About" {
+				t.Assert(item.in.Component, "/custom/about")
+			}
+		}
+
+		t.Assert(len(kept), 3)
+		t.Assert(kept[0].Action, menuTemplateKeep)
+		t.Assert(kept[0].Name, "SystemMenu")
+		t.Assert(kept[0].Fields[0].Field, "meta.title")
+		t.Assert(kept[0].Fields[0].From, json.RawMessage(`"my.menu"`))
+		t.Assert(kept[0].Fields[0].To, json.RawMessage(`"system.menu.v2"`))
+		t.Assert(kept[1].Name, "SystemLog")
+		t.Assert(kept[1].Action, menuTemplateSkip)
+		t.Assert(kept[2].Name, "About")
+		t.Assert(kept[2].Fields, []*menuv1.MenuFieldChange{{Field: "component", From: "/custom/about", To: "/about/v2"}})
+	})
+}
+
+func TestMergeMenuTemplate_NeverSynced(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		template := menuBundleFromTree(buildSystemMenuTree(testMenuRecords()))
+		findBundleItem(template.Menus, "SystemMenu").Meta.Icon = "carbon:list"
+		template.Menus = append(template.Menus, &menuv1.MenuBundleItem{
+			Name: "Docs", Path: "/docs", Type: MenuTypeCatalog, Status: 1,
+			Children: []*menuv1.MenuBundleItem{{Name: "Guide", Path: "/docs/guide", Component: "/docs/guide", Type: MenuTypeMenu, Status: 1}},
+		})
+
+		// Without a base every difference counts as the tenant's own.
+		items, kept, err := mergeMenuTemplate(testMenuRecords(), nil, template)
+		t.AssertNil(err)
+		res := planMenuItems(testMenuRecords(), items)
+		t.Assert(res.Created, 2)
+		t.Assert(res.Updated, 0)
+		t.Assert(len(kept), 1)
+		t.Assert(kept[0].Fields[0].Field, "meta.icon")
+
+		// A new tenant receives the template as is.
+		items, kept, err = mergeMenuTemplate(nil, nil, template)
+		t.AssertNil(err)
+		t.Assert(len(kept), 0)
+		res = planMenuItems(nil, items)
+		t.Assert(res.Created, 7)
+		t.Assert(len(res.Conflicts), 0)
+	})
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"backend/api/menu/v1"
@@ -9,8 +10,17 @@ import (
 	"github.com/gogf/gf/v2/test/gtest"
 )
 
+// withAccessCodes returns ctx carrying a principal of tenant whose access
+// codes are already resolved to codes.
+func withAccessCodes(ctx context.Context, tenant string, codes ...string) context.Context {
+	p := &Principal{UserId: "u1", TenantId: tenant, Roles: []string{"staff"}}
+	p.accessCodesOnce.Do(func() { p.accessCodes = codes })
+	return WithPrincipal(ctx, p)
+}
+
 func TestMenu_All(t *testing.T) {
-	ctx := context.TODO()
+	// A tenant without stored menus is served the built-in menus.
+	ctx := withAccessCodes(context.TODO(), "t1", "System:Menu:List")
 	gtest.C(t, func(t *gtest.T) {
 		menus, err := Menu().All(ctx)
 		t.AssertNil(err)
@@ -19,6 +29,225 @@ func TestMenu_All(t *testing.T) {
 		workspace := findMenuByPath(menus, "/workspace")
 		t.AssertNE(workspace, nil)
 		t.Assert(workspace.Component, "/dashboard/workspace/index")
+
+		t.AssertNE(findMenuByPath(menus, "/system/menu"), nil)
+		t.Assert(findMenuByPath(menus, "/system/dept"), nil)
+	})
+}
+
+func TestFilterMenusByCodes(t *testing.T) {
+	stubRoleMenuCodes(t, map[string][]string{
+		"admin":   {"System:Menu:List", "System:Dept:List", "Report:View"},
+		"staff":   {"System:Menu:List"},
+		"auditor": {"Audit:View"},
+	})
+	cache := newTestEnforcerCache(t, 10, newMemoryRules(
+		"p, admin, t1, /*, .*, allow",
+		"p, staff, t1, /system/menu/*, get, allow",
+		"p, auditor, t1, Report:View, get, allow",
+		"g, lead, staff, t1",
+	))
+	enforcer, err := cache.Get("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := func() []*v1.MenuItem {
+		return []*v1.MenuItem{
+			{Name: "Dashboard", Path: "/dashboard", Type: "catalog", Children: []*v1.MenuItem{
+				{Name: "Workspace", Path: "/workspace", Type: "menu"},
+			}},
+			{Name: "System", Path: "/system", Type: "catalog", Children: []*v1.MenuItem{
+				{Name: "SystemMenu", Path: "/system/menu", Type: "menu", AuthCode: "System:Menu:List"},
+				{Name: "SystemDept", Path: "/system/dept", Type: "menu", AuthCode: "System:Dept:List"},
+			}},
+			{Name: "Reports", Path: "/reports", Type: "catalog", AuthCode: "Report:View", Children: []*v1.MenuItem{
+				{Name: "Monthly", Path: "/reports/monthly", Type: "menu"},
+			}},
+			{Name: "Audit", Path: "/audit", Type: "catalog", Children: []*v1.MenuItem{
+				{Name: "AuditLog", Path: "/audit/log", Type: "menu", AuthCode: "Audit:View", Meta: &v1.MenuMeta{MenuVisibleWithForbidden: true}},
+				{Name: "AuditExport", Path: "/audit/export", Type: "menu", AuthCode: "Audit:Export"},
+			}},
+			{Name: "Empty", Path: "/empty", Type: "catalog"},
+		}
+	}
+	names := func(items []*v1.MenuItem) []string {
+		var result []string
+		var walk func([]*v1.MenuItem)
+		walk = func(items []*v1.MenuItem) {
+			for _, item := range items {
+				result = append(result, item.Name)
+				walk(item.Children)
+			}
+		}
+		walk(items)
+		return result
+	}
+
+	testCases := []struct {
+		name     string
+		subjects []string
+		want     []string
+	}{
+		{
+			name:     "admin",
+			subjects: []string{"u1", "admin"},
+			want:     []string{"Dashboard", "Workspace", "System", "SystemMenu", "SystemDept", "Reports", "Monthly", "Audit", "AuditLog"},
+		},
+		{
+			name:     "staff",
+			subjects: []string{"u2", "staff"},
+			want:     []string{"Dashboard", "Workspace", "System", "SystemMenu", "Audit", "AuditLog"},
+		},
+		{
+			name:     "role inherited through g",
+			subjects: []string{"u3", "lead"},
+			want:     []string{"Dashboard", "Workspace", "System", "SystemMenu", "Audit", "AuditLog"},
+		},
+		{
+			name:     "staff and auditor",
+			subjects: []string{"u4", "staff", "auditor"},
+			want:     []string{"Dashboard", "Workspace", "System", "SystemMenu", "Reports", "Monthly", "Audit", "AuditLog"},
+		},
+		{
+			name:     "no grants",
+			subjects: []string{"u5", "guest"},
+			want:     []string{"Dashboard", "Workspace", "Audit", "AuditLog"},
+		},
+	}
+	gtest.C(t, func(t *gtest.T) {
+		for _, c := range testCases {
+			codes, err := accessCodes(context.Background(), enforcer, "t1", c.subjects)
+			t.AssertNil(err)
+			source := tree()
+			t.Assert(names(filterMenusByCodes(source, codes)), c.want)
+			// The source tree is left untouched.
+			t.Assert(len(source[1].Children), 2)
+		}
+	})
+}
+
+func TestDefaultMenuList_IDs(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		seen := make(map[string]bool)
+		var walk func(items []*v1.MenuItem, pid string)
+		walk = func(items []*v1.MenuItem, pid string) {
+			for _, item := range items {
+				t.AssertNE(item.Id, "")
+				t.Assert(seen[item.Id], false)
+				seen[item.Id] = true
+				t.Assert(item.Pid, pid)
+				walk(item.Children, item.Id)
+			}
+		}
+		walk(defaultMenuList(), "")
+	})
+}
+
+func TestBuildMenuTree_IDs(t *testing.T) {
+	var records []menuRecord
+	for _, record := range testMenuRecords() {
+		if record.Status == 1 {
+			records = append(records, *record)
+		}
+	}
+	gtest.C(t, func(t *gtest.T) {
+		tree := buildMenuTree(records)
+		t.Assert(len(tree), 2)
+		t.Assert(tree[0].Id, testMenuSystem)
+		t.Assert(tree[0].Pid, "")
+		t.Assert(tree[0].Meta.Order, 1)
+		menu := tree[0].Children[0]
+		t.Assert(menu.Id, testMenuMenu)
+		t.Assert(menu.Pid, testMenuSystem)
+		t.Assert(menu.Children[0].Id, testMenuButton)
+		t.Assert(menu.Children[0].Pid, testMenuMenu)
+		t.Assert(tree[1].Id, testMenuAbout)
+
+		// Ids survive encoding as sent to the frontend.
+		data, err := json.Marshal(tree)
+		t.AssertNil(err)
+		var decoded v1.MenuAllRes
+		t.AssertNil(json.Unmarshal(data, &decoded))
+		t.Assert(decoded[0].Children[0].Id, testMenuMenu)
+		t.Assert(decoded[0].Children[0].Pid, testMenuSystem)
+
+		// A menu picked from /menu/all addresses the same record on the
+		// management endpoints.
+		list := buildSystemMenuTree(testMenuRecords())
+		t.Assert(list[0].Children[0].Id, menu.Id)
+		t.Assert(list[0].Children[0].Pid, menu.Pid)
+		in := v1.MenuInput{Pid: menu.Pid, Name: menu.Name, Path: menu.Path, Component: menu.Component, Type: menu.Type}
+		in, err = normalizeMenuInput(in)
+		t.AssertNil(err)
+		t.AssertNil(validateMenuPlacement(testMenuRecords(), menu.Id, in))
+		t.Assert(menuData(in)["parent_id"], testMenuSystem)
+
+		// Moving a menu under another keeps its id and takes the new pid.
+		moved := records
+		for i := range moved {
+			if moved[i].Id == testMenuAbout {
+				moved[i].ParentId = testMenuSystem
+			}
+		}
+		tree = buildMenuTree(moved)
+		t.Assert(len(tree), 1)
+		t.Assert(tree[0].Children[1].Id, testMenuAbout)
+		t.Assert(tree[0].Children[1].Pid, testMenuSystem)
+	})
+}
+
+func TestRecordToMenuItem_Meta(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		// The demos catalog as seeded by 000008.
+		item := recordToMenuItem(menuRecord{
+			Name: "Demos", Path: "/demos", Type: MenuTypeCatalog, Visible: 1, Status: 1, Order: 1000,
+			Meta: `{"title":"demos.title","order":1000,"keepAlive":true}`,
+		})
+		t.Assert(item.Meta.KeepAlive, true)
+		t.Assert(item.Meta.HideInMenu, false)
+		t.Assert(item.Meta.Order, 1000)
+
+		item = recordToMenuItem(menuRecord{Name: "Hidden", Path: "/hidden", Type: MenuTypeMenu, Visible: 0, Status: 1})
+		t.AssertNE(item.Meta, nil)
+		t.Assert(item.Meta.HideInMenu, true)
+
+		item = recordToMenuItem(menuRecord{Name: "Plain", Path: "/plain", Type: MenuTypeMenu, Visible: 1, Status: 1})
+		t.Assert(item.Meta, nil)
+
+		// hideInMenu kept in meta is not overwritten by a visible column.
+		item = recordToMenuItem(menuRecord{Name: "Detail", Path: "/detail", Type: MenuTypeMenu, Visible: 1, Status: 1, Meta: `{"title":"detail","hideInMenu":true}`})
+		t.Assert(item.Meta.HideInMenu, true)
+		t.Assert(recordToSystemMenu(&menuRecord{Name: "Detail", Path: "/detail", Type: MenuTypeMenu, Visible: 1, Status: 1, Meta: `{"title":"detail","hideInMenu":true}`}).Meta.HideInMenu, true)
+
+		// Keys vben adds later reach the frontend unchanged.
+		item = recordToMenuItem(menuRecord{Name: "New", Path: "/new", Type: MenuTypeMenu, Visible: 1, Meta: `{"title":"new","domCached":true}`})
+		data, err := json.Marshal(item.Meta)
+		t.AssertNil(err)
+		t.Assert(string(data), `{"domCached":true,"title":"new"}`)
+	})
+}
+
+func TestMenuTreeIssues(t *testing.T) {
+	records := []menuRecord{
+		{Id: "root", Name: "Root", Path: "/root", Type: MenuTypeCatalog, Visible: 1, Status: 1},
+		{Id: "child", ParentId: "root", Name: "Child", Path: "/root/child", Type: MenuTypeMenu, Visible: 1, Status: 1},
+		{Id: "orphan", ParentId: "gone", Name: "Orphan", Path: "/orphan", Type: MenuTypeCatalog, Visible: 1, Status: 1},
+		{Id: "under-orphan", ParentId: "orphan", Name: "UnderOrphan", Path: "/orphan/x", Type: MenuTypeMenu, Visible: 1, Status: 1},
+		{Id: "c1", ParentId: "c2", Name: "C1", Path: "/c1", Type: MenuTypeCatalog, Visible: 1, Status: 1},
+		{Id: "c2", ParentId: "c1", Name: "C2", Path: "/c2", Type: MenuTypeCatalog, Visible: 1, Status: 1},
+		{Id: "under-cycle", ParentId: "c1", Name: "UnderCycle", Path: "/c1/x", Type: MenuTypeMenu, Visible: 1, Status: 1},
+		{Id: "self", ParentId: "self", Name: "Self", Path: "/self", Type: MenuTypeMenu, Visible: 1, Status: 1},
+	}
+	gtest.C(t, func(t *gtest.T) {
+		orphans, cycles := menuTreeIssues(records)
+		t.Assert(orphans, []string{"orphan"})
+		t.Assert(cycles, []string{"c1", "c2", "self"})
+
+		// None of them is promoted to a root.
+		tree := buildMenuTree(records)
+		t.Assert(len(tree), 1)
+		t.Assert(tree[0].Id, "root")
+		t.Assert(tree[0].Children[0].Id, "child")
 	})
 }
 
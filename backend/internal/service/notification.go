@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+
+	"backend/api/notification/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+const notificationTable = "sys_notification"
+
+var localNotification INotification
+
+// Notification returns the notification service instance.
+func Notification() INotification {
+	return localNotification
+}
+
+// RegisterNotification sets the instance used by notification handlers.
+func RegisterNotification(i INotification) {
+	localNotification = i
+}
+
+var _ INotification = (*sNotification)(nil)
+
+func init() {
+	RegisterNotification(NewNotification())
+}
+
+// NewNotification creates a new notification service instance.
+func NewNotification() *sNotification {
+	return &sNotification{}
+}
+
+// INotification defines the interface for reading the caller's notifications.
+type INotification interface {
+	List(ctx context.Context, in v1.NotificationListReq) (*v1.NotificationListRes, error)
+	Read(ctx context.Context, id int64) error
+}
+
+type sNotification struct{}
+
+// List returns a page of the caller's notifications, newest first.
+func (s *sNotification) List(ctx context.Context, in v1.NotificationListReq) (*v1.NotificationListRes, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	model := g.DB().Ctx(ctx).Model(notificationTable).Where("user_id", p.UserId)
+	if in.Unread {
+		model = model.Where("read_at is null")
+	}
+	var items []*v1.NotificationItem
+	total := 0
+	if err = model.OrderDesc("id").Page(in.Page, in.PageSize).ScanAndCount(&items, &total, false); err != nil {
+		return nil, err
+	}
+	unread, err := g.DB().Ctx(ctx).Model(notificationTable).
+		Where("user_id", p.UserId).
+		Where("read_at is null").
+		Count()
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []*v1.NotificationItem{}
+	}
+	return &v1.NotificationListRes{Items: items, Total: total, Unread: unread}, nil
+}
+
+// Read marks one of the caller's notifications as read.
+func (s *sNotification) Read(ctx context.Context, id int64) error {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	count, err := g.DB().Ctx(ctx).Model(notificationTable).
+		Where("id", id).
+		Where("user_id", p.UserId).
+		Count()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return gerror.NewCodef(consts.ErrorCodeNotificationNotFound, "notification %d not found", id)
+	}
+	_, err = g.DB().Ctx(ctx).Model(notificationTable).
+		Data(g.Map{"read_at": gtime.Now()}).
+		Where("id", id).
+		Where("read_at is null").
+		Update()
+	return err
+}
+
+// notify stores a notification for userID.
+var notify = func(ctx context.Context, tenantID, userID, kind, title, content string) error {
+	_, err := g.DB().Ctx(ctx).Model(notificationTable).Data(g.Map{
+		"tenant_id": NormalizeDomain(tenantID),
+		"user_id":   userID,
+		"kind":      kind,
+		"title":     title,
+		"content":   content,
+	}).Insert()
+	return err
+}
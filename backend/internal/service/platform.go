@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	menuv1 "backend/api/menu/v1"
+	"backend/api/platform/v1"
+	"backend/internal/consts"
+	"backend/internal/dao"
+	"backend/internal/model/entity"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+const (
+	tenantTable  = "sys_tenant"
+	settingTable = "sys_setting"
+
+	// platformPathPrefix is the path prefix of the routes checked in the
+	// platform domain.
+	platformPathPrefix = "/platform"
+)
+
+var settingKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+var localPlatform IPlatform
+
+// Platform returns the platform service instance.
+func Platform() IPlatform {
+	return localPlatform
+}
+
+// RegisterPlatform sets the instance used by platform related handlers.
+func RegisterPlatform(i IPlatform) {
+	localPlatform = i
+}
+
+var _ IPlatform = (*sPlatform)(nil)
+
+func init() {
+	RegisterPlatform(NewPlatform())
+}
+
+// NewPlatform creates a new platform service instance.
+func NewPlatform() *sPlatform {
+	return &sPlatform{}
+}
+
+// IPlatform defines the operations of platform operators.
+type IPlatform interface {
+	ListTenants(ctx context.Context, in v1.TenantListReq) (*v1.TenantListRes, error)
+	CreateTenant(ctx context.Context, in v1.TenantCreateReq) (*v1.TenantItem, error)
+	UpdateTenant(ctx context.Context, in v1.TenantUpdateReq) (*v1.TenantItem, error)
+	SyncTenantMenus(ctx context.Context, in v1.TenantMenuSyncReq) (*menuv1.MenuImportRes, error)
+	ListUsers(ctx context.Context, in v1.PlatformUserListReq) (*v1.PlatformUserListRes, error)
+	MoveUser(ctx context.Context, in v1.PlatformUserTenantReq) (*v1.PlatformUserItem, error)
+	ListSettings(ctx context.Context) (*v1.SettingListRes, error)
+	SaveSetting(ctx context.Context, in v1.SettingSaveReq) (*v1.SettingItem, error)
+}
+
+type sPlatform struct{}
+
+// setting is a sys_setting row.
+type setting struct {
+	Key       string      `orm:"key"`
+	Value     string      `orm:"value"`
+	Remark    string      `orm:"remark"`
+	UpdatedBy string      `orm:"updated_by"`
+	UpdatedAt *gtime.Time `orm:"updated_at"`
+}
+
+// IsPlatformPath reports whether requestPath is served in the platform
+// domain. The path is cleaned first so "/system/../platform/tenant" is
+// recognised as well.
+func IsPlatformPath(requestPath string) bool {
+	cleaned := strings.ToLower(path.Clean("/" + requestPath))
+	return cleaned == platformPathPrefix || strings.HasPrefix(cleaned, platformPathPrefix+"/")
+}
+
+// AuthzTarget returns the casbin domain and subjects a request of p is
+// checked with. Platform requests are checked in the platform domain for the
+// user alone, so only operator roles granted there through "g" rules apply;
+// all other requests are confined to the principal's tenant, which is the
+// tenant the token was issued for.
+func AuthzTarget(p *Principal, platform bool) (domain string, subjects []string) {
+	if platform {
+		return consts.PlatformDomain, []string{p.UserId}
+	}
+	return NormalizeDomain(p.TenantId), p.Subjects()
+}
+
+// isPlatformOperator reports whether the user holds the operator role in the
+// platform domain.
+var isPlatformOperator = func(ctx context.Context, userID string) (bool, error) {
+	enforcer, err := Casbin(ctx, consts.PlatformDomain)
+	if err != nil {
+		return false, err
+	}
+	return platformOperator(enforcer, userID)
+}
+
+func platformOperator(enforcer casbin.IEnforcer, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	roles, err := enforcer.GetImplicitRolesForUser(userID, consts.PlatformDomain)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(roles, consts.PlatformOperatorRole), nil
+}
+
+// requirePlatformOperator returns the caller when it is a platform operator.
+func requirePlatformOperator(ctx context.Context) (*Principal, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := isPlatformOperator(ctx, p.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, gerror.NewCode(consts.ErrorCodeForbidden, "platform operator required")
+	}
+	return p, nil
+}
+
+// ListTenants returns a page of tenants with their user counts.
+func (s *sPlatform) ListTenants(ctx context.Context, in v1.TenantListReq) (*v1.TenantListRes, error) {
+	if _, err := requirePlatformOperator(ctx); err != nil {
+		return nil, err
+	}
+	model := g.DB().Ctx(ctx).Model(tenantTable + " t").
+		Fields("t.id, t.name, t.status, t.created_at, t.updated_at").
+		Fields("(SELECT COUNT(*) FROM sys_user u WHERE u.tenant_id = t.id AND u.deleted_at IS NULL) AS users").
+		Where("t.deleted_at is null")
+	if name := strings.TrimSpace(in.Name); name != "" {
+		model = model.WhereLike("t.name", "%"+name+"%")
+	}
+	var items []*v1.TenantItem
+	total := 0
+	if err := model.OrderAsc("t.created_at").Page(in.Page, in.PageSize).ScanAndCount(&items, &total, false); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []*v1.TenantItem{}
+	}
+	return &v1.TenantListRes{Items: items, Total: total}, nil
+}
+
+// CreateTenant creates a tenant with the menus of the menu template. Its
+// roles and policies are set up by the operator afterwards.
+func (s *sPlatform) CreateTenant(ctx context.Context, in v1.TenantCreateReq) (*v1.TenantItem, error) {
+	p, err := requirePlatformOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var item *v1.TenantItem
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		id, err := g.DB().GetValue(ctx,
+			"INSERT INTO sys_tenant (name, status) VALUES (?, ?) RETURNING id",
+			strings.TrimSpace(in.Name), in.Status)
+		if err != nil {
+			return err
+		}
+		if item, err = findTenant(ctx, id.String()); err != nil {
+			return err
+		}
+		if err = provisionTenantMenus(ctx, item.Id, p.UserId); err != nil {
+			return err
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   consts.PlatformDomain,
+			OperatorId: p.UserId,
+			Action:     "tenant.create",
+			Resource:   tenantTable,
+			ResourceId: item.Id,
+			After:      item,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// UpdateTenant renames, enables or disables a tenant.
+func (s *sPlatform) UpdateTenant(ctx context.Context, in v1.TenantUpdateReq) (*v1.TenantItem, error) {
+	p, err := requirePlatformOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	before, err := findTenant(ctx, in.Id)
+	if err != nil {
+		return nil, err
+	}
+	var after *v1.TenantItem
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		_, err := dao.SysTenant.Ctx(ctx).
+			Data(g.Map{
+				dao.SysTenant.Columns().Name:      strings.TrimSpace(in.Name),
+				dao.SysTenant.Columns().Status:    in.Status,
+				dao.SysTenant.Columns().UpdatedAt: gtime.Now(),
+			}).
+			Where(dao.SysTenant.Columns().Id, before.Id).
+			Update()
+		if err != nil {
+			return err
+		}
+		if after, err = findTenant(ctx, before.Id); err != nil {
+			return err
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   consts.PlatformDomain,
+			OperatorId: p.UserId,
+			Action:     "tenant.update",
+			Resource:   tenantTable,
+			ResourceId: before.Id,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// ListUsers returns a page of users of every tenant, or of one tenant when
+// TenantId is set.
+func (s *sPlatform) ListUsers(ctx context.Context, in v1.PlatformUserListReq) (*v1.PlatformUserListRes, error) {
+	if _, err := requirePlatformOperator(ctx); err != nil {
+		return nil, err
+	}
+	columns := dao.SysUser.Columns()
+	model := dao.SysUser.Ctx(ctx).
+		Fields(columns.Id, columns.TenantId, columns.Username, columns.RealName, columns.Status, columns.Roles, columns.CreatedAt).
+		WhereNull(columns.DeletedAt)
+	if tenantID := strings.TrimSpace(in.TenantId); tenantID != "" {
+		if !isUUID(tenantID) {
+			return nil, gerror.NewCodef(consts.ErrorCodeTenantNotFound, "tenant %s not found", tenantID)
+		}
+		model = model.Where(columns.TenantId, tenantID)
+	}
+	if username := strings.TrimSpace(in.Username); username != "" {
+		model = model.WhereLike(columns.Username, "%"+username+"%")
+	}
+	var users []*entity.SysUser
+	total := 0
+	if err := model.OrderAsc(columns.TenantId).OrderAsc(columns.Username).Page(in.Page, in.PageSize).ScanAndCount(&users, &total, false); err != nil {
+		return nil, err
+	}
+	items := make([]*v1.PlatformUserItem, 0, len(users))
+	for _, user := range users {
+		items = append(items, &v1.PlatformUserItem{
+			Id:        user.Id,
+			TenantId:  user.TenantId,
+			Username:  user.Username,
+			RealName:  user.RealName,
+			Status:    user.Status,
+			Roles:     parseRoles(user.Roles),
+			CreatedAt: user.CreatedAt,
+		})
+	}
+	return &v1.PlatformUserListRes{Items: items, Total: total}, nil
+}
+
+// MoveUser moves a user to another tenant and out of its department. Access
+// tokens issued before the move carry the old tenant until they expire.
+func (s *sPlatform) MoveUser(ctx context.Context, in v1.PlatformUserTenantReq) (*v1.PlatformUserItem, error) {
+	p, err := requirePlatformOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := findTenant(ctx, in.TenantId)
+	if err != nil {
+		return nil, err
+	}
+	id := strings.TrimSpace(in.Id)
+	if !isUUID(id) {
+		return nil, gerror.NewCodef(consts.ErrorCodeUserNotFound, "user %s not found", id)
+	}
+	columns := dao.SysUser.Columns()
+	user, err := saveUser(ctx, "", p.UserId, "user.tenant", id, g.Map{
+		columns.TenantId: tenant.Id,
+		columns.DeptId:   nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	InvalidateUserCache(ctx, user.Id)
+	return &v1.PlatformUserItem{
+		Id:        user.Id,
+		TenantId:  user.TenantId,
+		Username:  user.Username,
+		RealName:  user.RealName,
+		Status:    user.Status,
+		Roles:     parseRoles(user.Roles),
+		CreatedAt: user.CreatedAt,
+	}, nil
+}
+
+// ListSettings returns every global setting ordered by key.
+func (s *sPlatform) ListSettings(ctx context.Context) (*v1.SettingListRes, error) {
+	if _, err := requirePlatformOperator(ctx); err != nil {
+		return nil, err
+	}
+	var rows []*setting
+	if err := g.DB().Ctx(ctx).Model(settingTable).OrderAsc("key").Scan(&rows); err != nil {
+		return nil, err
+	}
+	items := make([]*v1.SettingItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, settingToItem(row))
+	}
+	return &v1.SettingListRes{Items: items}, nil
+}
+
+// SaveSetting creates or replaces a global setting.
+func (s *sPlatform) SaveSetting(ctx context.Context, in v1.SettingSaveReq) (*v1.SettingItem, error) {
+	p, err := requirePlatformOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := strings.TrimSpace(in.Key)
+	if !settingKeyPattern.MatchString(key) {
+		return nil, gerror.NewCodef(consts.ErrorCodeSettingInvalid, "key %s may only contain letters, digits and _.:-", key)
+	}
+	value, err := gjson.EncodeString(in.Value)
+	if err != nil {
+		return nil, gerror.WrapCode(consts.ErrorCodeSettingInvalid, err, "value is not valid JSON")
+	}
+	var after *setting
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		var before *setting
+		if err := g.DB().Ctx(ctx).Model(settingTable).Where("key", key).LockUpdate().Scan(&before); err != nil {
+			return err
+		}
+		after = &setting{
+			Key:       key,
+			Value:     value,
+			Remark:    strings.TrimSpace(in.Remark),
+			UpdatedBy: p.UserId,
+			UpdatedAt: gtime.Now(),
+		}
+		_, err := g.DB().Ctx(ctx).Model(settingTable).Data(g.Map{
+			"key":        after.Key,
+			"value":      after.Value,
+			"remark":     after.Remark,
+			"updated_by": after.UpdatedBy,
+			"updated_at": after.UpdatedAt,
+		}).OnConflict("key").Save()
+		if err != nil {
+			return err
+		}
+		entry := auditEntry{
+			TenantId:   consts.PlatformDomain,
+			OperatorId: p.UserId,
+			Action:     "setting.update",
+			Resource:   settingTable,
+			ResourceId: key,
+			After:      settingToItem(after),
+		}
+		if before != nil {
+			entry.Before = settingToItem(before)
+		} else {
+			entry.Action = "setting.create"
+		}
+		return writeAuditLog(ctx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return settingToItem(after), nil
+}
+
+// findTenant returns the live tenant with id.
+func findTenant(ctx context.Context, id string) (*v1.TenantItem, error) {
+	id = strings.TrimSpace(id)
+	if !isUUID(id) {
+		return nil, gerror.NewCodef(consts.ErrorCodeTenantNotFound, "tenant %s not found", id)
+	}
+	var item *v1.TenantItem
+	err := g.DB().Ctx(ctx).Model(tenantTable+" t").
+		Fields("t.id, t.name, t.status, t.created_at, t.updated_at").
+		Fields("(SELECT COUNT(*) FROM sys_user u WHERE u.tenant_id = t.id AND u.deleted_at IS NULL) AS users").
+		Where("t.id", id).
+		Where("t.deleted_at is null").
+		Scan(&item)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, gerror.NewCodef(consts.ErrorCodeTenantNotFound, "tenant %s not found", id)
+	}
+	return item, nil
+}
+
+func settingToItem(row *setting) *v1.SettingItem {
+	item := &v1.SettingItem{
+		Key:       row.Key,
+		Remark:    row.Remark,
+		UpdatedBy: row.UpdatedBy,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if row.Value != "" {
+		if decoded, err := gjson.DecodeToJson(row.Value); err == nil {
+			item.Value = decoded.Interface()
+		}
+	}
+	return item
+}
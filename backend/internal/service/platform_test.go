@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/consts"
+	"backend/internal/model/entity"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+func TestIsPlatformPath(t *testing.T) {
+	testCases := []struct {
+		path string
+		want bool
+	}{
+		{path: "/platform", want: true},
+		{path: "/platform/tenant/list", want: true},
+		{path: "/system/../platform/tenant/list", want: true},
+		{path: "//platform/tenant/list", want: true},
+		{path: "/./platform/setting", want: true},
+		{path: "/PLATFORM/tenant/list", want: true},
+		{path: "platform/tenant/list", want: true},
+		{path: "/platforms", want: false},
+		{path: "/system/platform", want: false},
+		{path: "/platform/../system/policy/list", want: false},
+		{path: "", want: false},
+	}
+	gtest.C(t, func(t *gtest.T) {
+		for _, c := range testCases {
+			t.Assert(IsPlatformPath(c.path), c.want)
+		}
+	})
+}
+
+func TestPlatformOperator(t *testing.T) {
+	cache := newTestEnforcerCache(t, 10, newMemoryRules(
+		"p, operator, platform, /platform/*, .*, allow",
+		"g, u-op, operator, platform",
+		"g, u-lead, support, platform",
+		"g, support, operator, platform",
+		"g, u-admin, operator, t1",
+	))
+	enforcer, err := cache.Get(consts.PlatformDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gtest.C(t, func(t *gtest.T) {
+		for userID, want := range map[string]bool{"u-op": true, "u-lead": true, "u-admin": false, "": false} {
+			ok, err := platformOperator(enforcer, userID)
+			t.AssertNil(err)
+			t.Assert(ok, want)
+		}
+	})
+}
+
+// TestAuthzTarget_TenantConfinement replays what CasbinAuthz checks for a
+// tenant admin holding "/*" in its own tenant.
+func TestAuthzTarget_TenantConfinement(t *testing.T) {
+	stubPolicyConditions(t, authzNow())
+	cache := newTestEnforcerCache(t, 10, newMemoryRules(
+		"p, admin, t1, /*, .*, allow",
+		"p, super, t1, /*, .*, allow",
+		"p, admin, t2, /*, .*, allow",
+		"g, u-t2, admin, t2",
+		"g, u-t1, admin, t2",
+		"p, operator, platform, /platform/*, .*, allow",
+		"g, u-op, operator, platform",
+	))
+	ctx := context.Background()
+	authorize := func(p *Principal, path string) bool {
+		domain, subjects := AuthzTarget(p, IsPlatformPath(path))
+		enforcer, err := cache.Get(domain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, err := NewRequestEnv(ctx, domain, "10.0.0.1", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		allowed, _, err := EvaluateSubjects(enforcer, subjects, domain, path, "get", env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return allowed
+	}
+	tenantAdmin := &Principal{UserId: "u-t1", TenantId: "t1", Roles: []string{"admin"}}
+	// A role-less user of a tenant is treated as super there, but not on the
+	// platform.
+	tenantSuper := &Principal{UserId: "u-s1", TenantId: "t1", Roles: []string{"super"}}
+	operator := &Principal{UserId: "u-op", TenantId: "t1", Roles: []string{"staff"}}
+
+	gtest.C(t, func(t *gtest.T) {
+		domain, subjects := AuthzTarget(tenantAdmin, false)
+		t.Assert(domain, "t1")
+		t.Assert(subjects, []string{"u-t1", "admin"})
+		domain, subjects = AuthzTarget(tenantAdmin, true)
+		t.Assert(domain, consts.PlatformDomain)
+		t.Assert(subjects, []string{"u-t1"})
+
+		t.Assert(authorize(tenantAdmin, "/system/policy/list"), true)
+		for _, path := range []string{
+			"/platform/tenant/list",
+			"/platform/user/list",
+			"/system/../platform/tenant/list",
+			"//platform/setting",
+			"/PLATFORM/tenant/list",
+		} {
+			t.Assert(authorize(tenantAdmin, path), false)
+			t.Assert(authorize(tenantSuper, path), false)
+		}
+		// Rules naming the user in another tenant do not follow it home, and
+		// the tenant's own rules never reach another tenant's domain.
+		enforcer, err := cache.Get("t1")
+		t.AssertNil(err)
+		t.Assert(enforcer.HasGroupingPolicy("u-t1", "admin", "t2"), false)
+		t.Assert(len(enforcer.GetFilteredPolicy(1, "t2")), 0)
+
+		t.Assert(authorize(operator, "/platform/tenant/list"), true)
+		t.Assert(authorize(operator, "/system/policy/list"), false)
+	})
+}
+
+func TestPolicyOperator_ResolveDomain(t *testing.T) {
+	op := &policyOperator{domain: "11111111-2222-3333-4444-555555555555"}
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		domain, err := op.resolveDomain(ctx, "")
+		t.AssertNil(err)
+		t.Assert(domain, op.domain)
+		domain, err = op.resolveDomain(ctx, " "+op.domain+" ")
+		t.AssertNil(err)
+		t.Assert(domain, op.domain)
+
+		for _, requested := range []string{consts.DefaultTenantId, consts.PlatformDomain, "default"} {
+			_, err = op.resolveDomain(ctx, requested)
+			t.Assert(gerror.Code(err), consts.ErrorCodeForbidden)
+		}
+
+		operator := &policyOperator{domain: consts.DefaultTenantId, platform: true}
+		domain, err = operator.resolveDomain(ctx, consts.PlatformDomain)
+		t.AssertNil(err)
+		t.Assert(domain, consts.PlatformDomain)
+	})
+}
+
+// TestPolicyOperator_TokenTenant checks that the tenant claim of the token,
+// not the tenant stored on the user, decides the domain a caller manages
+// and is authorized in.
+func TestPolicyOperator_TokenTenant(t *testing.T) {
+	const (
+		storedTenant = "11111111-1111-1111-1111-111111111111"
+		tokenTenant  = "22222222-2222-2222-2222-222222222222"
+	)
+	user := &entity.SysUser{Id: "u1", TenantId: storedTenant, Roles: `["admin"]`}
+	stubLoadUser(t, user)
+	previous := isPlatformOperator
+	isPlatformOperator = func(ctx context.Context, userID string) (bool, error) { return false, nil }
+	t.Cleanup(func() { isPlatformOperator = previous })
+
+	issued := *user
+	issued.TenantId = tokenTenant
+	token, err := (&sAuth{}).generateAccessToken(&issued)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	p, err := principalFromToken(context.Background(), token)
+	if err != nil {
+		t.Fatalf("failed to resolve principal: %v", err)
+	}
+	ctx := WithPrincipal(context.Background(), p)
+
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(p.TenantId, tokenTenant)
+		t.Assert(p.User.TenantId, storedTenant)
+
+		domain, subjects := AuthzTarget(p, false)
+		t.Assert(domain, tokenTenant)
+		t.Assert(subjects, []string{"u1", "admin"})
+
+		op, err := loadPolicyOperator(ctx)
+		t.AssertNil(err)
+		t.Assert(op.domain, tokenTenant)
+		domain, err = op.resolveDomain(ctx, "")
+		t.AssertNil(err)
+		t.Assert(domain, tokenTenant)
+		_, err = op.resolveDomain(ctx, storedTenant)
+		t.Assert(gerror.Code(err), consts.ErrorCodeForbidden)
+	})
+}
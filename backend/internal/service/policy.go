@@ -0,0 +1,550 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"backend/api/policy/v1"
+	"backend/internal/consts"
+	"backend/internal/dao"
+	"backend/internal/model/entity"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	policyTypeP = "p"
+	policyTypeG = "g"
+
+	roleTable = "sys_role"
+)
+
+var localPolicy IPolicy
+
+// Policy returns the policy service instance.
+func Policy() IPolicy {
+	return localPolicy
+}
+
+// RegisterPolicy sets the instance used by policy related handlers.
+func RegisterPolicy(i IPolicy) {
+	localPolicy = i
+}
+
+var _ IPolicy = (*sPolicy)(nil)
+
+func init() {
+	RegisterPolicy(NewPolicy())
+}
+
+// NewPolicy creates a new policy service instance.
+func NewPolicy() *sPolicy {
+	return &sPolicy{}
+}
+
+// IPolicy defines the casbin policy administration interface.
+type IPolicy interface {
+	List(ctx context.Context, in v1.PolicyListReq) (*v1.PolicyListRes, error)
+	Create(ctx context.Context, in v1.PolicyInput) (*v1.PolicyItem, error)
+	Update(ctx context.Context, id int64, in v1.PolicyInput) (*v1.PolicyItem, error)
+	Delete(ctx context.Context, id int64) error
+	Reload(ctx context.Context) error
+	Explain(ctx context.Context, in v1.PolicyExplainReq) (*v1.PolicyExplainRes, error)
+	ListConditions(ctx context.Context, domain string) (*v1.PolicyConditionListRes, error)
+	SaveCondition(ctx context.Context, in v1.PolicyConditionSaveReq) (*v1.PolicyConditionItem, error)
+	DeleteCondition(ctx context.Context, id int64) error
+}
+
+type sPolicy struct{}
+
+// policyOperator is the caller of a policy administration endpoint.
+type policyOperator struct {
+	user     *entity.SysUser
+	domain   string
+	platform bool
+}
+
+// List returns policies of the caller's domain. Platform operators may list
+// any domain, or all of them when no domain is given.
+func (s *sPolicy) List(ctx context.Context, in v1.PolicyListReq) (*v1.PolicyListRes, error) {
+	op, err := loadPolicyOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	domain := strings.TrimSpace(in.Domain)
+	if domain != "" || !op.platform {
+		if domain, err = op.resolveDomain(ctx, domain); err != nil {
+			return nil, err
+		}
+	}
+
+	model := g.DB().Ctx(ctx).Model(casbinRuleTable)
+	if in.Ptype != "" {
+		model = model.Where("ptype", in.Ptype)
+	}
+	if domain != "" {
+		model = model.Where(model.Builder().
+			Where(model.Builder().Where("ptype", policyTypeP).Where("v1", domain)).
+			WhereOr(model.Builder().Where("ptype", policyTypeG).Where("v2", domain)),
+		)
+	}
+	if subject := strings.TrimSpace(in.Subject); subject != "" {
+		model = model.Where("v0", subject)
+	}
+	if object := strings.TrimSpace(in.Object); object != "" {
+		model = model.Where("ptype", policyTypeP).WhereLike("v2", "%"+object+"%")
+	}
+	if action := strings.TrimSpace(in.Action); action != "" {
+		model = model.Where("ptype", policyTypeP).WhereLike("v3", "%"+action+"%")
+	}
+
+	records, total, err := model.OrderAsc("id").Page(in.Page, in.PageSize).AllAndCount(false)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*v1.PolicyItem, 0, len(records))
+	for _, record := range records {
+		items = append(items, recordToPolicyItem(record))
+	}
+	return &v1.PolicyListRes{Items: items, Total: total}, nil
+}
+
+// Create validates and adds a policy through the enforcer.
+func (s *sPolicy) Create(ctx context.Context, in v1.PolicyInput) (*v1.PolicyItem, error) {
+	op, err := loadPolicyOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ptype, rule, err := op.buildRule(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		item   *v1.PolicyItem
+		change *watcherMessage
+	)
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if change, err = addNamedRule(ctx, ptype, rule); err != nil {
+			return err
+		}
+		if change == nil {
+			return gerror.NewCode(consts.ErrorCodePolicyExists, "policy already exists")
+		}
+		if item, err = findPolicyItem(ctx, ptype, rule); err != nil {
+			return err
+		}
+		return op.audit(ctx, "policy.create", item, nil, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = applyPolicyChanges(ctx, change); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Update replaces the policy identified by id.
+func (s *sPolicy) Update(ctx context.Context, id int64, in v1.PolicyInput) (*v1.PolicyItem, error) {
+	op, err := loadPolicyOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	before, err := op.loadPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ptype, rule, err := op.buildRule(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	oldRule := policyItemToRule(before)
+	if before.Ptype == ptype && strings.Join(oldRule, ",") == strings.Join(rule, ",") {
+		return before, nil
+	}
+
+	enforcer, err := Casbin(ctx, ruleDomain(ptype, rule))
+	if err != nil {
+		return nil, err
+	}
+	if enforcer.HasNamedPolicy(ptype, rule) || enforcer.HasNamedGroupingPolicy(ptype, rule) {
+		return nil, gerror.NewCode(consts.ErrorCodePolicyExists, "policy already exists")
+	}
+	var (
+		after   *v1.PolicyItem
+		changes []*watcherMessage
+	)
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if before.Ptype == ptype {
+			change, err := updateNamedRule(ctx, ptype, oldRule, rule)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, change)
+		} else {
+			removed, err := removePolicyRow(ctx, before)
+			if err != nil {
+				return err
+			}
+			added, err := addNamedRule(ctx, ptype, rule)
+			if err != nil {
+				return err
+			}
+			if added == nil {
+				return gerror.NewCode(consts.ErrorCodePolicyExists, "policy already exists")
+			}
+			changes = append(changes, removed, added)
+		}
+		if after, err = findPolicyItem(ctx, ptype, rule); err != nil {
+			return err
+		}
+		return op.audit(ctx, "policy.update", after, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = applyPolicyChanges(ctx, changes...); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// Delete removes the policy identified by id.
+func (s *sPolicy) Delete(ctx context.Context, id int64) error {
+	op, err := loadPolicyOperator(ctx)
+	if err != nil {
+		return err
+	}
+	before, err := op.loadPolicy(ctx, id)
+	if err != nil {
+		return err
+	}
+	var change *watcherMessage
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if change, err = removePolicyRow(ctx, before); err != nil {
+			return err
+		}
+		return op.audit(ctx, "policy.delete", before, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+	return applyPolicyChanges(ctx, change)
+}
+
+// Reload reloads policies on this and every other instance.
+func (s *sPolicy) Reload(ctx context.Context) error {
+	op, err := loadPolicyOperator(ctx)
+	if err != nil {
+		return err
+	}
+	if err = ReloadCasbinPolicy(ctx); err != nil {
+		return err
+	}
+	return writeAuditLog(ctx, auditEntry{
+		TenantId:   op.domain,
+		OperatorId: op.user.Id,
+		Action:     "policy.reload",
+		Resource:   casbinRuleTable,
+	})
+}
+
+// loadPolicyOperator returns the caller as a policy operator. The caller's
+// domain is the tenant of the principal, which is the token's tenant claim
+// rather than the tenant stored on the user.
+func loadPolicyOperator(ctx context.Context) (*policyOperator, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	platform, err := isPlatformOperator(ctx, p.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return &policyOperator{
+		user:     p.User,
+		domain:   NormalizeDomain(p.TenantId),
+		platform: platform,
+	}, nil
+}
+
+// resolveDomain returns the domain a request targets. Tenant admins are pinned
+// to their own domain; platform operators may target the platform domain and
+// any existing tenant.
+func (op *policyOperator) resolveDomain(ctx context.Context, requested string) (string, error) {
+	requested = strings.TrimSpace(requested)
+	if requested == "" || requested == op.domain {
+		return op.domain, nil
+	}
+	if !op.platform {
+		return "", gerror.NewCodef(consts.ErrorCodeForbidden, "cannot manage policies of domain %s", requested)
+	}
+	if requested == consts.PlatformDomain {
+		return requested, nil
+	}
+	if !isUUID(requested) {
+		return "", gerror.NewCodef(consts.ErrorCodePolicyInvalid, "unknown domain %s", requested)
+	}
+	count, err := dao.SysTenant.Ctx(ctx).Where(dao.SysTenant.Columns().Id, requested).Count()
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", gerror.NewCodef(consts.ErrorCodePolicyInvalid, "unknown domain %s", requested)
+	}
+	return requested, nil
+}
+
+// loadPolicy fetches a policy row and checks the caller may manage it.
+func (op *policyOperator) loadPolicy(ctx context.Context, id int64) (*v1.PolicyItem, error) {
+	record, err := g.DB().Ctx(ctx).Model(casbinRuleTable).Where("id", id).One()
+	if err != nil {
+		return nil, err
+	}
+	if record.IsEmpty() {
+		return nil, gerror.NewCodef(consts.ErrorCodePolicyNotFound, "policy %d not found", id)
+	}
+	item := recordToPolicyItem(record)
+	if item.Domain != op.domain && !op.platform {
+		// Do not reveal rows of other tenants.
+		return nil, gerror.NewCodef(consts.ErrorCodePolicyNotFound, "policy %d not found", id)
+	}
+	return item, nil
+}
+
+// buildRule validates input and converts it into a casbin rule.
+func (op *policyOperator) buildRule(ctx context.Context, in v1.PolicyInput) (string, []string, error) {
+	domain, err := op.resolveDomain(ctx, in.Domain)
+	if err != nil {
+		return "", nil, err
+	}
+	subject := strings.TrimSpace(in.Subject)
+	if err = validatePolicySubject(ctx, domain, subject); err != nil {
+		return "", nil, err
+	}
+
+	switch strings.TrimSpace(in.Ptype) {
+	case policyTypeP:
+		object := strings.TrimSpace(in.Object)
+		action := strings.ToLower(strings.TrimSpace(in.Action))
+		effect := strings.TrimSpace(in.Effect)
+		if effect == "" {
+			effect = PolicyEffectAllow
+		}
+		if err = validatePolicyPattern(object, action, effect); err != nil {
+			return "", nil, err
+		}
+		if strings.TrimSpace(in.Role) != "" {
+			return "", nil, gerror.NewCode(consts.ErrorCodePolicyInvalid, "role is only valid for g policies")
+		}
+		return policyTypeP, []string{subject, domain, object, action, effect}, nil
+
+	case policyTypeG:
+		role := strings.TrimSpace(in.Role)
+		if role == "" {
+			return "", nil, gerror.NewCode(consts.ErrorCodePolicyInvalid, "role is required for g policies")
+		}
+		if role == subject {
+			return "", nil, gerror.NewCode(consts.ErrorCodePolicyInvalid, "a subject cannot inherit itself")
+		}
+		exists, err := roleExists(ctx, domain, role)
+		if err != nil {
+			return "", nil, err
+		}
+		if !exists {
+			return "", nil, gerror.NewCodef(consts.ErrorCodePolicyInvalid, "role %s does not exist", role)
+		}
+		if in.Object != "" || in.Action != "" || in.Effect != "" {
+			return "", nil, gerror.NewCode(consts.ErrorCodePolicyInvalid, "object, action and effect are only valid for p policies")
+		}
+		return policyTypeG, []string{subject, role, domain}, nil
+
+	default:
+		return "", nil, gerror.NewCodef(consts.ErrorCodePolicyInvalid, "unsupported policy type %s", in.Ptype)
+	}
+}
+
+func (op *policyOperator) audit(ctx context.Context, action string, item, before, after *v1.PolicyItem) error {
+	return writeAuditLog(ctx, auditEntry{
+		TenantId:   item.Domain,
+		OperatorId: op.user.Id,
+		Action:     action,
+		Resource:   casbinRuleTable,
+		ResourceId: strconv.FormatInt(item.Id, 10),
+		Before:     before,
+		After:      after,
+	})
+}
+
+// validatePolicySubject requires the subject to be a role or a user of the
+// domain. In the platform domain it is a platform role or a user of any tenant.
+func validatePolicySubject(ctx context.Context, domain, subject string) error {
+	if subject == "" {
+		return gerror.NewCode(consts.ErrorCodePolicyInvalid, "subject is required")
+	}
+	if domain == consts.PlatformDomain && !isUUID(subject) {
+		return nil
+	}
+	exists, err := roleExists(ctx, domain, subject)
+	if err != nil || exists {
+		return err
+	}
+	if isUUID(subject) && (isUUID(domain) || domain == consts.PlatformDomain) {
+		model := dao.SysUser.Ctx(ctx).Where(dao.SysUser.Columns().Id, subject)
+		if domain != consts.PlatformDomain {
+			model = model.Where(dao.SysUser.Columns().TenantId, domain)
+		}
+		count, err := model.Count()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+	return gerror.NewCodef(consts.ErrorCodePolicyInvalid, "subject %s is neither a role nor a user of the domain", subject)
+}
+
+// roleExists reports whether code is a role of domain. Platform roles have no
+// sys_role rows; any name other than a user id is one.
+func roleExists(ctx context.Context, domain, code string) (bool, error) {
+	if domain == consts.PlatformDomain {
+		return code != "" && !isUUID(code), nil
+	}
+	if !isUUID(domain) || code == "" {
+		return false, nil
+	}
+	count, err := g.DB().Ctx(ctx).Model(roleTable).
+		Where("tenant_id", domain).
+		Where("code", code).
+		Where("deleted_at is null").
+		Count()
+	return count > 0, err
+}
+
+// validatePolicyPattern checks the object/action syntax understood by the casbin model.
+func validatePolicyPattern(object, action, effect string) error {
+	if object == "" {
+		return gerror.NewCode(consts.ErrorCodePolicyInvalid, "object is required")
+	}
+	if object != "*" && !strings.HasPrefix(object, "/") {
+		return gerror.NewCodef(consts.ErrorCodePolicyInvalid, "object %s must be * or start with /", object)
+	}
+	if action == "" {
+		return gerror.NewCode(consts.ErrorCodePolicyInvalid, "action is required")
+	}
+	if action != "*" {
+		if _, err := regexp.Compile("^(" + action + ")$"); err != nil {
+			return gerror.NewCodef(consts.ErrorCodePolicyInvalid, "action %s is not a valid pattern", action)
+		}
+	}
+	if effect != PolicyEffectAllow && effect != PolicyEffectDeny {
+		return gerror.NewCodef(consts.ErrorCodePolicyInvalid, "effect %s must be allow or deny", effect)
+	}
+	return nil
+}
+
+// addNamedRule persists a rule in the transaction carried by ctx and returns
+// the change to apply to the enforcers once it is committed, or nil when the
+// enforcer of the rule's domain already holds the rule.
+func addNamedRule(ctx context.Context, ptype string, rule []string) (*watcherMessage, error) {
+	enforcer, err := Casbin(ctx, ruleDomain(ptype, rule))
+	if err != nil {
+		return nil, err
+	}
+	if enforcer.HasNamedPolicy(ptype, rule) || enforcer.HasNamedGroupingPolicy(ptype, rule) {
+		return nil, nil
+	}
+	if err = casbinAdapter.AddPoliciesCtx(ctx, ptype[:1], ptype, [][]string{rule}); err != nil {
+		return nil, err
+	}
+	return &watcherMessage{
+		Op: watcherOpAdd, Sec: ptype[:1], Ptype: ptype, Rules: [][]string{rule},
+	}, nil
+}
+
+// updateNamedRule replaces a stored rule in place in the transaction carried
+// by ctx and returns the change to apply to the enforcers of its old and new
+// domain once it is committed. It fails with ErrorCodePolicyNotFound when the
+// old rule is no longer stored.
+func updateNamedRule(ctx context.Context, ptype string, oldRule, newRule []string) (*watcherMessage, error) {
+	if err := initCasbin(ctx); err != nil {
+		return nil, err
+	}
+	if err := casbinAdapter.UpdatePoliciesCtx(ctx, ptype[:1], ptype, [][]string{oldRule}, [][]string{newRule}); err != nil {
+		return nil, err
+	}
+	return &watcherMessage{
+		Op: watcherOpUpdate, Sec: ptype[:1], Ptype: ptype, Rules: [][]string{oldRule}, NewRules: [][]string{newRule},
+	}, nil
+}
+
+// removePolicyRow deletes a stored rule in the transaction carried by ctx and
+// returns the change removing it from the enforcer of its domain once it is
+// committed.
+func removePolicyRow(ctx context.Context, item *v1.PolicyItem) (*watcherMessage, error) {
+	if err := initCasbin(ctx); err != nil {
+		return nil, err
+	}
+	if err := casbinAdapter.DeleteRow(ctx, item.Id); err != nil {
+		return nil, err
+	}
+	return &watcherMessage{
+		Op: watcherOpRemove, Sec: item.Ptype[:1], Ptype: item.Ptype, Rules: [][]string{policyItemToRule(item)},
+	}, nil
+}
+
+// applyPolicyChanges applies committed policy changes to the enforcers of
+// every instance, in order.
+func applyPolicyChanges(ctx context.Context, changes ...*watcherMessage) error {
+	for _, change := range changes {
+		if err := applyCasbinChange(ctx, *change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findPolicyItem(ctx context.Context, ptype string, rule []string) (*v1.PolicyItem, error) {
+	model := g.DB().Ctx(ctx).Model(casbinRuleTable).Where("ptype", ptype)
+	for i, value := range rule {
+		model = model.Where("v"+strconv.Itoa(i), value)
+	}
+	record, err := model.OrderDesc("id").One()
+	if err != nil {
+		return nil, err
+	}
+	if record.IsEmpty() {
+		return nil, gerror.NewCode(consts.ErrorCodePolicyNotFound, "policy not found after write")
+	}
+	return recordToPolicyItem(record), nil
+}
+
+func recordToPolicyItem(record gdb.Record) *v1.PolicyItem {
+	item := &v1.PolicyItem{
+		Id:      record["id"].Int64(),
+		Ptype:   strings.TrimSpace(record["ptype"].String()),
+		Subject: strings.TrimSpace(record["v0"].String()),
+	}
+	if item.Ptype == policyTypeG {
+		item.Role = strings.TrimSpace(record["v1"].String())
+		item.Domain = strings.TrimSpace(record["v2"].String())
+		return item
+	}
+	item.Domain = strings.TrimSpace(record["v1"].String())
+	item.Object = strings.TrimSpace(record["v2"].String())
+	item.Action = strings.TrimSpace(record["v3"].String())
+	item.Effect = policyEffect([]string{item.Subject, item.Domain, item.Object, item.Action, strings.TrimSpace(record["v4"].String())})
+	return item
+}
+
+func policyItemToRule(item *v1.PolicyItem) []string {
+	if item.Ptype == policyTypeG {
+		return []string{item.Subject, item.Role, item.Domain}
+	}
+	return []string{item.Subject, item.Domain, item.Object, item.Action, item.Effect}
+}
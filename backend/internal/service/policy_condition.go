@@ -0,0 +1,513 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/api/policy/v1"
+	"backend/internal/consts"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcache"
+)
+
+const (
+	policyConditionTable  = "sys_policy_condition"
+	policyConditionFields = "id, tenant_id as domain, subject, cidrs, time_windows, timezone, user_agent, remark"
+
+	// policyConditionDefaultCacheTTL bounds how long instances that did not
+	// make a change keep enforcing old conditions, when
+	// casbin.conditionCacheTTL is not set.
+	policyConditionDefaultCacheTTL = 30 * time.Second
+)
+
+// casbinConditionContext selects the r2/m2 request and matcher of the model,
+// which evaluate policy conditions against a *RequestEnv.
+var casbinConditionContext = casbin.EnforceContext{RType: "r2", PType: "p", EType: "e", MType: "m2"}
+
+var (
+	// authzNow is the clock requests are stamped with for time windows.
+	authzNow = time.Now
+
+	policyConditionCache = gcache.New()
+
+	policyConditionTTLOnce  sync.Once
+	policyConditionTTLValue time.Duration
+
+	// loadPolicyConditions reads the conditions of a domain.
+	loadPolicyConditions = func(ctx context.Context, domain string) ([]*v1.PolicyConditionItem, error) {
+		var items []*v1.PolicyConditionItem
+		err := g.DB().Ctx(ctx).Model(policyConditionTable).
+			Fields(policyConditionFields).
+			Where("tenant_id", domain).
+			OrderAsc("id").
+			Scan(&items)
+		return items, err
+	}
+
+	weekdays = map[string]time.Weekday{
+		"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+		"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	}
+)
+
+// RequestEnv carries the attributes of a request that policy conditions are
+// evaluated against, together with the conditions of the request's domain.
+type RequestEnv struct {
+	ClientIp  string
+	Time      time.Time
+	UserAgent string
+
+	conditions map[string]*policyCondition
+}
+
+// NewRequestEnv stamps a request of domain with the current time and loads
+// the domain's conditions.
+func NewRequestEnv(ctx context.Context, domain, clientIP, userAgent string) (*RequestEnv, error) {
+	conditions, err := domainConditions(ctx, NormalizeDomain(domain))
+	if err != nil {
+		return nil, err
+	}
+	return &RequestEnv{
+		ClientIp:   clientIP,
+		Time:       authzNow(),
+		UserAgent:  userAgent,
+		conditions: conditions,
+	}, nil
+}
+
+// HasCondition reports whether subject carries a condition.
+func (env *RequestEnv) HasCondition(subject string) bool {
+	_, ok := env.conditions[subject]
+	return ok
+}
+
+// Allows reports whether the condition of subject accepts the request.
+// Subjects without a condition accept every request.
+func (env *RequestEnv) Allows(subject string) bool {
+	condition, ok := env.conditions[subject]
+	if !ok {
+		return true
+	}
+	return condition.allows(env)
+}
+
+// conditionMatch implements conditionMatch(r2.env, p.sub) for the model.
+func conditionMatch(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return false, fmt.Errorf("conditionMatch expects 2 arguments, got %d", len(args))
+	}
+	env, ok := args[0].(*RequestEnv)
+	if !ok || env == nil {
+		return false, fmt.Errorf("conditionMatch expects a *RequestEnv, got %T", args[0])
+	}
+	subject, _ := args[1].(string)
+	return env.Allows(subject), nil
+}
+
+// policyCondition is the parsed form of a PolicyConditionItem.
+type policyCondition struct {
+	// invalid marks a stored condition that no longer parses.
+	invalid   bool
+	networks  []*net.IPNet
+	windows   []timeWindow
+	location  *time.Location
+	userAgent *regexp.Regexp
+}
+
+// allows reports whether env matches every restriction of the condition.
+func (c *policyCondition) allows(env *RequestEnv) bool {
+	if c.invalid {
+		return false
+	}
+	if len(c.networks) > 0 {
+		ip := net.ParseIP(strings.TrimSpace(env.ClientIp))
+		if ip == nil {
+			return false
+		}
+		matched := false
+		for _, network := range c.networks {
+			if network.Contains(ip) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(c.windows) > 0 {
+		if env.Time.IsZero() {
+			return false
+		}
+		local := env.Time.In(c.location)
+		matched := false
+		for _, window := range c.windows {
+			if window.contains(local) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if c.userAgent != nil && !c.userAgent.MatchString(env.UserAgent) {
+		return false
+	}
+	return true
+}
+
+// timeWindow is a daily period on some weekdays, in minutes after midnight.
+// A window whose end is not after its start runs past midnight into the
+// following day.
+type timeWindow struct {
+	days       [7]bool
+	start, end int
+}
+
+func (w timeWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.start < w.end {
+		return w.days[day] && minute >= w.start && minute < w.end
+	}
+	previous := (day + 6) % 7
+	return (w.days[day] && minute >= w.start) || (w.days[previous] && minute < w.end)
+}
+
+// compilePolicyCondition parses and validates a condition.
+func compilePolicyCondition(item *v1.PolicyConditionItem) (*policyCondition, error) {
+	condition := &policyCondition{location: time.UTC}
+	for _, cidr := range item.Cidrs {
+		network, err := parseNetwork(cidr)
+		if err != nil {
+			return nil, err
+		}
+		condition.networks = append(condition.networks, network)
+	}
+	for _, spec := range item.TimeWindows {
+		window, err := parseTimeWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		condition.windows = append(condition.windows, window)
+	}
+	if zone := strings.TrimSpace(item.Timezone); zone != "" {
+		location, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, gerror.NewCodef(consts.ErrorCodePolicyInvalid, "unknown timezone %s", zone)
+		}
+		condition.location = location
+	}
+	if pattern := strings.TrimSpace(item.UserAgent); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, gerror.NewCodef(consts.ErrorCodePolicyInvalid, "userAgent %s is not a valid pattern", pattern)
+		}
+		condition.userAgent = re
+	}
+	return condition, nil
+}
+
+// parseNetwork parses a CIDR, or an address standing for itself.
+func parseNetwork(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, gerror.NewCodef(consts.ErrorCodePolicyInvalid, "invalid network %s", value)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, gerror.NewCodef(consts.ErrorCodePolicyInvalid, "invalid network %s", value)
+	}
+	return network, nil
+}
+
+// parseTimeWindow parses "[days] HH:MM-HH:MM", where days is "*" or a comma
+// separated list of weekdays and weekday ranges such as "Mon-Fri,Sun".
+func parseTimeWindow(spec string) (timeWindow, error) {
+	var window timeWindow
+	invalid := gerror.NewCodef(consts.ErrorCodePolicyInvalid, "invalid time window %q", spec)
+	fields := strings.Fields(spec)
+	var days, hours string
+	switch len(fields) {
+	case 1:
+		days, hours = "*", fields[0]
+	case 2:
+		days, hours = fields[0], fields[1]
+	default:
+		return window, invalid
+	}
+
+	if days == "*" {
+		for i := range window.days {
+			window.days[i] = true
+		}
+	} else {
+		for _, part := range strings.Split(days, ",") {
+			from, to, isRange := strings.Cut(part, "-")
+			first, ok := weekdays[strings.ToLower(strings.TrimSpace(from))]
+			if !ok {
+				return window, invalid
+			}
+			last := first
+			if isRange {
+				if last, ok = weekdays[strings.ToLower(strings.TrimSpace(to))]; !ok {
+					return window, invalid
+				}
+			}
+			for day := first; ; day = (day + 1) % 7 {
+				window.days[day] = true
+				if day == last {
+					break
+				}
+			}
+		}
+	}
+
+	from, to, ok := strings.Cut(hours, "-")
+	if !ok {
+		return window, invalid
+	}
+	var err error
+	if window.start, err = parseClock(from); err != nil || window.start == 24*60 {
+		return window, invalid
+	}
+	if window.end, err = parseClock(to); err != nil {
+		return window, invalid
+	}
+	return window, nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight; "24:00" is accepted.
+func parseClock(value string) (int, error) {
+	hour, minute, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid clock %s", value)
+	}
+	h, err := strconv.Atoi(hour)
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.Atoi(minute)
+	if err != nil {
+		return 0, err
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid clock %s", value)
+	}
+	return h*60 + m, nil
+}
+
+// domainConditions returns the parsed conditions of domain by subject, cached
+// for casbin.conditionCacheTTL. A stored condition that no longer parses
+// accepts no request.
+func domainConditions(ctx context.Context, domain string) (map[string]*policyCondition, error) {
+	value, err := policyConditionCache.GetOrSetFuncLock(ctx, domain, func(ctx context.Context) (interface{}, error) {
+		items, err := loadPolicyConditions(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		conditions := make(map[string]*policyCondition, len(items))
+		for _, item := range items {
+			condition, err := compilePolicyCondition(item)
+			if err != nil {
+				g.Log().Warningf(ctx, "policy condition %d of %s is invalid and denies every request: %v", item.Id, domain, err)
+				condition = &policyCondition{invalid: true}
+			}
+			conditions[item.Subject] = condition
+		}
+		return conditions, nil
+	}, policyConditionCacheTTL(ctx))
+	if err != nil || value == nil || value.IsNil() {
+		return nil, err
+	}
+	conditions, _ := value.Val().(map[string]*policyCondition)
+	return conditions, nil
+}
+
+// policyConditionCacheTTL reads casbin.conditionCacheTTL once, as it is
+// consulted on every request.
+func policyConditionCacheTTL(ctx context.Context) time.Duration {
+	policyConditionTTLOnce.Do(func() {
+		policyConditionTTLValue = policyConditionDefaultCacheTTL
+		if v, err := g.Cfg().Get(ctx, "casbin.conditionCacheTTL"); err == nil && v != nil && !v.IsNil() {
+			policyConditionTTLValue = v.Duration()
+		}
+	})
+	return policyConditionTTLValue
+}
+
+// ListConditions returns the conditions of a domain, the caller's by default.
+func (s *sPolicy) ListConditions(ctx context.Context, domain string) (*v1.PolicyConditionListRes, error) {
+	op, err := loadPolicyOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if domain, err = op.resolveDomain(ctx, domain); err != nil {
+		return nil, err
+	}
+	items, err := loadPolicyConditions(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []*v1.PolicyConditionItem{}
+	}
+	return &v1.PolicyConditionListRes{Items: items}, nil
+}
+
+// SaveCondition creates or replaces the condition of a subject.
+func (s *sPolicy) SaveCondition(ctx context.Context, in v1.PolicyConditionSaveReq) (*v1.PolicyConditionItem, error) {
+	op, err := loadPolicyOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	domain, err := op.resolveDomain(ctx, in.Domain)
+	if err != nil {
+		return nil, err
+	}
+	item := &v1.PolicyConditionItem{
+		Domain:      domain,
+		Subject:     strings.TrimSpace(in.Subject),
+		Cidrs:       trimNonEmpty(in.Cidrs),
+		TimeWindows: trimNonEmpty(in.TimeWindows),
+		Timezone:    strings.TrimSpace(in.Timezone),
+		UserAgent:   strings.TrimSpace(in.UserAgent),
+		Remark:      strings.TrimSpace(in.Remark),
+	}
+	if item.Timezone == "" {
+		item.Timezone = "UTC"
+	}
+	if len(item.Cidrs) == 0 && len(item.TimeWindows) == 0 && item.UserAgent == "" {
+		return nil, gerror.NewCode(consts.ErrorCodePolicyInvalid, "a condition needs cidrs, timeWindows or userAgent")
+	}
+	if _, err = compilePolicyCondition(item); err != nil {
+		return nil, err
+	}
+	if err = validatePolicySubject(ctx, domain, item.Subject); err != nil {
+		return nil, err
+	}
+
+	var before *v1.PolicyConditionItem
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		err := g.DB().Ctx(ctx).Model(policyConditionTable).
+			Fields(policyConditionFields).
+			Where("tenant_id", domain).
+			Where("subject", item.Subject).
+			LockUpdate().
+			Scan(&before)
+		if err != nil {
+			return err
+		}
+		data := g.Map{
+			"tenant_id":    domain,
+			"subject":      item.Subject,
+			"cidrs":        gjson.MustEncodeString(item.Cidrs),
+			"time_windows": gjson.MustEncodeString(item.TimeWindows),
+			"timezone":     item.Timezone,
+			"user_agent":   item.UserAgent,
+			"remark":       item.Remark,
+		}
+		action := "policy_condition.create"
+		if before == nil {
+			if item.Id, err = g.DB().Ctx(ctx).Model(policyConditionTable).Data(data).InsertAndGetId(); err != nil {
+				return err
+			}
+		} else {
+			action = "policy_condition.update"
+			item.Id = before.Id
+			data["updated_at"] = time.Now()
+			if _, err = g.DB().Ctx(ctx).Model(policyConditionTable).Data(data).Where("id", item.Id).Update(); err != nil {
+				return err
+			}
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   domain,
+			OperatorId: op.user.Id,
+			Action:     action,
+			Resource:   policyConditionTable,
+			ResourceId: strconv.FormatInt(item.Id, 10),
+			Before:     before,
+			After:      item,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidatePolicyConditions(ctx, domain)
+	return item, nil
+}
+
+// DeleteCondition removes a condition of a domain the caller manages.
+func (s *sPolicy) DeleteCondition(ctx context.Context, id int64) error {
+	op, err := loadPolicyOperator(ctx)
+	if err != nil {
+		return err
+	}
+	var item *v1.PolicyConditionItem
+	err = g.DB().Ctx(ctx).Model(policyConditionTable).
+		Fields(policyConditionFields).
+		Where("id", id).
+		Scan(&item)
+	if err != nil {
+		return err
+	}
+	if item == nil || (!op.platform && item.Domain != op.domain) {
+		return gerror.NewCodef(consts.ErrorCodePolicyNotFound, "policy condition %d not found", id)
+	}
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if _, err := g.DB().Ctx(ctx).Model(policyConditionTable).Where("id", id).Delete(); err != nil {
+			return err
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   item.Domain,
+			OperatorId: op.user.Id,
+			Action:     "policy_condition.delete",
+			Resource:   policyConditionTable,
+			ResourceId: strconv.FormatInt(id, 10),
+			Before:     item,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	invalidatePolicyConditions(ctx, item.Domain)
+	return nil
+}
+
+// invalidatePolicyConditions drops the cached conditions of domain on this
+// instance; other instances pick the change up within the cache TTL.
+func invalidatePolicyConditions(ctx context.Context, domain string) {
+	if _, err := policyConditionCache.Remove(ctx, domain); err != nil {
+		g.Log().Warningf(ctx, "failed to invalidate policy conditions of %s: %v", domain, err)
+	}
+}
+
+func trimNonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
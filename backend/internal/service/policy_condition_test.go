@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/api/policy/v1"
+
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+// stubPolicyConditions serves conditions from memory and fixes the authz
+// clock at now.
+func stubPolicyConditions(tb testing.TB, now time.Time, items ...*v1.PolicyConditionItem) {
+	tb.Helper()
+	previous, previousNow := loadPolicyConditions, authzNow
+	loadPolicyConditions = func(ctx context.Context, domain string) ([]*v1.PolicyConditionItem, error) {
+		var result []*v1.PolicyConditionItem
+		for _, item := range items {
+			if item.Domain == domain {
+				result = append(result, item)
+			}
+		}
+		return result, nil
+	}
+	authzNow = func() time.Time { return now }
+	_ = policyConditionCache.Clear(context.Background())
+	tb.Cleanup(func() {
+		loadPolicyConditions, authzNow = previous, previousNow
+		_ = policyConditionCache.Clear(context.Background())
+	})
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	// 2026-03-02 is a Monday.
+	at := func(day int, clock string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", fmt.Sprintf("2026-03-%02d %s", day, clock))
+		if err != nil {
+			panic(err)
+		}
+		return parsed
+	}
+	cases := []struct {
+		spec string
+		at   time.Time
+		want bool
+	}{
+		{spec: "Mon-Fri 09:00-18:00", at: at(2, "09:00"), want: true},
+		{spec: "Mon-Fri 09:00-18:00", at: at(2, "17:59"), want: true},
+		{spec: "Mon-Fri 09:00-18:00", at: at(2, "18:00"), want: false},
+		{spec: "Mon-Fri 09:00-18:00", at: at(2, "08:59"), want: false},
+		{spec: "Mon-Fri 09:00-18:00", at: at(7, "10:00"), want: false},
+		{spec: "sat,SUN 10:00-14:00", at: at(7, "10:00"), want: true},
+		{spec: "Fri-Mon 00:00-24:00", at: at(8, "23:59"), want: true},
+		{spec: "Fri-Mon 00:00-24:00", at: at(3, "12:00"), want: false},
+		{spec: "08:00-12:00", at: at(4, "11:00"), want: true},
+		{spec: "* 08:00-12:00", at: at(7, "11:00"), want: true},
+		// Overnight windows belong to the day they start on.
+		{spec: "Mon 22:00-06:00", at: at(2, "23:00"), want: true},
+		{spec: "Mon 22:00-06:00", at: at(3, "05:59"), want: true},
+		{spec: "Mon 22:00-06:00", at: at(3, "06:00"), want: false},
+		{spec: "Mon 22:00-06:00", at: at(2, "05:00"), want: false},
+	}
+	gtest.C(t, func(t *gtest.T) {
+		for _, c := range cases {
+			window, err := parseTimeWindow(c.spec)
+			t.AssertNil(err)
+			t.Assert(window.contains(c.at), c.want)
+		}
+		for _, spec := range []string{"", "Mon", "Funday 09:00-10:00", "Mon 9-10", "Mon 09:00-25:00", "Mon 24:00-01:00", "Mon 09:60-10:00", "Mon Tue 09:00-10:00"} {
+			_, err := parseTimeWindow(spec)
+			t.AssertNE(err, nil)
+		}
+	})
+}
+
+func TestPolicyCondition_Allows(t *testing.T) {
+	// Monday 2026-03-02 08:30 UTC is 09:30 in Berlin.
+	monday := time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)
+	condition, err := compilePolicyCondition(&v1.PolicyConditionItem{
+		Cidrs:       []string{"10.0.0.0/8", "192.168.1.7", "2001:db8::/32"},
+		TimeWindows: []string{"Mon-Fri 09:00-18:00"},
+		Timezone:    "Europe/Berlin",
+		UserAgent:   "^Mozilla/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := func(ip string, at time.Time, ua string) *RequestEnv {
+		return &RequestEnv{ClientIp: ip, Time: at, UserAgent: ua}
+	}
+
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(condition.allows(env("10.1.2.3", monday, "Mozilla/5.0")), true)
+		t.Assert(condition.allows(env("192.168.1.7", monday, "Mozilla/5.0")), true)
+		t.Assert(condition.allows(env("2001:db8::1", monday, "Mozilla/5.0")), true)
+		t.Assert(condition.allows(env("192.168.1.8", monday, "Mozilla/5.0")), false)
+		t.Assert(condition.allows(env("", monday, "Mozilla/5.0")), false)
+		t.Assert(condition.allows(env("10.1.2.3", monday, "curl/8.0")), false)
+		// 07:30 UTC is 08:30 in Berlin, before business hours.
+		t.Assert(condition.allows(env("10.1.2.3", monday.Add(-time.Hour), "Mozilla/5.0")), false)
+		t.Assert(condition.allows(env("10.1.2.3", time.Time{}, "Mozilla/5.0")), false)
+
+		_, err := compilePolicyCondition(&v1.PolicyConditionItem{Cidrs: []string{"10.0.0.0/33"}})
+		t.AssertNE(err, nil)
+		_, err = compilePolicyCondition(&v1.PolicyConditionItem{Timezone: "Mars/Olympus"})
+		t.AssertNE(err, nil)
+		_, err = compilePolicyCondition(&v1.PolicyConditionItem{UserAgent: "("})
+		t.AssertNE(err, nil)
+	})
+}
+
+func TestEvaluateSubjects_Conditions(t *testing.T) {
+	businessHours := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	stubPolicyConditions(t, businessHours,
+		&v1.PolicyConditionItem{Domain: "t1", Subject: "admin", Cidrs: []string{"10.0.0.0/8"}, TimeWindows: []string{"Mon-Fri 09:00-18:00"}},
+		&v1.PolicyConditionItem{Domain: "t1", Subject: "auditor", Cidrs: []string{"10.0.0.0/8"}},
+		&v1.PolicyConditionItem{Domain: "t2", Subject: "admin", Cidrs: []string{"172.16.0.0/12"}},
+	)
+	cache := newTestEnforcerCache(t, 10, newMemoryRules(
+		"p, admin, t1, /system/*, .*, allow",
+		"p, staff, t1, /system/menu, get, allow",
+		"p, auditor, t1, /system/audit, get, deny",
+		"p, admin, t2, /system/*, .*, allow",
+		"g, alice, admin, t1",
+	))
+	enforcer, err := cache.Get("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	cases := []struct {
+		name     string
+		subjects []string
+		obj      string
+		ip       string
+		clock    time.Time
+		want     bool
+	}{
+		{name: "office during business hours", subjects: []string{"alice", "admin"}, obj: "/system/user", ip: "10.0.0.5", clock: businessHours, want: true},
+		{name: "role inherited through g", subjects: []string{"alice"}, obj: "/system/user", ip: "10.0.0.5", clock: businessHours, want: true},
+		{name: "outside office network", subjects: []string{"alice", "admin"}, obj: "/system/user", ip: "203.0.113.9", clock: businessHours, want: false},
+		{name: "after hours", subjects: []string{"alice", "admin"}, obj: "/system/user", ip: "10.0.0.5", clock: businessHours.Add(9 * time.Hour), want: false},
+		{name: "weekend", subjects: []string{"alice", "admin"}, obj: "/system/user", ip: "10.0.0.5", clock: businessHours.AddDate(0, 0, 5), want: false},
+		{name: "unconditioned role still applies", subjects: []string{"bob", "staff", "admin"}, obj: "/system/menu", ip: "203.0.113.9", clock: businessHours, want: true},
+		{name: "deny rules ignore conditions", subjects: []string{"carol", "auditor", "admin"}, obj: "/system/audit", ip: "203.0.113.9", clock: businessHours, want: false},
+	}
+	gtest.C(t, func(t *gtest.T) {
+		for _, c := range cases {
+			authzNow = func() time.Time { return c.clock }
+			env, err := NewRequestEnv(ctx, "t1", c.ip, "Mozilla/5.0")
+			t.AssertNil(err)
+			allowed, _, err := EvaluateSubjects(enforcer, c.subjects, "t1", c.obj, "get", env)
+			t.AssertNil(err)
+			t.Assert(allowed, c.want)
+		}
+
+		// Without an env conditions are not evaluated.
+		allowed, _, err := EvaluateSubjects(enforcer, []string{"admin"}, "t1", "/system/user", "get", nil)
+		t.AssertNil(err)
+		t.Assert(allowed, true)
+
+		// Conditions are per domain.
+		authzNow = func() time.Time { return businessHours }
+		other, err := cache.Get("t2")
+		t.AssertNil(err)
+		env, err := NewRequestEnv(ctx, "t2", "10.0.0.5", "")
+		t.AssertNil(err)
+		allowed, _, err = EvaluateSubjects(other, []string{"admin"}, "t2", "/system/user", "get", env)
+		t.AssertNil(err)
+		t.Assert(allowed, false)
+		env.ClientIp = "172.16.4.4"
+		allowed, _, err = EvaluateSubjects(other, []string{"admin"}, "t2", "/system/user", "get", env)
+		t.AssertNil(err)
+		t.Assert(allowed, true)
+	})
+}
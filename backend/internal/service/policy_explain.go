@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"backend/api/policy/v1"
+	"backend/internal/consts"
+	"backend/internal/dao"
+	"backend/internal/model/entity"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/util"
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+// explainNearestLimit bounds the policies returned as nearest to a request.
+const explainNearestLimit = 10
+
+// Explain evaluates a request the way CasbinAuthz does and reports the
+// subjects, rules and access codes involved.
+func (s *sPolicy) Explain(ctx context.Context, in v1.PolicyExplainReq) (*v1.PolicyExplainRes, error) {
+	op, err := loadPolicyOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	domain, err := op.resolveDomain(ctx, in.TenantId)
+	if err != nil {
+		return nil, err
+	}
+	var user entity.SysUser
+	if err = dao.SysUser.Ctx(ctx).Where(dao.SysUser.Columns().Id, strings.TrimSpace(in.UserId)).Scan(&user); err != nil {
+		return nil, err
+	}
+	if user.Id == "" || (!op.platform && NormalizeDomain(user.TenantId) != domain) {
+		return nil, gerror.NewCodef(consts.ErrorCodeUserNotFound, "user %s not found", in.UserId)
+	}
+
+	obj := in.Path
+	if i := strings.IndexByte(obj, '?'); i >= 0 {
+		obj = obj[:i]
+	}
+	act := strings.ToLower(strings.TrimSpace(in.Method))
+
+	enforcer, err := Casbin(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	granted, err := grantedRoles(ctx, user.Id, domain)
+	if err != nil {
+		return nil, err
+	}
+	roles, subjects := AuthzSubjects(&user, granted...)
+	if domain == consts.PlatformDomain {
+		// Tenant roles do not apply in the platform domain.
+		roles, subjects = []string{}, []string{user.Id}
+	}
+	env, err := NewRequestEnv(ctx, domain, strings.TrimSpace(in.ClientIp), in.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	if in.Time != nil {
+		env.Time = in.Time.Time
+	}
+	allowed, decisions, err := EvaluateSubjects(enforcer, subjects, domain, obj, act, env)
+	if err != nil {
+		return nil, err
+	}
+	implicitRoles, err := enforcer.GetImplicitRolesForUser(user.Id, domain)
+	if err != nil {
+		return nil, err
+	}
+	nearest, err := nearestPolicies(enforcer, subjects, domain, obj, act)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := accessCodes(ctx, enforcer, domain, subjects)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &v1.PolicyExplainRes{
+		UserId:          user.Id,
+		Domain:          domain,
+		Path:            obj,
+		Method:          act,
+		Allowed:         allowed,
+		Roles:           roles,
+		ImplicitRoles:   implicitRoles,
+		Subjects:        make([]*v1.PolicyExplainSubject, 0, len(decisions)),
+		NearestPolicies: nearest,
+		AccessCodes:     codes,
+		Conditions:      explainConditions(env, slices.Concat(subjects, implicitRoles)),
+	}
+	for _, decision := range decisions {
+		subject := &v1.PolicyExplainSubject{Subject: decision.Subject, Allowed: decision.Allowed}
+		if len(decision.Rule) > 0 {
+			subject.Rule = explainRule(decision.Rule, obj, act)
+		}
+		res.Subjects = append(res.Subjects, subject)
+	}
+	return res, nil
+}
+
+// nearestPolicies returns the rules granted to subjects in domain, directly
+// or through roles, ordered by how closely they match the request: rules
+// matching both object and action first, then object only, then action
+// only, then by the longest common prefix with the object.
+func nearestPolicies(enforcer casbin.IEnforcer, subjects []string, domain, obj, act string) ([]*v1.PolicyExplainRule, error) {
+	seen := make(map[string]struct{})
+	rules := make([]*v1.PolicyExplainRule, 0)
+	for _, subject := range subjects {
+		permissions, err := enforcer.GetImplicitPermissionsForUser(subject, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, permission := range permissions {
+			key := strings.Join(permission, ",")
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			if rule := explainRule(permission, obj, act); rule != nil {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	score := func(rule *v1.PolicyExplainRule) int {
+		value := 0
+		if rule.ObjectMatch {
+			value += 2
+		}
+		if rule.ActionMatch {
+			value++
+		}
+		return value
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if si, sj := score(rules[i]), score(rules[j]); si != sj {
+			return si > sj
+		}
+		return commonPrefixLen(rules[i].Object, obj) > commonPrefixLen(rules[j].Object, obj)
+	})
+	if len(rules) > explainNearestLimit {
+		rules = rules[:explainNearestLimit]
+	}
+	return rules, nil
+}
+
+// explainRule describes a "p" rule against a request, using the same object
+// and action matching as the casbin model.
+func explainRule(rule []string, obj, act string) *v1.PolicyExplainRule {
+	if len(rule) < 4 {
+		return nil
+	}
+	item := &v1.PolicyExplainRule{
+		Subject: rule[0],
+		Domain:  rule[1],
+		Object:  rule[2],
+		Action:  rule[3],
+		Effect:  policyEffect(rule),
+	}
+	item.ObjectMatch = item.Object == "*" || util.KeyMatch2(obj, item.Object) || util.KeyMatch5(obj, item.Object)
+	if item.Action == "*" {
+		item.ActionMatch = true
+	} else if matched, err := regexp.MatchString("^("+item.Action+")$", act); err == nil {
+		item.ActionMatch = matched
+	}
+	return item
+}
+
+func commonPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// explainConditions reports the conditions carried by subjects.
+func explainConditions(env *RequestEnv, subjects []string) []*v1.PolicyExplainCondition {
+	seen := make(map[string]struct{}, len(subjects))
+	conditions := make([]*v1.PolicyExplainCondition, 0)
+	for _, subject := range subjects {
+		if _, ok := seen[subject]; ok || !env.HasCondition(subject) {
+			continue
+		}
+		seen[subject] = struct{}{}
+		conditions = append(conditions, &v1.PolicyExplainCondition{Subject: subject, Matched: env.Allows(subject)})
+	}
+	return conditions
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+func TestNearestPolicies(t *testing.T) {
+	enforcer := newTestEnforcer(t,
+		"p, staff, t1, /system/dept/:id, get, allow",
+		"p, staff, t1, /system/menu/list, get, allow",
+		"p, staff, t1, /system/dept/:id, delete, deny",
+		"p, admin, t1, /tenant/*, .*, allow",
+		"p, staff, t2, /system/dept/:id, put, allow",
+		"g, alice, staff, t1",
+	)
+	gtest.C(t, func(t *gtest.T) {
+		rules, err := nearestPolicies(enforcer, []string{"alice", "staff"}, "t1", "/system/dept/7", "put")
+		t.AssertNil(err)
+		t.Assert(len(rules), 3)
+
+		t.Assert(rules[0].Object, "/system/dept/:id")
+		t.Assert(rules[0].ObjectMatch, true)
+		t.Assert(rules[0].ActionMatch, false)
+		t.Assert(rules[1].Object, "/system/dept/:id")
+		t.Assert(rules[1].Effect, PolicyEffectDeny)
+		t.Assert(rules[2].Object, "/system/menu/list")
+		t.Assert(rules[2].ObjectMatch, false)
+
+		rules, err = nearestPolicies(enforcer, []string{"alice", "staff"}, "t1", "/system/menu/list", "get")
+		t.AssertNil(err)
+		t.Assert(rules[0].Object, "/system/menu/list")
+		t.Assert(rules[0].ObjectMatch && rules[0].ActionMatch, true)
+	})
+}
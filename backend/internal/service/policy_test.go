@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+
+	"backend/api/policy/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+func TestValidatePolicyPattern(t *testing.T) {
+	testCases := []struct {
+		name   string
+		object string
+		action string
+		effect string
+		ok     bool
+	}{
+		{name: "path parameter", object: "/system/dept/:id", action: "get", effect: PolicyEffectAllow, ok: true},
+		{name: "multi method", object: "/system/*", action: "(get)|(post)", effect: PolicyEffectDeny, ok: true},
+		{name: "wildcards", object: "*", action: "*", effect: PolicyEffectAllow, ok: true},
+		{name: "relative object", object: "system/dept", action: "get", effect: PolicyEffectAllow},
+		{name: "missing object", object: "", action: "get", effect: PolicyEffectAllow},
+		{name: "missing action", object: "/system", action: "", effect: PolicyEffectAllow},
+		{name: "broken regex", object: "/system", action: "(get", effect: PolicyEffectAllow},
+		{name: "unknown effect", object: "/system", action: "get", effect: "maybe"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			gtest.C(t, func(t *gtest.T) {
+				err := validatePolicyPattern(tc.object, tc.action, tc.effect)
+				if tc.ok {
+					t.AssertNil(err)
+					return
+				}
+				t.Assert(gerror.Code(err), consts.ErrorCodePolicyInvalid)
+			})
+		})
+	}
+}
+
+func TestPolicyItemMapping(t *testing.T) {
+	record := func(values map[string]interface{}) gdb.Record {
+		r := gdb.Record{}
+		for key, value := range values {
+			r[key] = gvar.New(value)
+		}
+		return r
+	}
+	gtest.C(t, func(t *gtest.T) {
+		item := recordToPolicyItem(record(map[string]interface{}{
+			"id": 7, "ptype": "p", "v0": "admin", "v1": "t1", "v2": "/tenant/billing/*", "v3": ".*", "v4": "deny",
+		}))
+		t.Assert(item, &v1.PolicyItem{
+			Id: 7, Ptype: "p", Subject: "admin", Domain: "t1", Object: "/tenant/billing/*", Action: ".*", Effect: PolicyEffectDeny,
+		})
+		t.Assert(policyItemToRule(item), []string{"admin", "t1", "/tenant/billing/*", ".*", PolicyEffectDeny})
+
+		legacy := recordToPolicyItem(record(map[string]interface{}{
+			"id": 8, "ptype": "p", "v0": "staff", "v1": "t1", "v2": "/*", "v3": ".*",
+		}))
+		t.Assert(legacy.Effect, PolicyEffectAllow)
+
+		grouping := recordToPolicyItem(record(map[string]interface{}{
+			"id": 9, "ptype": "g", "v0": "alice", "v1": "admin", "v2": "t1",
+		}))
+		t.Assert(grouping, &v1.PolicyItem{Id: 9, Ptype: "g", Subject: "alice", Role: "admin", Domain: "t1"})
+		t.Assert(policyItemToRule(grouping), []string{"alice", "admin", "t1"})
+	})
+}
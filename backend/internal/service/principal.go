@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/consts"
+	"backend/internal/dao"
+	"backend/internal/model/entity"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcache"
+)
+
+// userCacheDefaultTTL bounds how long a changed user may be served stale by
+// instances that did not make the change, when auth.userCacheTTL is not set.
+const userCacheDefaultTTL = 30 * time.Second
+
+var (
+	userCache = gcache.New()
+
+	userCacheTTLOnce  sync.Once
+	userCacheTTLValue time.Duration
+
+	// loadUser reads a user from storage; it returns nil when none exists.
+	loadUser = func(ctx context.Context, id string) (*entity.SysUser, error) {
+		var user *entity.SysUser
+		if err := dao.SysUser.Ctx(ctx).Where(dao.SysUser.Columns().Id, id).Scan(&user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+)
+
+// Principal is the authenticated caller of a request. CasbinAuthz resolves it
+// once and stores it in the request context for handlers and services.
+type Principal struct {
+	UserId string
+	// TenantId is the tenant the request acts in: the token's tenantId
+	// claim, else the user's tenant.
+	TenantId string
+	// Roles are the roles used for authorization: the user's roles plus the
+	// time-bound grants active in TenantId. Users without any are treated as
+	// super while they are marked legacy_super, see AuthzSubjects.
+	Roles []string
+	// TokenId is the jti claim of the access token.
+	TokenId string
+	// User is shared with the user cache and must not be modified.
+	User *entity.SysUser
+
+	dataScopeOnce sync.Once
+	dataScope     *DataScope
+	dataScopeErr  error
+
+	accessCodesOnce sync.Once
+	accessCodes     []string
+	accessCodesErr  error
+}
+
+type principalCtxKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, p)
+}
+
+// PrincipalFromCtx returns the principal stored by WithPrincipal, or nil.
+func PrincipalFromCtx(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalCtxKey{}).(*Principal)
+	return p
+}
+
+// ResolvePrincipal returns the principal of the current request, from the
+// context when the middleware already resolved it, otherwise by validating
+// the request's access token.
+func ResolvePrincipal(ctx context.Context) (*Principal, error) {
+	if p := PrincipalFromCtx(ctx); p != nil {
+		return p, nil
+	}
+	token, err := resolveAccessToken(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return principalFromToken(ctx, token)
+}
+
+// principalFromToken validates an access token and loads its user.
+func principalFromToken(ctx context.Context, token string) (*Principal, error) {
+	claims, err := parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	userID, _ := claims["id"].(string)
+	if userID == "" {
+		return nil, gerror.NewCode(consts.ErrorCodeUnauthorized, "invalid token subject")
+	}
+	user, err := cachedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, gerror.NewCode(consts.ErrorCodeUserNotFound, "user not found")
+	}
+	tenantID := user.TenantId
+	if claimTenant, ok := claims["tenantId"].(string); ok && strings.TrimSpace(claimTenant) != "" {
+		tenantID = claimTenant
+	}
+	granted, err := grantedRoles(ctx, user.Id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	tokenID, _ := claims["jti"].(string)
+	roles, _ := AuthzSubjects(user, granted...)
+	return &Principal{
+		UserId:   user.Id,
+		TenantId: tenantID,
+		Roles:    roles,
+		TokenId:  tokenID,
+		User:     user,
+	}, nil
+}
+
+// cachedUser returns the user with id for up to auth.userCacheTTL, or nil
+// when it does not exist. Missing users are not cached.
+func cachedUser(ctx context.Context, id string) (*entity.SysUser, error) {
+	value, err := userCache.GetOrSetFuncLock(ctx, userCacheKey(id), func(ctx context.Context) (interface{}, error) {
+		user, err := loadUser(ctx, id)
+		if err != nil || user == nil || user.Id == "" {
+			return nil, err
+		}
+		return user, nil
+	}, userCacheTTL(ctx))
+	if err != nil || value == nil || value.IsNil() {
+		return nil, err
+	}
+	user, _ := value.Val().(*entity.SysUser)
+	return user, nil
+}
+
+// InvalidateUserCache drops cached users and their role grants so the next
+// request reloads them. Call it after changing a user's tenant, department,
+// profile, roles, grants or status, or deleting it.
+func InvalidateUserCache(ctx context.Context, ids ...string) {
+	keys := make([]interface{}, 0, 2*len(ids))
+	for _, id := range ids {
+		keys = append(keys, userCacheKey(id), roleGrantCacheKey(id))
+	}
+	if _, err := userCache.Remove(ctx, keys...); err != nil {
+		g.Log().Warningf(ctx, "failed to invalidate user cache: %v", err)
+	}
+}
+
+// Subjects returns the casbin subjects checked for the principal: the user
+// followed by its roles.
+func (p *Principal) Subjects() []string {
+	return append([]string{p.UserId}, p.Roles...)
+}
+
+func userCacheKey(id string) string {
+	return "user:" + id
+}
+
+// userCacheTTL reads auth.userCacheTTL once, as it is consulted on every request.
+func userCacheTTL(ctx context.Context) time.Duration {
+	userCacheTTLOnce.Do(func() {
+		userCacheTTLValue = userCacheDefaultTTL
+		if v, err := g.Cfg().Get(ctx, "auth.userCacheTTL"); err == nil && v != nil && !v.IsNil() {
+			userCacheTTLValue = v.Duration()
+		}
+	})
+	return userCacheTTLValue
+}
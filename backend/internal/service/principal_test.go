@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"backend/internal/model/entity"
+
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+// stubLoadUser serves users from memory and counts the loads, each of which
+// stands in for one sys_user query. Users have no role grants unless
+// stubRoleGrants is also called.
+func stubLoadUser(tb testing.TB, users ...*entity.SysUser) *atomic.Int64 {
+	tb.Helper()
+	var loads atomic.Int64
+	previous := loadUser
+	previousGrants := loadRoleGrants
+	loadRoleGrants = func(ctx context.Context, userID string) ([]*roleGrant, error) {
+		return nil, nil
+	}
+	loadUser = func(ctx context.Context, id string) (*entity.SysUser, error) {
+		loads.Add(1)
+		for _, user := range users {
+			if user.Id == id {
+				copied := *user
+				return &copied, nil
+			}
+		}
+		return nil, nil
+	}
+	tb.Cleanup(func() {
+		loadUser = previous
+		loadRoleGrants = previousGrants
+		_ = userCache.Clear(context.Background())
+	})
+	return &loads
+}
+
+func TestPrincipalFromToken(t *testing.T) {
+	user := &entity.SysUser{Id: "u1", Username: "alice", TenantId: "t1", Roles: `["admin"]`}
+	stubLoadUser(t, user)
+	token, err := (&sAuth{}).generateAccessToken(user)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	ctx := context.Background()
+
+	gtest.C(t, func(t *gtest.T) {
+		p, err := principalFromToken(ctx, token)
+		t.AssertNil(err)
+		t.Assert(p.UserId, "u1")
+		t.Assert(p.TenantId, "t1")
+		t.Assert(p.Roles, []string{"admin"})
+		t.AssertNE(p.TokenId, "")
+
+		t.AssertNil(PrincipalFromCtx(ctx))
+		resolved, err := ResolvePrincipal(WithPrincipal(ctx, p))
+		t.AssertNil(err)
+		t.Assert(resolved == p, true)
+
+		_, err = principalFromToken(ctx, "not-a-token")
+		t.AssertNE(err, nil)
+	})
+}
+
+func TestCachedUser_Invalidate(t *testing.T) {
+	loads := stubLoadUser(t, &entity.SysUser{Id: "u1", TenantId: "t1"})
+	ctx := context.Background()
+
+	gtest.C(t, func(t *gtest.T) {
+		for i := 0; i < 3; i++ {
+			user, err := cachedUser(ctx, "u1")
+			t.AssertNil(err)
+			t.Assert(user.Id, "u1")
+		}
+		t.Assert(loads.Load(), 1)
+
+		InvalidateUserCache(ctx, "u1")
+		_, err := cachedUser(ctx, "u1")
+		t.AssertNil(err)
+		t.Assert(loads.Load(), 2)
+
+		// Missing users are looked up again rather than cached.
+		for i := 0; i < 2; i++ {
+			user, err := cachedUser(ctx, "missing")
+			t.AssertNil(err)
+			t.AssertNil(user)
+		}
+		t.Assert(loads.Load(), 4)
+	})
+}
+
+// BenchmarkRequestIdentity compares resolving the caller for one request that
+// reaches GET /user/info: previously the middleware, the user service and the
+// access code lookup each parsed the token and queried sys_user; now the
+// middleware resolves a cached principal once and the others read the context.
+func BenchmarkRequestIdentity(b *testing.B) {
+	user := &entity.SysUser{Id: "u1", Username: "alice", TenantId: "t1", Roles: `["admin"]`}
+	token, err := (&sAuth{}).generateAccessToken(user)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+
+	b.Run("before", func(b *testing.B) {
+		loads := stubLoadUser(b, user)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 3; j++ {
+				claims, err := parseToken(token)
+				if err != nil {
+					b.Fatal(err)
+				}
+				id, _ := claims["id"].(string)
+				if _, err = loadUser(ctx, id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(loads.Load())/float64(b.N), "queries/op")
+	})
+
+	b.Run("after", func(b *testing.B) {
+		loads := stubLoadUser(b, user)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			p, err := principalFromToken(ctx, token)
+			if err != nil {
+				b.Fatal(err)
+			}
+			reqCtx := WithPrincipal(ctx, p)
+			for j := 0; j < 2; j++ {
+				if _, err = ResolvePrincipal(reqCtx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(loads.Load())/float64(b.N), "queries/op")
+	})
+}
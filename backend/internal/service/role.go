@@ -0,0 +1,672 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/api/role/v1"
+	"backend/internal/consts"
+	"backend/internal/dao"
+	"backend/internal/model/do"
+	"backend/internal/model/entity"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+const (
+	roleRequestTable = "sys_role_request"
+
+	RoleRequestPending  = "pending"
+	RoleRequestApproved = "approved"
+	RoleRequestRejected = "rejected"
+
+	// roleRequestReviewCode is the access code of the users who review role
+	// requests.
+	roleRequestReviewCode = "System:RoleRequest:Review"
+)
+
+var (
+	localRole IRole
+
+	// loadRoleApproverCandidates returns the live, enabled users of domain
+	// and the unexpired role grants held in domain.
+	loadRoleApproverCandidates = func(ctx context.Context, domain string) ([]*entity.SysUser, []*roleGrant, error) {
+		columns := dao.SysUser.Columns()
+		var users []*entity.SysUser
+		err := dao.SysUser.Ctx(ctx).
+			Fields(columns.Id, columns.TenantId, columns.Roles, columns.LegacySuper).
+			Where(columns.TenantId, domain).
+			Where(columns.Status, 1).
+			WhereNull(columns.DeletedAt).
+			OrderAsc(columns.Username).
+			Scan(&users)
+		if err != nil {
+			return nil, nil, err
+		}
+		var grants []*roleGrant
+		err = g.DB().Ctx(ctx).Model(roleGrantTable).
+			Where("tenant_id", domain).
+			Where("(expires_at IS NULL OR expires_at > ?)", roleGrantNow()).
+			Scan(&grants)
+		if err != nil {
+			return nil, nil, err
+		}
+		return users, grants, nil
+	}
+)
+
+// Role returns the role service instance.
+func Role() IRole {
+	return localRole
+}
+
+// RegisterRole sets the instance used by role related handlers.
+func RegisterRole(i IRole) {
+	localRole = i
+}
+
+var _ IRole = (*sRole)(nil)
+
+func init() {
+	RegisterRole(NewRole())
+}
+
+// NewRole creates a new role service instance.
+func NewRole() *sRole {
+	return &sRole{}
+}
+
+// IRole defines time-bound role grants and the role request workflow.
+type IRole interface {
+	ListGrants(ctx context.Context, in v1.RoleGrantListReq) (*v1.RoleGrantListRes, error)
+	CreateGrant(ctx context.Context, in v1.RoleGrantCreateReq) (*v1.RoleGrantItem, error)
+	DeleteGrant(ctx context.Context, id int64) error
+	CreateRequest(ctx context.Context, in v1.RoleRequestCreateReq) (*v1.RoleRequestItem, error)
+	MyRequests(ctx context.Context, in v1.RoleRequestMineReq) (*v1.RoleRequestListRes, error)
+	ListRequests(ctx context.Context, in v1.RoleRequestListReq) (*v1.RoleRequestListRes, error)
+	Approve(ctx context.Context, id int64, comment string) (*v1.RoleRequestApproveRes, error)
+	Reject(ctx context.Context, id int64, comment string) (*v1.RoleRequestItem, error)
+	ListMenus(ctx context.Context, role string) (*v1.RoleMenuListRes, error)
+	SaveMenus(ctx context.Context, role string, menuIds []string) (*v1.RoleMenuListRes, error)
+}
+
+type sRole struct{}
+
+// roleRequest is a sys_role_request row.
+type roleRequest struct {
+	Id            int64       `orm:"id"`
+	TenantId      string      `orm:"tenant_id"`
+	UserId        string      `orm:"user_id"`
+	RoleCode      string      `orm:"role_code"`
+	StartsAt      *gtime.Time `orm:"starts_at"`
+	ExpiresAt     *gtime.Time `orm:"expires_at"`
+	Reason        string      `orm:"reason"`
+	Status        string      `orm:"status"`
+	ReviewerId    string      `orm:"reviewer_id"`
+	ReviewComment string      `orm:"review_comment"`
+	ReviewedAt    *gtime.Time `orm:"reviewed_at"`
+	CreatedAt     *gtime.Time `orm:"created_at"`
+}
+
+// ListGrants returns the grants of the caller's tenant, including expired
+// ones not purged yet.
+func (s *sRole) ListGrants(ctx context.Context, in v1.RoleGrantListReq) (*v1.RoleGrantListRes, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	model := g.DB().Ctx(ctx).Model(roleGrantTable).Where("tenant_id", NormalizeDomain(p.TenantId))
+	if userID := strings.TrimSpace(in.UserId); userID != "" {
+		model = model.Where("user_id", userID)
+	}
+	if role := strings.TrimSpace(in.Role); role != "" {
+		model = model.Where("role_code", role)
+	}
+	var grants []*roleGrant
+	total := 0
+	if err = model.OrderDesc("id").Page(in.Page, in.PageSize).ScanAndCount(&grants, &total, false); err != nil {
+		return nil, err
+	}
+	now := roleGrantNow()
+	items := make([]*v1.RoleGrantItem, 0, len(grants))
+	for _, grant := range grants {
+		items = append(items, grantToItem(grant, now))
+	}
+	return &v1.RoleGrantListRes{Items: items, Total: total}, nil
+}
+
+// CreateGrant assigns a role of the caller's tenant to one of its users for
+// the given period.
+func (s *sRole) CreateGrant(ctx context.Context, in v1.RoleGrantCreateReq) (*v1.RoleGrantItem, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	domain := NormalizeDomain(p.TenantId)
+	role := strings.TrimSpace(in.Role)
+	if err = validateRoleGrant(ctx, domain, in.UserId, role, in.StartsAt, in.ExpiresAt); err != nil {
+		return nil, err
+	}
+	grant := &roleGrant{
+		TenantId:  domain,
+		UserId:    in.UserId,
+		RoleCode:  role,
+		StartsAt:  in.StartsAt,
+		ExpiresAt: in.ExpiresAt,
+		Reason:    strings.TrimSpace(in.Reason),
+		GrantedBy: p.UserId,
+	}
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if err := insertRoleGrant(ctx, grant); err != nil {
+			return err
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   domain,
+			OperatorId: p.UserId,
+			Action:     "role_grant.create",
+			Resource:   roleGrantTable,
+			ResourceId: strconv.FormatInt(grant.Id, 10),
+			After:      grant,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	InvalidateUserCache(ctx, grant.UserId)
+	return grantToItem(grant, roleGrantNow()), nil
+}
+
+// DeleteGrant revokes a grant of the caller's tenant.
+func (s *sRole) DeleteGrant(ctx context.Context, id int64) error {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	var grant *roleGrant
+	err = g.DB().Ctx(ctx).Model(roleGrantTable).
+		Where("id", id).
+		Where("tenant_id", NormalizeDomain(p.TenantId)).
+		Scan(&grant)
+	if err != nil {
+		return err
+	}
+	if grant == nil {
+		return gerror.NewCodef(consts.ErrorCodeRoleGrantNotFound, "role grant %d not found", id)
+	}
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if _, err := g.DB().Ctx(ctx).Model(roleGrantTable).Where("id", id).Delete(); err != nil {
+			return err
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   grant.TenantId,
+			OperatorId: p.UserId,
+			Action:     "role_grant.delete",
+			Resource:   roleGrantTable,
+			ResourceId: strconv.FormatInt(id, 10),
+			Before:     grant,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	InvalidateUserCache(ctx, grant.UserId)
+	return nil
+}
+
+// CreateRequest records the caller's request for a role of its tenant.
+func (s *sRole) CreateRequest(ctx context.Context, in v1.RoleRequestCreateReq) (*v1.RoleRequestItem, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	domain := NormalizeDomain(p.TenantId)
+	role := strings.TrimSpace(in.Role)
+	if err = validateRoleGrant(ctx, domain, p.UserId, role, in.StartsAt, in.ExpiresAt); err != nil {
+		return nil, err
+	}
+	pending, err := g.DB().Ctx(ctx).Model(roleRequestTable).
+		Where("tenant_id", domain).
+		Where("user_id", p.UserId).
+		Where("role_code", role).
+		Where("status", RoleRequestPending).
+		Count()
+	if err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, gerror.NewCodef(consts.ErrorCodeRoleGrantInvalid, "a request for role %s is already pending", role)
+	}
+	enforcer, err := Casbin(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	request := &roleRequest{
+		TenantId:  domain,
+		UserId:    p.UserId,
+		RoleCode:  role,
+		StartsAt:  in.StartsAt,
+		ExpiresAt: in.ExpiresAt,
+		Reason:    strings.TrimSpace(in.Reason),
+		Status:    RoleRequestPending,
+	}
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		id, err := g.DB().Ctx(ctx).Model(roleRequestTable).Data(g.Map{
+			"tenant_id":  request.TenantId,
+			"user_id":    request.UserId,
+			"role_code":  request.RoleCode,
+			"starts_at":  request.StartsAt,
+			"expires_at": request.ExpiresAt,
+			"reason":     request.Reason,
+			"status":     request.Status,
+		}).InsertAndGetId()
+		if err != nil {
+			return err
+		}
+		request.Id = id
+		if err = writeAuditLog(ctx, auditEntry{
+			TenantId:   domain,
+			OperatorId: p.UserId,
+			Action:     "role_request.create",
+			Resource:   roleRequestTable,
+			ResourceId: strconv.FormatInt(id, 10),
+			After:      request,
+		}); err != nil {
+			return err
+		}
+		return notifyRoleRequestCreated(ctx, enforcer, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return requestToItem(request), nil
+}
+
+// MyRequests returns the caller's role requests, newest first.
+func (s *sRole) MyRequests(ctx context.Context, in v1.RoleRequestMineReq) (*v1.RoleRequestListRes, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	model := g.DB().Ctx(ctx).Model(roleRequestTable).Where("user_id", p.UserId)
+	return listRoleRequests(model, in.Page, in.PageSize)
+}
+
+// ListRequests returns the role requests of the caller's tenant.
+func (s *sRole) ListRequests(ctx context.Context, in v1.RoleRequestListReq) (*v1.RoleRequestListRes, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	model := g.DB().Ctx(ctx).Model(roleRequestTable).Where("tenant_id", NormalizeDomain(p.TenantId))
+	if in.Status != "" {
+		model = model.Where("status", in.Status)
+	}
+	if userID := strings.TrimSpace(in.UserId); userID != "" {
+		model = model.Where("user_id", userID)
+	}
+	return listRoleRequests(model, in.Page, in.PageSize)
+}
+
+// Approve grants the requested role for the requested period and notifies
+// the requester and the reviewer.
+func (s *sRole) Approve(ctx context.Context, id int64, comment string) (*v1.RoleRequestApproveRes, error) {
+	var grant *roleGrant
+	request, err := s.review(ctx, id, RoleRequestApproved, comment, func(ctx context.Context, request *roleRequest) error {
+		// The role may have been removed, or the period may have passed,
+		// since the request was made.
+		if err := validateRoleGrant(ctx, request.TenantId, request.UserId, request.RoleCode, request.StartsAt, request.ExpiresAt); err != nil {
+			return err
+		}
+		grant = &roleGrant{
+			TenantId:  request.TenantId,
+			UserId:    request.UserId,
+			RoleCode:  request.RoleCode,
+			StartsAt:  request.StartsAt,
+			ExpiresAt: request.ExpiresAt,
+			Reason:    request.Reason,
+			GrantedBy: request.ReviewerId,
+			RequestId: request.Id,
+		}
+		if err := insertRoleGrant(ctx, grant); err != nil {
+			return err
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   grant.TenantId,
+			OperatorId: grant.GrantedBy,
+			Action:     "role_grant.create",
+			Resource:   roleGrantTable,
+			ResourceId: strconv.FormatInt(grant.Id, 10),
+			After:      grant,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	InvalidateUserCache(ctx, request.UserId)
+	return &v1.RoleRequestApproveRes{
+		RoleRequestItem: requestToItem(request),
+		Grant:           grantToItem(grant, roleGrantNow()),
+	}, nil
+}
+
+// Reject closes a pending request without granting the role and notifies
+// the requester and the reviewer.
+func (s *sRole) Reject(ctx context.Context, id int64, comment string) (*v1.RoleRequestItem, error) {
+	request, err := s.review(ctx, id, RoleRequestRejected, comment, nil)
+	if err != nil {
+		return nil, err
+	}
+	return requestToItem(request), nil
+}
+
+// review moves a pending request of the caller's tenant to status inside a
+// transaction, runs apply, then audits the decision and notifies both
+// parties. Users cannot review their own requests.
+func (s *sRole) review(ctx context.Context, id int64, status, comment string,
+	apply func(ctx context.Context, request *roleRequest) error) (*roleRequest, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	domain := NormalizeDomain(p.TenantId)
+	var request *roleRequest
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		err := g.DB().Ctx(ctx).Model(roleRequestTable).
+			Where("id", id).
+			Where("tenant_id", domain).
+			LockUpdate().
+			Scan(&request)
+		if err != nil {
+			return err
+		}
+		if request == nil {
+			return gerror.NewCodef(consts.ErrorCodeRoleRequestNotFound, "role request %d not found", id)
+		}
+		if request.Status != RoleRequestPending {
+			return gerror.NewCodef(consts.ErrorCodeRoleRequestClosed, "role request %d is already %s", id, request.Status)
+		}
+		if request.UserId == p.UserId {
+			return gerror.NewCode(consts.ErrorCodeForbidden, "cannot review your own role request")
+		}
+		before := *request
+		request.Status = status
+		request.ReviewerId = p.UserId
+		request.ReviewComment = strings.TrimSpace(comment)
+		request.ReviewedAt = gtime.Now()
+		_, err = g.DB().Ctx(ctx).Model(roleRequestTable).Data(g.Map{
+			"status":         request.Status,
+			"reviewer_id":    request.ReviewerId,
+			"review_comment": request.ReviewComment,
+			"reviewed_at":    request.ReviewedAt,
+			"updated_at":     request.ReviewedAt,
+		}).Where("id", id).Update()
+		if err != nil {
+			return err
+		}
+		if apply != nil {
+			if err = apply(ctx, request); err != nil {
+				return err
+			}
+		}
+		if err = writeAuditLog(ctx, auditEntry{
+			TenantId:   domain,
+			OperatorId: p.UserId,
+			Action:     "role_request." + reviewAction(status),
+			Resource:   roleRequestTable,
+			ResourceId: strconv.FormatInt(id, 10),
+			Before:     &before,
+			After:      request,
+		}); err != nil {
+			return err
+		}
+		return notifyRoleRequestReviewed(ctx, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func reviewAction(status string) string {
+	if status == RoleRequestApproved {
+		return "approve"
+	}
+	return "reject"
+}
+
+// notifyRoleRequestCreated tells the approvers of the request's tenant that
+// the request awaits their review.
+func notifyRoleRequestCreated(ctx context.Context, enforcer casbin.IEnforcer, request *roleRequest) error {
+	approvers, err := roleApprovers(ctx, enforcer, request.TenantId, request.UserId)
+	if err != nil {
+		return err
+	}
+	title := fmt.Sprintf("Role request for %s awaits review", request.RoleCode)
+	content := roleRequestSummary(request)
+	if request.Reason != "" {
+		content += "\nReason: " + request.Reason
+	}
+	for _, approver := range approvers {
+		if err = notify(ctx, request.TenantId, approver, "role_request."+RoleRequestPending, title, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roleApprovers returns the users of domain who hold roleRequestReviewCode,
+// leaving out the requester who may not review its own
+// request.
+func roleApprovers(ctx context.Context, enforcer casbin.IEnforcer, domain, requesterID string) ([]string, error) {
+	users, grants, err := loadRoleApproverCandidates(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	grantsByUser := make(map[string][]*roleGrant)
+	for _, grant := range grants {
+		grantsByUser[grant.UserId] = append(grantsByUser[grant.UserId], grant)
+	}
+	now := roleGrantNow()
+	// Menu grants are looked up once per role; rules naming the code may
+	// also be held by users directly.
+	reviews := make(map[string]bool)
+	roleReviews := func(role string) (bool, error) {
+		if ok, seen := reviews[role]; seen {
+			return ok, nil
+		}
+		codes, err := loadRoleMenuCodes(ctx, domain, []string{role})
+		if err != nil {
+			return false, err
+		}
+		reviews[role] = slices.Contains(codes, roleRequestReviewCode)
+		return reviews[role], nil
+	}
+	approvers := make([]string, 0)
+	for _, user := range users {
+		if user.Id == requesterID {
+			continue
+		}
+		_, subjects := AuthzSubjects(user, activeGrantRoles(grantsByUser[user.Id], domain, now)...)
+		roles, err := effectiveRoles(enforcer, domain, subjects)
+		if err != nil {
+			return nil, err
+		}
+		approves := slices.Contains(accessCodesFromCasbin(enforcer, domain, roles), roleRequestReviewCode)
+		for _, role := range roles {
+			if approves {
+				break
+			}
+			if role == user.Id {
+				continue
+			}
+			if approves, err = roleReviews(role); err != nil {
+				return nil, err
+			}
+		}
+		if approves {
+			approvers = append(approvers, user.Id)
+		}
+	}
+	return approvers, nil
+}
+
+// notifyRoleRequestReviewed tells the requester the outcome of the request
+// and confirms the decision to the reviewer.
+func notifyRoleRequestReviewed(ctx context.Context, request *roleRequest) error {
+	kind := "role_request." + request.Status
+	title := fmt.Sprintf("Role request for %s %s", request.RoleCode, request.Status)
+	content := roleRequestSummary(request)
+	if err := notify(ctx, request.TenantId, request.UserId, kind, title, content); err != nil {
+		return err
+	}
+	return notify(ctx, request.TenantId, request.ReviewerId, kind,
+		fmt.Sprintf("You %s a role request for %s", request.Status, request.RoleCode), content)
+}
+
+func roleRequestSummary(request *roleRequest) string {
+	parts := []string{"Role: " + request.RoleCode, "Period: " + grantPeriod(request.StartsAt, request.ExpiresAt)}
+	if request.ReviewComment != "" {
+		parts = append(parts, "Comment: "+request.ReviewComment)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func grantPeriod(startsAt, expiresAt *gtime.Time) string {
+	from, until := "now", "revoked"
+	if startsAt != nil {
+		from = startsAt.String()
+	}
+	if expiresAt != nil {
+		until = expiresAt.String()
+	}
+	return from + " until " + until
+}
+
+// validateRoleGrant checks that role exists in domain, that userID belongs
+// to domain and that the period has not ended.
+func validateRoleGrant(ctx context.Context, domain, userID, role string, startsAt, expiresAt *gtime.Time) error {
+	if err := validateGrantPeriod(startsAt, expiresAt, roleGrantNow()); err != nil {
+		return err
+	}
+	exists, err := roleExists(ctx, domain, role)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return gerror.NewCodef(consts.ErrorCodeRoleGrantInvalid, "role %s does not exist", role)
+	}
+	if !isUUID(userID) {
+		return gerror.NewCodef(consts.ErrorCodeUserNotFound, "user %s not found", userID)
+	}
+	count, err := dao.SysUser.Ctx(ctx).
+		Where(dao.SysUser.Columns().Id, userID).
+		Where(dao.SysUser.Columns().TenantId, domain).
+		Count()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return gerror.NewCodef(consts.ErrorCodeUserNotFound, "user %s not found", userID)
+	}
+	return nil
+}
+
+// validateGrantPeriod requires a period that ends after now and after it starts.
+func validateGrantPeriod(startsAt, expiresAt *gtime.Time, now time.Time) error {
+	if expiresAt == nil {
+		return nil
+	}
+	if !expiresAt.Time.After(now) {
+		return gerror.NewCode(consts.ErrorCodeRoleGrantInvalid, "expiresAt must be in the future")
+	}
+	if startsAt != nil && !expiresAt.Time.After(startsAt.Time) {
+		return gerror.NewCode(consts.ErrorCodeRoleGrantInvalid, "expiresAt must be after startsAt")
+	}
+	return nil
+}
+
+func insertRoleGrant(ctx context.Context, grant *roleGrant) error {
+	data := g.Map{
+		"tenant_id":  grant.TenantId,
+		"user_id":    grant.UserId,
+		"role_code":  grant.RoleCode,
+		"starts_at":  grant.StartsAt,
+		"expires_at": grant.ExpiresAt,
+		"reason":     grant.Reason,
+	}
+	if isUUID(grant.GrantedBy) {
+		data["granted_by"] = grant.GrantedBy
+	}
+	if grant.RequestId > 0 {
+		data["request_id"] = grant.RequestId
+	}
+	id, err := g.DB().Ctx(ctx).Model(roleGrantTable).Data(data).InsertAndGetId()
+	if err != nil {
+		return err
+	}
+	grant.Id = id
+	// The user's roles are managed through grants from now on, so it no
+	// longer falls back to super once they expire.
+	_, err = dao.SysUser.Ctx(ctx).
+		Data(do.SysUser{LegacySuper: false}).
+		Where(dao.SysUser.Columns().Id, grant.UserId).
+		Update()
+	return err
+}
+
+func listRoleRequests(model *gdb.Model, page, pageSize int) (*v1.RoleRequestListRes, error) {
+	var requests []*roleRequest
+	total := 0
+	if err := model.OrderDesc("id").Page(page, pageSize).ScanAndCount(&requests, &total, false); err != nil {
+		return nil, err
+	}
+	items := make([]*v1.RoleRequestItem, 0, len(requests))
+	for _, request := range requests {
+		items = append(items, requestToItem(request))
+	}
+	return &v1.RoleRequestListRes{Items: items, Total: total}, nil
+}
+
+func grantToItem(grant *roleGrant, now time.Time) *v1.RoleGrantItem {
+	if grant == nil {
+		return nil
+	}
+	return &v1.RoleGrantItem{
+		Id:        grant.Id,
+		TenantId:  grant.TenantId,
+		UserId:    grant.UserId,
+		Role:      grant.RoleCode,
+		StartsAt:  grant.StartsAt,
+		ExpiresAt: grant.ExpiresAt,
+		Reason:    grant.Reason,
+		GrantedBy: grant.GrantedBy,
+		RequestId: grant.RequestId,
+		Active:    grant.activeAt(now),
+		CreatedAt: grant.CreatedAt,
+	}
+}
+
+func requestToItem(request *roleRequest) *v1.RoleRequestItem {
+	return &v1.RoleRequestItem{
+		Id:            request.Id,
+		TenantId:      request.TenantId,
+		UserId:        request.UserId,
+		Role:          request.RoleCode,
+		StartsAt:      request.StartsAt,
+		ExpiresAt:     request.ExpiresAt,
+		Reason:        request.Reason,
+		Status:        request.Status,
+		ReviewerId:    request.ReviewerId,
+		ReviewComment: request.ReviewComment,
+		ReviewedAt:    request.ReviewedAt,
+		CreatedAt:     request.CreatedAt,
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+const (
+	roleGrantTable = "sys_user_role_grant"
+
+	// roleGrantDefaultPurgeCron runs PurgeExpiredRoleGrants when
+	// auth.roleGrantPurgeCron is not set.
+	roleGrantDefaultPurgeCron = "@every 5m"
+)
+
+var (
+	// roleGrantNow is the clock time-bound grants are evaluated against.
+	roleGrantNow = time.Now
+
+	// loadRoleGrants reads the grants of a user that have not expired yet.
+	loadRoleGrants = func(ctx context.Context, userID string) ([]*roleGrant, error) {
+		var grants []*roleGrant
+		err := g.DB().Ctx(ctx).Model(roleGrantTable).
+			Where("user_id", userID).
+			Where("(expires_at IS NULL OR expires_at > ?)", roleGrantNow()).
+			OrderAsc("id").
+			Scan(&grants)
+		return grants, err
+	}
+)
+
+// roleGrant is a sys_user_role_grant row: RoleCode is held by UserId inside
+// TenantId from StartsAt until ExpiresAt, each bound being optional.
+type roleGrant struct {
+	Id        int64       `json:"id"        orm:"id"`
+	TenantId  string      `json:"tenantId"  orm:"tenant_id"`
+	UserId    string      `json:"userId"    orm:"user_id"`
+	RoleCode  string      `json:"roleCode"  orm:"role_code"`
+	StartsAt  *gtime.Time `json:"startsAt"  orm:"starts_at"`
+	ExpiresAt *gtime.Time `json:"expiresAt" orm:"expires_at"`
+	Reason    string      `json:"reason"    orm:"reason"`
+	GrantedBy string      `json:"grantedBy" orm:"granted_by"`
+	RequestId int64       `json:"requestId" orm:"request_id"`
+	CreatedAt *gtime.Time `json:"createdAt" orm:"created_at"`
+}
+
+// activeAt reports whether the grant applies at now.
+func (grant *roleGrant) activeAt(now time.Time) bool {
+	if grant.StartsAt != nil && now.Before(grant.StartsAt.Time) {
+		return false
+	}
+	if grant.ExpiresAt != nil && !now.Before(grant.ExpiresAt.Time) {
+		return false
+	}
+	return true
+}
+
+// activeGrantRoles returns the roles granted inside tenantID that apply at now.
+func activeGrantRoles(grants []*roleGrant, tenantID string, now time.Time) []string {
+	set := make(map[string]struct{})
+	for _, grant := range grants {
+		if grant.TenantId != tenantID || !grant.activeAt(now) {
+			continue
+		}
+		if role := strings.TrimSpace(grant.RoleCode); role != "" {
+			set[role] = struct{}{}
+		}
+	}
+	roles := make([]string, 0, len(set))
+	for role := range set {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// grantedRoles returns the time-bound roles userID holds inside tenantID
+// right now. Grants are cached like users, but their validity is checked on
+// every call so an expired grant stops applying immediately.
+func grantedRoles(ctx context.Context, userID, tenantID string) ([]string, error) {
+	value, err := userCache.GetOrSetFuncLock(ctx, roleGrantCacheKey(userID), func(ctx context.Context) (interface{}, error) {
+		grants, err := loadRoleGrants(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if grants == nil {
+			grants = []*roleGrant{}
+		}
+		return grants, nil
+	}, userCacheTTL(ctx))
+	if err != nil || value == nil || value.IsNil() {
+		return nil, err
+	}
+	grants, _ := value.Val().([]*roleGrant)
+	return activeGrantRoles(grants, NormalizeDomain(tenantID), roleGrantNow()), nil
+}
+
+func roleGrantCacheKey(userID string) string {
+	return "grants:" + userID
+}
+
+// PurgeExpiredRoleGrants deletes expired grants and records each removal in
+// the audit log. It returns the number of grants removed.
+func PurgeExpiredRoleGrants(ctx context.Context) (int, error) {
+	var expired []*roleGrant
+	err := g.DB().Ctx(ctx).Model(roleGrantTable).
+		Where("expires_at <= ?", roleGrantNow()).
+		OrderAsc("id").
+		Scan(&expired)
+	if err != nil || len(expired) == 0 {
+		return 0, err
+	}
+	purged := 0
+	for _, grant := range expired {
+		result, err := g.DB().Ctx(ctx).Model(roleGrantTable).Where("id", grant.Id).Delete()
+		if err != nil {
+			return purged, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			// Another instance purged it first.
+			continue
+		}
+		purged++
+		InvalidateUserCache(ctx, grant.UserId)
+		if err = writeAuditLog(ctx, auditEntry{
+			TenantId:   grant.TenantId,
+			Action:     "role_grant.expire",
+			Resource:   roleGrantTable,
+			ResourceId: strconv.FormatInt(grant.Id, 10),
+			Before:     grant,
+		}); err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
+
+// RoleGrantPurgeCron returns the gcron pattern of the expired grant purge job.
+func RoleGrantPurgeCron(ctx context.Context) string {
+	v, err := g.Cfg().Get(ctx, "auth.roleGrantPurgeCron")
+	if err != nil || v == nil || strings.TrimSpace(v.String()) == "" {
+		return roleGrantDefaultPurgeCron
+	}
+	return strings.TrimSpace(v.String())
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"backend/api/role/v1"
+	"backend/internal/consts"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// roleRef is the identity of a sys_role row.
+type roleRef struct {
+	Id       string `orm:"id"`
+	TenantId string `orm:"tenant_id"`
+	Code     string `orm:"code"`
+}
+
+// ListMenus returns the ids of the menus and buttons granted to role in the
+// caller's tenant.
+func (s *sRole) ListMenus(ctx context.Context, role string) (*v1.RoleMenuListRes, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := findRole(ctx, NormalizeDomain(p.TenantId), role)
+	if err != nil {
+		return nil, err
+	}
+	menuIDs, err := roleMenuIDs(ctx, ref.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.RoleMenuListRes{Role: ref.Code, MenuIds: menuIDs}, nil
+}
+
+// SaveMenus replaces the menus and buttons granted to role in the caller's
+// tenant. Every menu must belong to the tenant.
+func (s *sRole) SaveMenus(ctx context.Context, role string, menuIds []string) (*v1.RoleMenuListRes, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	domain := NormalizeDomain(p.TenantId)
+	ref, err := findRole(ctx, domain, role)
+	if err != nil {
+		return nil, err
+	}
+	after, err := validateTenantMenus(ctx, domain, menuIds)
+	if err != nil {
+		return nil, err
+	}
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		before, err := roleMenuIDs(ctx, ref.Id)
+		if err != nil {
+			return err
+		}
+		if _, err = g.DB().Ctx(ctx).Model(roleMenuTable).Where("role_id", ref.Id).Delete(); err != nil {
+			return err
+		}
+		if len(after) > 0 {
+			rows := make(g.List, 0, len(after))
+			for _, menuID := range after {
+				rows = append(rows, g.Map{"role_id": ref.Id, "menu_id": menuID})
+			}
+			if _, err = g.DB().Ctx(ctx).Model(roleMenuTable).Data(rows).Insert(); err != nil {
+				return err
+			}
+		}
+		return writeAuditLog(ctx, auditEntry{
+			TenantId:   domain,
+			OperatorId: p.UserId,
+			Action:     "role_menu.update",
+			Resource:   roleMenuTable,
+			ResourceId: ref.Code,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.RoleMenuListRes{Role: ref.Code, MenuIds: after}, nil
+}
+
+// findRole returns the role with the given code in domain.
+func findRole(ctx context.Context, domain, code string) (*roleRef, error) {
+	code = strings.TrimSpace(code)
+	if !isUUID(domain) || code == "" {
+		return nil, gerror.NewCodef(consts.ErrorCodeRoleNotFound, "role %s not found", code)
+	}
+	var ref *roleRef
+	err := g.DB().Ctx(ctx).Model(roleTable).
+		Fields("id", "tenant_id", "code").
+		Where("tenant_id", domain).
+		Where("code", code).
+		Where("deleted_at is null").
+		Scan(&ref)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, gerror.NewCodef(consts.ErrorCodeRoleNotFound, "role %s not found", code)
+	}
+	return ref, nil
+}
+
+// roleMenuIDs returns the sorted ids of the menus granted to the role.
+func roleMenuIDs(ctx context.Context, roleID string) ([]string, error) {
+	values, err := g.DB().Ctx(ctx).Model(roleMenuTable).
+		Fields("menu_id").
+		Where("role_id", roleID).
+		OrderAsc("menu_id").
+		Array()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		ids = append(ids, v.String())
+	}
+	return ids, nil
+}
+
+// validateTenantMenus returns the distinct, sorted menu ids after checking
+// that each one is a live menu of domain.
+func validateTenantMenus(ctx context.Context, domain string, menuIds []string) ([]string, error) {
+	set := make(map[string]struct{}, len(menuIds))
+	for _, id := range menuIds {
+		id = strings.TrimSpace(id)
+		if !isUUID(id) {
+			return nil, gerror.NewCodef(consts.ErrorCodeMenuNotFound, "menu %s not found", id)
+		}
+		set[id] = struct{}{}
+	}
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	values, err := g.DB().Ctx(ctx).Model(menuTable).
+		Fields("id").
+		Where("tenant_id", domain).
+		WhereIn("id", ids).
+		Where("deleted_at is null").
+		Array()
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		found[v.String()] = struct{}{}
+	}
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			return nil, gerror.NewCodef(consts.ErrorCodeMenuNotFound, "menu %s not found", id)
+		}
+	}
+	return ids, nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"regexp"
 	"strings"
 
 	"backend/internal/consts"
@@ -11,6 +12,8 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
 func parseRoles(raw string) []string {
 	roles := make([]string, 0)
 	if raw == "" {
@@ -64,3 +67,7 @@ func ParseAccessToken(tokenStr string) (jwt.MapClaims, error) {
 func ResolveAccessToken(ctx context.Context, provided string) (string, error) {
 	return resolveAccessToken(ctx, provided)
 }
+
+func isUUID(value string) bool {
+	return uuidPattern.MatchString(value)
+}
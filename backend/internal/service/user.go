@@ -2,17 +2,81 @@ package service
 
 import (
 	"context"
+	"strings"
 
 	"backend/api/user/v1"
 	"backend/internal/consts"
 	"backend/internal/dao"
 	"backend/internal/model/entity"
 
+	"github.com/gogf/gf/v2/database/gdb"
 	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
 )
 
+const userTable = "sys_user"
+
 var (
 	localUser IUser
+
+	// saveUser writes data to the live user id and records the change as
+	// action in one transaction, returning the user as written. With tenantID
+	// set the user must belong to it and be visible in the data scope of the
+	// request's principal, and the change is logged in tenantID; otherwise it
+	// is logged in the platform domain. Callers invalidate the user cache.
+	saveUser = func(ctx context.Context, tenantID, operatorID, action, id string, data g.Map) (*entity.SysUser, error) {
+		columns := dao.SysUser.Columns()
+		var after *entity.SysUser
+		err := g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+			auditTenant := consts.PlatformDomain
+			if tenantID != "" {
+				var visible *entity.SysUser
+				err := userListModel(dao.SysUser.Ctx(ctx), tenantID, v1.UserListReq{}).
+					Where(columns.Id, id).
+					Scan(&visible)
+				if err != nil {
+					return err
+				}
+				if visible == nil {
+					return gerror.NewCodef(consts.ErrorCodeUserNotFound, "user %s not found", id)
+				}
+				auditTenant = tenantID
+			}
+			var before *entity.SysUser
+			err := dao.SysUser.Ctx(ctx).
+				Where(columns.Id, id).
+				WhereNull(columns.DeletedAt).
+				LockUpdate().
+				Scan(&before)
+			if err != nil {
+				return err
+			}
+			if before == nil {
+				return gerror.NewCodef(consts.ErrorCodeUserNotFound, "user %s not found", id)
+			}
+			data[columns.UpdatedAt] = gtime.Now()
+			if _, err = dao.SysUser.Ctx(ctx).Data(data).Where(columns.Id, id).Update(); err != nil {
+				return err
+			}
+			if err = dao.SysUser.Ctx(ctx).Unscoped().Where(columns.Id, id).Scan(&after); err != nil {
+				return err
+			}
+			return writeAuditLog(ctx, auditEntry{
+				TenantId:   auditTenant,
+				OperatorId: operatorID,
+				Action:     action,
+				Resource:   userTable,
+				ResourceId: id,
+				Before:     userAuditItem(before),
+				After:      userAuditItem(after),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		return after, nil
+	}
 )
 
 // User returns the user service instance.
@@ -39,35 +103,24 @@ func NewUser() *sUser {
 // IUser defines the user service interface.
 type IUser interface {
 	Info(ctx context.Context, token string) (res *v1.UserInfoRes, err error)
+	List(ctx context.Context, in v1.UserListReq) (*v1.UserListRes, error)
+	Update(ctx context.Context, in v1.UserUpdateReq) (*v1.UserItem, error)
+	SetStatus(ctx context.Context, in v1.UserStatusReq) (*v1.UserItem, error)
+	Delete(ctx context.Context, id string) error
 }
 
 type sUser struct{}
 
-// Info returns the current authenticated user's profile by validating the JWT.
+// Info returns the current authenticated user's profile, using the principal
+// resolved by the middleware or else validating token.
 func (s *sUser) Info(ctx context.Context, token string) (res *v1.UserInfoRes, err error) {
-	claims, err := parseToken(token)
-	if err != nil {
-		return nil, err
-	}
-
-	id, _ := claims["id"].(string)
-	if id == "" {
-		return nil, gerror.NewCode(consts.ErrorCodeUnauthorized, "user id not found in token")
-	}
-
-	var user entity.SysUser
-	err = dao.SysUser.Ctx(ctx).Where(dao.SysUser.Columns().Id, id).Scan(&user)
-	if err != nil {
-		return nil, err
-	}
-	if user.Id == "" {
-		return nil, gerror.NewCode(consts.ErrorCodeUserNotFound, "user not found")
-	}
-
-	roles := parseRoles(user.Roles)
-	if len(roles) == 0 {
-		roles = []string{"super"}
+	p := PrincipalFromCtx(ctx)
+	if p == nil {
+		if p, err = principalFromToken(ctx, token); err != nil {
+			return nil, err
+		}
 	}
+	user := p.User
 
 	homePath := user.HomePath
 	if homePath == "" {
@@ -79,10 +132,184 @@ func (s *sUser) Info(ctx context.Context, token string) (res *v1.UserInfoRes, er
 		Username: user.Username,
 		RealName: user.RealName,
 		Avatar:   user.Avatar,
-		Roles:    roles,
+		Roles:    p.Roles,
 		Desc:     user.RealName,
 		HomePath: homePath,
 		Token:    token,
 	}
 	return
 }
+
+// List returns a page of the users of the caller's tenant that the data
+// scope of the caller's roles allows.
+func (s *sUser) List(ctx context.Context, in v1.UserListReq) (*v1.UserListRes, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if deptID := strings.TrimSpace(in.DeptId); deptID != "" && !isUUID(deptID) {
+		return &v1.UserListRes{Items: []*v1.UserItem{}}, nil
+	}
+	var users []*entity.SysUser
+	total := 0
+	model := userListModel(dao.SysUser.Ctx(ctx), NormalizeDomain(p.TenantId), in)
+	if err = model.Page(in.Page, in.PageSize).ScanAndCount(&users, &total, false); err != nil {
+		return nil, err
+	}
+	items := make([]*v1.UserItem, 0, len(users))
+	for _, user := range users {
+		items = append(items, userToItem(user))
+	}
+	return &v1.UserListRes{Items: items, Total: total}, nil
+}
+
+// Update replaces the profile and department of a user of the caller's
+// tenant. An empty DeptId removes the user from its department.
+func (s *sUser) Update(ctx context.Context, in v1.UserUpdateReq) (*v1.UserItem, error) {
+	p, domain, id, err := userOperator(ctx, in.Id)
+	if err != nil {
+		return nil, err
+	}
+	var dept interface{}
+	if deptID := strings.TrimSpace(in.DeptId); deptID != "" {
+		if err = checkUserDept(ctx, domain, deptID); err != nil {
+			return nil, err
+		}
+		dept = deptID
+	}
+	columns := dao.SysUser.Columns()
+	user, err := saveUser(ctx, domain, p.UserId, "user.update", id, g.Map{
+		columns.RealName: strings.TrimSpace(in.RealName),
+		columns.Avatar:   strings.TrimSpace(in.Avatar),
+		columns.HomePath: strings.TrimSpace(in.HomePath),
+		columns.DeptId:   dept,
+	})
+	if err != nil {
+		return nil, err
+	}
+	InvalidateUserCache(ctx, user.Id)
+	return userToItem(user), nil
+}
+
+// SetStatus enables or disables a user of the caller's tenant. Callers cannot
+// disable themselves.
+func (s *sUser) SetStatus(ctx context.Context, in v1.UserStatusReq) (*v1.UserItem, error) {
+	p, domain, id, err := userOperator(ctx, in.Id)
+	if err != nil {
+		return nil, err
+	}
+	if id == p.UserId && in.Status == 0 {
+		return nil, gerror.NewCode(consts.ErrorCodeUserInvalid, "you cannot disable yourself")
+	}
+	user, err := saveUser(ctx, domain, p.UserId, "user.status", id, g.Map{
+		dao.SysUser.Columns().Status: in.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	InvalidateUserCache(ctx, user.Id)
+	return userToItem(user), nil
+}
+
+// Delete soft deletes a user of the caller's tenant. Callers cannot delete
+// themselves.
+func (s *sUser) Delete(ctx context.Context, id string) error {
+	p, domain, id, err := userOperator(ctx, id)
+	if err != nil {
+		return err
+	}
+	if id == p.UserId {
+		return gerror.NewCode(consts.ErrorCodeUserInvalid, "you cannot delete yourself")
+	}
+	if _, err = saveUser(ctx, domain, p.UserId, "user.delete", id, g.Map{
+		dao.SysUser.Columns().DeletedAt: gtime.Now(),
+	}); err != nil {
+		return err
+	}
+	InvalidateUserCache(ctx, id)
+	return nil
+}
+
+// userOperator returns the caller, the tenant whose users it manages and the
+// trimmed id of the user it targets.
+func userOperator(ctx context.Context, id string) (*Principal, string, string, error) {
+	p, err := ResolvePrincipal(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+	id = strings.TrimSpace(id)
+	if !isUUID(id) {
+		return nil, "", "", gerror.NewCodef(consts.ErrorCodeUserNotFound, "user %s not found", id)
+	}
+	return p, NormalizeDomain(p.TenantId), id, nil
+}
+
+// checkUserDept requires deptID to be a live department of domain.
+func checkUserDept(ctx context.Context, domain, deptID string) error {
+	if !isUUID(deptID) {
+		return gerror.NewCodef(consts.ErrorCodeUserInvalid, "department %s not found", deptID)
+	}
+	count, err := g.DB().Ctx(ctx).Model(deptTable).
+		Where("id", deptID).
+		Where("tenant_id", domain).
+		Where("deleted_at is null").
+		Count()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return gerror.NewCodef(consts.ErrorCodeUserInvalid, "department %s not found", deptID)
+	}
+	return nil
+}
+
+// userToItem converts a sys_user row for the users page.
+func userToItem(user *entity.SysUser) *v1.UserItem {
+	return &v1.UserItem{
+		Id:        user.Id,
+		Username:  user.Username,
+		RealName:  user.RealName,
+		DeptId:    user.DeptId,
+		Status:    user.Status,
+		Roles:     parseRoles(user.Roles),
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// userAuditItem returns the columns of user recorded in the audit log,
+// leaving out the password.
+func userAuditItem(user *entity.SysUser) g.Map {
+	if user == nil {
+		return nil
+	}
+	return g.Map{
+		"id":        user.Id,
+		"tenantId":  user.TenantId,
+		"username":  user.Username,
+		"realName":  user.RealName,
+		"avatar":    user.Avatar,
+		"homePath":  user.HomePath,
+		"deptId":    user.DeptId,
+		"status":    user.Status,
+		"roles":     parseRoles(user.Roles),
+		"deletedAt": user.DeletedAt,
+	}
+}
+
+// userListModel restricts model, a sys_user model carrying the request
+// context, to the users of tenantID matching in and visible in the data
+// scope of the request's principal.
+func userListModel(model *gdb.Model, tenantID string, in v1.UserListReq) *gdb.Model {
+	columns := dao.SysUser.Columns()
+	model = model.Handler(DataScopeHandler).
+		Fields(columns.Id, columns.Username, columns.RealName, columns.DeptId, columns.Status, columns.Roles, columns.CreatedAt).
+		Where(columns.TenantId, tenantID).
+		WhereNull(columns.DeletedAt)
+	if username := strings.TrimSpace(in.Username); username != "" {
+		model = model.WhereLike(columns.Username, "%"+username+"%")
+	}
+	if deptID := strings.TrimSpace(in.DeptId); deptID != "" {
+		model = model.Where(columns.DeptId, deptID)
+	}
+	return model.OrderAsc(columns.Username)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"backend/api/user/v1"
+	"backend/internal/consts"
+	"backend/internal/dao"
+	"backend/internal/model/entity"
+
+	_ "github.com/gogf/gf/contrib/drivers/pgsql/v2"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+// stubDataScope gives every role the scope *scope points to and every table
+// the dept_id and created_by columns.
+func stubDataScope(tb testing.TB, scope *string) {
+	tb.Helper()
+	previousScopes, previousColumns := loadRoleDataScopes, dataScopeColumns
+	loadRoleDataScopes = func(ctx context.Context, domain string, roles []string) ([]string, error) {
+		return []string{*scope}, nil
+	}
+	dataScopeColumns = func(m *gdb.Model) (bool, bool, error) {
+		return true, true, nil
+	}
+	tb.Cleanup(func() {
+		loadRoleDataScopes, dataScopeColumns = previousScopes, previousColumns
+	})
+}
+
+func TestUserListModel_DataScope(t *testing.T) {
+	// The database is never reached: gdb.ToSQL only renders the statements.
+	db, err := gdb.New(gdb.ConfigNode{Type: "pgsql", Link: "pgsql:test:test@tcp(127.0.0.1:5432)/test"})
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	listSQL := func(p *Principal) string {
+		ctx := WithPrincipal(context.Background(), p)
+		sql, err := gdb.ToSQL(ctx, func(ctx context.Context) error {
+			_, err := userListModel(db.Model("sys_user").Safe().Ctx(ctx), "t1", v1.UserListReq{}).All()
+			return err
+		})
+		if err != nil {
+			t.Fatalf("failed to render query: %v", err)
+		}
+		return sql
+	}
+	staff := func() *Principal {
+		return &Principal{UserId: "u1", TenantId: "t1", Roles: []string{"staff"}, User: &entity.SysUser{Id: "u1", DeptId: "d2"}}
+	}
+
+	scope := DataScopeDept
+	stubDataScope(t, &scope)
+
+	gtest.C(t, func(t *gtest.T) {
+		sql := listSQL(staff())
+		t.Assert(strings.Contains(sql, `("tenant_id"='t1')`), true)
+		t.Assert(strings.Contains(sql, "(dept_id IN('d2'))"), true)
+		t.Assert(strings.Contains(sql, "created_by"), false)
+
+		scope = DataScopeSelf
+		sql = listSQL(staff())
+		t.Assert(strings.Contains(sql, "(created_by = 'u1')"), true)
+		t.Assert(strings.Contains(sql, "dept_id IN"), false)
+
+		scope = DataScopeAll
+		sql = listSQL(staff())
+		t.Assert(strings.Contains(sql, "dept_id IN"), false)
+		t.Assert(strings.Contains(sql, "created_by"), false)
+
+		// super sees every user whatever its role rows say.
+		scope = DataScopeSelf
+		sql = listSQL(&Principal{UserId: "u0", TenantId: "t1", Roles: []string{"super"}})
+		t.Assert(strings.Contains(sql, "created_by"), false)
+	})
+}
+
+func TestUserChanges_InvalidateUserCache(t *testing.T) {
+	const (
+		operatorID = "10000000-0000-0000-0000-000000000001"
+		userID     = "10000000-0000-0000-0000-000000000002"
+	)
+	user := &entity.SysUser{Id: userID, TenantId: "t1", RealName: "Before", Status: 1}
+	stubLoadUser(t, user)
+	columns := dao.SysUser.Columns()
+	var actions []string
+	previous := saveUser
+	saveUser = func(ctx context.Context, tenantID, operatorID, action, id string, data g.Map) (*entity.SysUser, error) {
+		actions = append(actions, action)
+		for column, value := range data {
+			switch column {
+			case columns.RealName:
+				user.RealName = value.(string)
+			case columns.Status:
+				user.Status = value.(int)
+			case columns.DeletedAt:
+				user.Id = ""
+			}
+		}
+		copied := *user
+		copied.Id = id
+		return &copied, nil
+	}
+	t.Cleanup(func() { saveUser = previous })
+	ctx := WithPrincipal(context.Background(), &Principal{UserId: operatorID, TenantId: "t1", Roles: []string{"admin"}})
+	cached := func() *entity.SysUser {
+		cachedUser, err := cachedUser(ctx, userID)
+		if err != nil {
+			t.Fatalf("failed to load user: %v", err)
+		}
+		return cachedUser
+	}
+
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(cached().RealName, "Before")
+
+		item, err := User().Update(ctx, v1.UserUpdateReq{Id: userID, RealName: " After "})
+		t.AssertNil(err)
+		t.Assert(item.RealName, "After")
+		t.Assert(cached().RealName, "After")
+
+		_, err = User().SetStatus(ctx, v1.UserStatusReq{Id: userID, Status: 0})
+		t.AssertNil(err)
+		t.Assert(cached().Status, 0)
+
+		t.AssertNil(User().Delete(ctx, userID))
+		t.AssertNil(cached())
+		t.Assert(actions, []string{"user.update", "user.status", "user.delete"})
+
+		// Callers cannot lock themselves out.
+		_, err = User().SetStatus(ctx, v1.UserStatusReq{Id: operatorID, Status: 0})
+		t.Assert(gerror.Code(err), consts.ErrorCodeUserInvalid)
+		t.Assert(gerror.Code(User().Delete(ctx, operatorID)), consts.ErrorCodeUserInvalid)
+		t.Assert(len(actions), 3)
+	})
+}